@@ -1,16 +1,31 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/hibiken/asynq"
+	"github.com/lppduy/go-asynq-loadtest/internal/auth"
+	"github.com/lppduy/go-asynq-loadtest/internal/backpressure"
+	"github.com/lppduy/go-asynq-loadtest/internal/carrier"
 	"github.com/lppduy/go-asynq-loadtest/internal/config"
+	"github.com/lppduy/go-asynq-loadtest/internal/events"
 	"github.com/lppduy/go-asynq-loadtest/internal/handler"
+	"github.com/lppduy/go-asynq-loadtest/internal/middleware"
+	"github.com/lppduy/go-asynq-loadtest/internal/ratelimit"
 	"github.com/lppduy/go-asynq-loadtest/internal/repository"
 	"github.com/lppduy/go-asynq-loadtest/internal/service"
+	"github.com/lppduy/go-asynq-loadtest/internal/storage"
+	"github.com/lppduy/go-asynq-loadtest/internal/tasks"
+	"github.com/lppduy/go-asynq-loadtest/internal/webhook"
 	"github.com/lppduy/go-asynq-loadtest/pkg/database"
+	"github.com/lppduy/go-asynq-loadtest/pkg/metrics"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
@@ -24,12 +39,14 @@ func main() {
 
 	// Database configuration
 	dbConfig := database.Config{
-		Host:     cfg.Database.Host,
-		Port:     cfg.Database.Port,
-		User:     cfg.Database.User,
-		Password: cfg.Database.Password,
-		DBName:   cfg.Database.DBName,
-		SSLMode:  cfg.Database.SSLMode,
+		Driver:     cfg.Database.Driver,
+		SQLitePath: cfg.Database.SQLitePath,
+		Host:       cfg.Database.Host,
+		Port:       cfg.Database.Port,
+		User:       cfg.Database.User,
+		Password:   cfg.Database.Password,
+		DBName:     cfg.Database.DBName,
+		SSLMode:    cfg.Database.SSLMode,
 	}
 
 	// Connect to database
@@ -38,26 +55,178 @@ func main() {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
-	// Run migrations
-	if err := database.AutoMigrate(db); err != nil {
-		log.Fatal("Failed to run migrations:", err)
+	// Schema changes are applied by cmd/migrate, never at boot (Connect creates
+	// the in-memory database with its schema)
+	if err := database.CheckSchema(db); err != nil {
+		log.Fatal("Database schema check failed:", err)
 	}
 
 	// Create Asynq client for enqueueing tasks
-	asynqClient := asynq.NewClient(asynq.RedisClientOpt{
+	redisOpt := asynq.RedisClientOpt{
 		Addr:     cfg.Redis.Addr,
 		Password: cfg.Redis.Password,
 		DB:       cfg.Redis.DB,
-	})
+	}
+	asynqClient := asynq.NewClient(redisOpt)
 	defer asynqClient.Close()
 
+	codec, err := tasks.CodecByName(cfg.Worker.PayloadCodec)
+	if err != nil {
+		log.Fatal("Invalid task payload codec:", err)
+	}
+	tasks.SetCodec(codec)
+
 	log.Printf("✅ Connected to Redis: %s", cfg.Redis.Addr)
+	log.Printf("📦 Task payload codec: %s", cfg.Worker.PayloadCodec)
+
+	// Inspector reads queue state (backpressure, admin endpoints)
+	inspector := asynq.NewInspector(redisOpt)
+	defer inspector.Close()
+
+	// Backpressure: watch queue depth/latency so the API can shed or reject load
+	var admission *handler.AdmissionControl
+	if cfg.Backpressure.Enabled {
+		monitor := backpressure.NewMonitor(inspector, backpressure.Config{
+			Queues:          []string{"critical", "high", "default", "low"},
+			RefreshInterval: cfg.Backpressure.RefreshInterval,
+			ShedBacklog:     cfg.Backpressure.ShedBacklog,
+			RejectBacklog:   cfg.Backpressure.RejectBacklog,
+			ShedLatency:     cfg.Backpressure.ShedLatency,
+			RejectLatency:   cfg.Backpressure.RejectLatency,
+		})
+		monitor.Start(context.Background())
+		monitor.LogConfig()
+
+		admission = &handler.AdmissionControl{
+			Monitor:      monitor,
+			RejectStatus: cfg.Backpressure.RejectStatus,
+			RetryAfter:   cfg.Backpressure.RetryAfter,
+		}
+	}
+
+	// Plain Redis client (rate limiting, live order updates)
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	defer redisClient.Close()
+
+	// Rate limiting backend (Redis shares buckets across API replicas)
+	var limiter ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		if cfg.RateLimit.Backend == "redis" {
+			limiter = ratelimit.NewRedisLimiter(redisClient)
+		} else {
+			limiter = ratelimit.NewMemoryLimiter(context.Background())
+		}
+		log.Printf("🚧 Rate limiting enabled (backend: %s)", cfg.RateLimit.Backend)
+	}
+	rateLimit := func(group string) gin.HandlerFunc {
+		if limiter == nil {
+			return func(c *gin.Context) { c.Next() }
+		}
+		limits := cfg.RateLimit.Groups[group]
+		return middleware.RateLimit(limiter, middleware.RateLimitPolicy{
+			Group:     group,
+			PerClient: ratelimit.Limit{Rate: limits.ClientRPS, Burst: limits.ClientBurst},
+			Global:    ratelimit.Limit{Rate: limits.GlobalRPS, Burst: limits.GlobalBurst},
+		})
+	}
+
+	// Authentication (API keys + HS256/RS256 JWT)
+	authenticate := func(c *gin.Context) { c.Next() }
+	requireAdmin := func(c *gin.Context) { c.Next() }
+	if cfg.Auth.Enabled {
+		authn, err := newAuthenticator(cfg.Auth)
+		if err != nil {
+			log.Fatal("Failed to configure authentication:", err)
+		}
+		authenticate = middleware.Authenticate(authn)
+		requireAdmin = middleware.RequireScope(auth.ScopeAdmin)
+		log.Println("🔐 Authentication enabled (API keys, JWT)")
+	} else {
+		log.Println("⚠️  Authentication disabled by AUTH_ENABLED=false, every caller is trusted")
+	}
 
 	// Initialize layers (Dependency Injection)
-	orderRepo := repository.NewGormOrderRepository(db)
-	orderService := service.NewOrderService(orderRepo)
+	// Webhooks: lifecycle events become signed webhook:deliver tasks
+	webhookRepo := repository.NewGormWebhookRepository(db)
+	dispatcher := webhook.NewDispatcher(webhookRepo, asynqClient)
+	webhookService := service.NewWebhookService(webhookRepo, dispatcher)
+
+	// Order writes are published for live streams and announced to webhooks
+	var orderRepo repository.OrderRepository = repository.NewGormOrderRepository(db)
+	inventoryRepo := repository.NewGormInventoryRepository(db)
+	orderClient := asynqClient
+	inMemory := cfg.Database.Driver == database.DriverMemory
+	if inMemory {
+		// Orders live in this process only: no worker could load them, so they
+		// get no tasks (they stay pending) and no webhook events
+		orderRepo = repository.NewMemoryOrderRepository()
+		orderClient = nil
+		created, _, err := service.SeedCatalog(context.Background(), inventoryRepo, 100, 100000)
+		if err != nil {
+			log.Fatal("Failed to seed in-memory catalog:", err)
+		}
+		log.Printf("⚠️  DB_DRIVER=memory: data is lost on restart, orders get no background tasks (%d products seeded)", created)
+	}
+	orderRepo = events.WithPublisher(orderRepo, events.NewPublisher(redisClient))
+	if !inMemory {
+		orderRepo = webhook.WithDispatcher(orderRepo, dispatcher)
+	}
+	couponRepo := repository.NewGormCouponRepository(db)
+	pricing, err := service.NewPricingRules(cfg.Pricing.TaxRates, cfg.Pricing.DefaultTaxRate,
+		cfg.Pricing.ShippingFees, cfg.Pricing.FreeShippingOver)
+	if err != nil {
+		log.Fatal("Failed to configure pricing:", err)
+	}
+	orderService := service.NewOrderService(orderRepo, inventoryRepo, couponRepo, repository.NewGormTransactor(db), pricing)
+	inventoryService := service.NewInventoryService(inventoryRepo)
+	couponService := service.NewCouponService(couponRepo)
+	analyticsService := service.NewAnalyticsService(repository.NewGormAnalyticsRepository(db))
+
+	// Invoice PDFs are written by the worker to the same store
+	blobs, err := storage.New(context.Background(), cfg.Storage.Backend, cfg.Storage.Dir, storage.S3Config{
+		Endpoint:  cfg.Storage.S3Endpoint,
+		Region:    cfg.Storage.S3Region,
+		Bucket:    cfg.Storage.S3Bucket,
+		AccessKey: cfg.Storage.S3AccessKey,
+		SecretKey: cfg.Storage.S3SecretKey,
+		UseSSL:    cfg.Storage.S3UseSSL,
+	})
+	if err != nil {
+		log.Fatal("Failed to open blob storage:", err)
+	}
+	invoiceService := service.NewInvoiceService(blobs, cfg.Storage.SignedURLExpiry)
+
+	// Carrier tracking callbacks advance shipments, and delivered orders are announced like any update
+	shippingCarrier, err := carrier.New(cfg.Carrier.Name, cfg.Carrier.WebhookSecret, cfg.Carrier.WebhookTolerance)
+	if err != nil {
+		log.Fatal("Failed to set up carrier:", err)
+	}
+	if cfg.Carrier.WebhookSecret == "" {
+		log.Println("⚠️  CARRIER_WEBHOOK_SECRET not set, carrier callbacks are not verified")
+	}
+	shipmentService := service.NewShipmentService(repository.NewGormShipmentRepository(db), orderRepo,
+		repository.NewGormTransactor(db), shippingCarrier)
 	taskRetention := time.Duration(cfg.Worker.RetentionMinutes) * time.Minute
-	orderHandler := handler.NewOrderHandler(orderService, asynqClient, taskRetention)
+	orderHandler := handler.NewOrderHandler(orderService, orderClient, inspector, taskRetention, admission, handler.BatchLimits{
+		MaxOrders:          cfg.OrderBatch.MaxOrders,
+		EnqueueConcurrency: cfg.OrderBatch.EnqueueConcurrency,
+	})
+	adminHandler := handler.NewAdminHandler(inspector)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	inventoryHandler := handler.NewInventoryHandler(inventoryService)
+	couponHandler := handler.NewCouponHandler(couponService)
+	analyticsHandler := handler.NewAnalyticsHandler(analyticsService)
+	invoiceHandler := handler.NewInvoiceHandler(orderService, invoiceService)
+	shipmentHandler := handler.NewShipmentHandler(orderService, shipmentService)
+
+	// SSE streams: one Redis subscription per replica, fanned out locally
+	hub := events.NewHub(context.Background(), redisClient)
+	go hub.Run(context.Background())
+	streamHandler := handler.NewStreamHandler(orderService, hub, cfg.Server.StreamHeartbeat, cfg.Server.StreamMaxDuration)
 
 	// Setup Gin router
 	router := gin.Default()
@@ -71,17 +240,84 @@ func main() {
 		})
 	})
 
+	// Prometheus metrics (admission decisions, queue depth)
+	if cfg.Monitoring.PrometheusEnabled {
+		router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	}
+
 	// API v1 routes
 	v1 := router.Group("/api/v1")
 	{
 		// Order endpoints
-		orders := v1.Group("/orders")
+		orders := v1.Group("/orders", authenticate, rateLimit("orders"))
+		{
+			orders.POST("", orderHandler.CreateOrder)                   // Create new order
+			orders.POST("/batch", orderHandler.CreateOrderBatch)        // Create up to ORDER_BATCH_MAX orders
+			orders.GET("", orderHandler.ListOrders)                     // List all orders
+			orders.GET("/:id", orderHandler.GetOrder)                   // Get order by ID
+			orders.PATCH("/:id", orderHandler.UpdateOrder)              // Edit items/address/notes before payment
+			orders.GET("/:id/status", orderHandler.GetOrderStatus)      // Get order status
+			orders.GET("/:id/stream", streamHandler.StreamOrder)        // Live status (SSE)
+			orders.POST("/:id/cancel", orderHandler.CancelOrder)        // Cancel order
+			orders.GET("/:id/invoice", invoiceHandler.GetInvoice)       // Invoice PDF
+			orders.GET("/:id/shipments", shipmentHandler.ListShipments) // Parcels with tracking history
+		}
+
+		// Carrier tracking callbacks (signed by the carrier, no API credentials)
+		v1.POST("/carriers/:carrier/webhook", rateLimit("carriers"), shipmentHandler.CarrierWebhook)
+
+		// Catalog and back-office writes share the admin rate limit
+		limitAdmin := rateLimit("admin")
+
+		// Product catalog (reads for any caller, writes need admin scope)
+		products := v1.Group("/products", authenticate)
+		{
+			products.POST("", requireAdmin, limitAdmin, inventoryHandler.CreateProduct)       // Add product with initial stock
+			products.GET("", inventoryHandler.ListProducts)                                   // List products
+			products.GET("/:id", inventoryHandler.GetProduct)                                 // Get product with stock
+			products.PUT("/:id", requireAdmin, limitAdmin, inventoryHandler.UpdateProduct)    // Update name/price/active
+			products.DELETE("/:id", requireAdmin, limitAdmin, inventoryHandler.DeleteProduct) // Remove from catalog
+		}
+
+		// Stock levels (admin scope)
+		inventory := v1.Group("/inventory", authenticate, requireAdmin, limitAdmin)
+		{
+			inventory.GET("", inventoryHandler.ListStock)                       // All stock levels
+			inventory.GET("/:product_id", inventoryHandler.GetStock)            // One stock level
+			inventory.PUT("/:product_id", inventoryHandler.SetStock)            // Set on-hand units
+			inventory.POST("/:product_id/adjust", inventoryHandler.AdjustStock) // Add/remove units
+		}
+
+		// Discount codes (admin scope)
+		coupons := v1.Group("/coupons", authenticate, requireAdmin, limitAdmin)
+		{
+			coupons.POST("", couponHandler.CreateCoupon)        // Create coupon
+			coupons.GET("", couponHandler.ListCoupons)          // List coupons with usage
+			coupons.GET("/:code", couponHandler.GetCoupon)      // Get coupon
+			coupons.PATCH("/:code", couponHandler.UpdateCoupon) // Enable/disable
+		}
+
+		// Webhook subscriptions (admin scope)
+		webhooks := v1.Group("/webhooks", authenticate, requireAdmin, limitAdmin)
 		{
-			orders.POST("", orderHandler.CreateOrder)           // Create new order
-			orders.GET("", orderHandler.ListOrders)             // List all orders
-			orders.GET("/:id", orderHandler.GetOrder)           // Get order by ID
-			orders.GET("/:id/status", orderHandler.GetOrderStatus) // Get order status
-			orders.POST("/:id/cancel", orderHandler.CancelOrder)   // Cancel order
+			webhooks.POST("", webhookHandler.CreateWebhook)                // Subscribe endpoint
+			webhooks.GET("", webhookHandler.ListWebhooks)                  // List subscriptions
+			webhooks.GET("/:id", webhookHandler.GetWebhook)                // Get subscription
+			webhooks.DELETE("/:id", webhookHandler.DeleteWebhook)          // Unsubscribe
+			webhooks.GET("/:id/deliveries", webhookHandler.ListDeliveries) // Delivery log
+			webhooks.POST("/:id/test", webhookHandler.TestWebhook)         // Send sample event
+		}
+
+		// Analytics rollups of recorded orders (admin scope)
+		analytics := v1.Group("/analytics", authenticate, requireAdmin, rateLimit("analytics"))
+		{
+			analytics.GET("/summary", analyticsHandler.Summary) // Per minute/hour rollups
+		}
+
+		// Admin endpoints (admin scope)
+		admin := v1.Group("/admin", authenticate, requireAdmin, limitAdmin)
+		{
+			admin.GET("/queues", adminHandler.ListQueues) // Queue depth overview
 		}
 	}
 
@@ -93,7 +329,21 @@ func main() {
 	log.Println("   - GET    /api/v1/orders          (List orders)")
 	log.Println("   - GET    /api/v1/orders/:id      (Get order)")
 	log.Println("   - GET    /api/v1/orders/:id/status (Get status)")
+	log.Println("   - GET    /api/v1/orders/:id/stream (Live status, SSE)")
 	log.Println("   - POST   /api/v1/orders/:id/cancel (Cancel order)")
+	log.Println("   - GET    /api/v1/orders/:id/invoice (Invoice PDF)")
+	log.Println("   - GET    /api/v1/orders/:id/shipments (Parcels and tracking)")
+	log.Println("   - POST   /api/v1/carriers/:carrier/webhook (Carrier tracking callbacks)")
+	log.Println("   - GET    /api/v1/products        (List products)")
+	log.Println("   - GET    /api/v1/inventory       (Stock levels, admin)")
+	log.Println("   - POST   /api/v1/coupons         (Create coupon, admin)")
+	log.Println("   - POST   /api/v1/webhooks        (Create webhook, admin)")
+	log.Println("   - POST   /api/v1/webhooks/:id/test (Send test event, admin)")
+	log.Println("   - GET    /api/v1/admin/queues    (Queue overview, admin)")
+	log.Println("   - GET    /api/v1/analytics/summary (Order rollups, admin)")
+	if cfg.Monitoring.PrometheusEnabled {
+		log.Println("   - GET    /metrics                (Prometheus metrics)")
+	}
 	log.Println("")
 	log.Printf("💡 Try: curl http://localhost:%s/health", cfg.Server.Port)
 	log.Println("")
@@ -105,3 +355,34 @@ func main() {
 		log.Fatal("Failed to start server:", err)
 	}
 }
+
+// newAuthenticator builds the authenticator from configuration
+func newAuthenticator(cfg config.AuthConfig) (*auth.Authenticator, error) {
+	keys, err := auth.ParseAPIKeys(cfg.APIKeys)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 && cfg.JWTSecret == "" && cfg.JWTPublicKeyFile == "" {
+		return nil, errors.New("no credentials accepted: set API_KEYS, JWT_SECRET or JWT_PUBLIC_KEY_FILE, or AUTH_ENABLED=false")
+	}
+
+	authCfg := auth.Config{
+		APIKeys:     keys,
+		JWTSecret:   []byte(cfg.JWTSecret),
+		JWTIssuer:   cfg.JWTIssuer,
+		JWTAudience: cfg.JWTAudience,
+	}
+
+	if cfg.JWTPublicKeyFile != "" {
+		pem, err := os.ReadFile(cfg.JWTPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		authCfg.JWTPublicKey, err = jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return auth.NewAuthenticator(authCfg), nil
+}
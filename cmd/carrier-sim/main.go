@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/carrier"
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/pkg/webhooksig"
+)
+
+// Plays the fake carrier: sends signed tracking callbacks that move parcels to
+// delivered, or a single -status. Tracking numbers are listed by
+// GET /api/v1/orders/:id/shipments.
+//
+//	CARRIER_WEBHOOK_SECRET=... go run cmd/carrier-sim/main.go FAKE000123456789 ...
+func main() {
+	api := flag.String("api", getEnv("API_URL", "http://localhost:8080"), "API base URL")
+	step := flag.Duration("step", 2*time.Second, "pause between stages")
+	status := flag.String("status", "", "send only this status (e.g. exception)")
+	description := flag.String("description", "", "description of the -status event")
+	flag.Parse()
+
+	trackingNumbers := flag.Args()
+	if len(trackingNumbers) == 0 {
+		log.Fatal("Usage: carrier-sim [flags] TRACKING_NUMBER...")
+	}
+	secret := os.Getenv("CARRIER_WEBHOOK_SECRET")
+	if secret == "" {
+		log.Println("⚠️  CARRIER_WEBHOOK_SECRET not set, callbacks are sent unsigned")
+	}
+	url := *api + "/api/v1/carriers/" + carrier.FakeName + "/webhook"
+
+	stages := []carrier.FakeEvent{
+		{Status: domain.ShipmentStatusInTransit, Location: "Sort facility, Memphis TN", Description: "Departed facility"},
+		{Status: domain.ShipmentStatusOutForDelivery, Location: "Local depot", Description: "Out for delivery"},
+		{Status: domain.ShipmentStatusDelivered, Location: "Front door", Description: "Delivered"},
+	}
+	if *status != "" {
+		stages = []carrier.FakeEvent{{Status: domain.ShipmentStatus(*status), Description: *description}}
+	}
+
+	for i, stage := range stages {
+		if i > 0 {
+			time.Sleep(*step)
+		}
+		for _, tracking := range trackingNumbers {
+			event := stage
+			event.TrackingNumber = tracking
+			event.OccurredAt = time.Now().UTC().Truncate(time.Second)
+			if err := send(url, secret, event); err != nil {
+				log.Printf("❌ %s %s: %v", tracking, event.Status, err)
+				continue
+			}
+			log.Printf("🚚 %s %s", tracking, event.Status)
+		}
+	}
+}
+
+// send posts one event, signed like the API's own webhooks
+func send(url, secret string, event carrier.FakeEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		ts := time.Now().Unix()
+		req.Header.Set(webhooksig.HeaderTimestamp, strconv.FormatInt(ts, 10))
+		req.Header.Set(webhooksig.HeaderSignature, webhooksig.Sign(secret, ts, body))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	reply, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, reply)
+	}
+	return nil
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
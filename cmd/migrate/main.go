@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/config"
+	"github.com/lppduy/go-asynq-loadtest/pkg/database"
+)
+
+const usage = `Usage: go run cmd/migrate/main.go <command> [n]
+
+Commands:
+  up [n]     apply pending migrations (all, or the next n)
+  down [n]   roll back the last n applied migrations (default 1)
+  status     list migrations and when they were applied`
+
+// Applies the versioned SQL migrations embedded in pkg/database/migrations.
+// Concurrent runs serialize on a Postgres advisory lock.
+func main() {
+	flag.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	flag.Parse()
+	if flag.NArg() < 1 || flag.NArg() > 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	command := flag.Arg(0)
+	steps := 0
+	if command == "down" {
+		steps = 1
+	}
+	if flag.NArg() == 2 {
+		n, err := strconv.Atoi(flag.Arg(1))
+		if err != nil || n < 1 {
+			log.Fatalf("Invalid step count %q", flag.Arg(1))
+		}
+		steps = n
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+	// The API creates its in-memory schema itself
+	if cfg.Database.Driver == database.DriverMemory {
+		log.Fatal("DB_DRIVER=memory has no database to migrate, use postgres or sqlite")
+	}
+
+	db, err := database.Connect(database.Config{
+		Driver:     cfg.Database.Driver,
+		SQLitePath: cfg.Database.SQLitePath,
+		Host:       cfg.Database.Host,
+		Port:       cfg.Database.Port,
+		User:       cfg.Database.User,
+		Password:   cfg.Database.Password,
+		DBName:     cfg.Database.DBName,
+		SSLMode:    cfg.Database.SSLMode,
+	})
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		log.Fatal("Failed to load migrations:", err)
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(steps)
+		if err != nil {
+			log.Fatal("Migration failed:", err)
+		}
+		log.Printf("✅ Applied %d migration(s), latest version %d", len(applied), migrator.Latest())
+
+	case "down":
+		reverted, err := migrator.Down(steps)
+		if err != nil {
+			log.Fatal("Rollback failed:", err)
+		}
+		log.Printf("✅ Reverted %d migration(s)", len(reverted))
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatal("Failed to read migration status:", err)
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-32s %s\n", s.Version, s.Name, applied)
+		}
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
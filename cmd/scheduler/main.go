@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/hibiken/asynq"
+	"github.com/lppduy/go-asynq-loadtest/internal/config"
+	"github.com/lppduy/go-asynq-loadtest/internal/tasks"
+)
+
+// Enqueues periodic tasks: orders:reconcile on RECONCILE_CRON. The worker runs
+// them, so one scheduler per Redis is enough.
+func main() {
+	log.Println("⏰ Starting Asynq Scheduler...")
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
+	redisOpt := asynq.RedisClientOpt{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	}
+
+	codec, err := tasks.CodecByName(cfg.Worker.PayloadCodec)
+	if err != nil {
+		log.Fatal("Invalid task payload codec:", err)
+	}
+	tasks.SetCodec(codec)
+
+	scheduler := asynq.NewScheduler(redisOpt, &asynq.SchedulerOpts{
+		PostEnqueueFunc: func(info *asynq.TaskInfo, err error) {
+			switch {
+			case err == nil:
+				log.Printf("📤 Enqueued %s (%s)", info.Type, info.ID)
+			case errors.Is(err, asynq.ErrDuplicateTask):
+				log.Println("⏭️  Previous orders:reconcile run is still queued, skipping")
+			default:
+				log.Printf("❌ Failed to enqueue periodic task: %v", err)
+			}
+		},
+	})
+
+	task, err := tasks.OrdersReconcile.NewTask(tasks.ReconcilePayload{})
+	if err != nil {
+		log.Fatal("Failed to create orders:reconcile task:", err)
+	}
+	if _, err := scheduler.Register(cfg.Reconcile.Cron, task); err != nil {
+		log.Fatalf("Invalid RECONCILE_CRON %q: %v", cfg.Reconcile.Cron, err)
+	}
+
+	log.Printf("✅ %s every %q", tasks.TypeOrdersReconcile, cfg.Reconcile.Cron)
+	log.Printf("   pending after %s, payment_processing after %s, failed after %s, %d orders per status",
+		cfg.Reconcile.PendingAfter, cfg.Reconcile.PaymentProcessingAfter, cfg.Reconcile.FailAfter, cfg.Reconcile.BatchSize)
+	log.Printf("🔴 Redis: %s", cfg.Redis.Addr)
+
+	if err := scheduler.Start(); err != nil {
+		log.Fatalf("Failed to run scheduler: %v", err)
+	}
+
+	// Wait for interrupt signal to gracefully shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	log.Println("")
+	log.Println("🛑 Shutting down scheduler...")
+	scheduler.Shutdown()
+	log.Println("✅ Scheduler stopped successfully")
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/config"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+	"github.com/lppduy/go-asynq-loadtest/internal/service"
+	"github.com/lppduy/go-asynq-loadtest/pkg/database"
+)
+
+// Seeds the catalog used by the k6 scripts (prod-0 .. prod-99).
+// Existing products only get their stock topped up to -stock.
+func main() {
+	count := flag.Int("products", 100, "number of products (prod-0 .. prod-N-1)")
+	stock := flag.Int("stock", 100000, "on-hand units per product")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+	// The API seeds its in-memory catalog itself
+	if cfg.Database.Driver == database.DriverMemory {
+		log.Fatal("DB_DRIVER=memory has no database to seed, use postgres or sqlite")
+	}
+
+	db, err := database.Connect(database.Config{
+		Driver:     cfg.Database.Driver,
+		SQLitePath: cfg.Database.SQLitePath,
+		Host:       cfg.Database.Host,
+		Port:       cfg.Database.Port,
+		User:       cfg.Database.User,
+		Password:   cfg.Database.Password,
+		DBName:     cfg.Database.DBName,
+		SSLMode:    cfg.Database.SSLMode,
+	})
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	if err := database.CheckSchema(db); err != nil {
+		log.Fatal("Database schema check failed:", err)
+	}
+
+	created, updated, err := service.SeedCatalog(context.Background(), repository.NewGormInventoryRepository(db), *count, *stock)
+	if err != nil {
+		log.Fatal("Failed to seed catalog:", err)
+	}
+
+	log.Printf("✅ Seeded catalog: %d created, %d restocked (%d units each)", created, updated, *stock)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/lppduy/go-asynq-loadtest/pkg/webhooksig"
+)
+
+// A local webhook target: verifies signatures and prints received events.
+//
+//	WEBHOOK_SECRET=whsec_... go run cmd/webhook-receiver/main.go
+func main() {
+	addr := getEnv("RECEIVER_ADDR", ":9090")
+	secret := os.Getenv("WEBHOOK_SECRET")
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+
+		if secret != "" {
+			err := webhooksig.Verify(secret,
+				r.Header.Get(webhooksig.HeaderTimestamp),
+				r.Header.Get(webhooksig.HeaderSignature),
+				body, 5*time.Minute)
+			if err != nil {
+				log.Printf("❌ Rejected %s: %v", r.Header.Get(webhooksig.HeaderID), err)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+		}
+
+		log.Printf("🔔 %s %s\n%s", r.Header.Get(webhooksig.HeaderEvent), r.Header.Get(webhooksig.HeaderID), body)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if secret == "" {
+		log.Println("⚠️  WEBHOOK_SECRET not set, signatures are not verified")
+	}
+	log.Printf("✅ Webhook receiver listening on %s", addr)
+	if err := http.ListenAndServe(addr, nil); err != nil {
+		log.Fatal("Failed to start receiver:", err)
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
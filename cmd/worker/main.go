@@ -3,15 +3,25 @@ package main
 import (
 	"context"
 	"log"
+	"net/http"
+	"net/mail"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/hibiken/asynq"
+	"github.com/lppduy/go-asynq-loadtest/internal/carrier"
 	"github.com/lppduy/go-asynq-loadtest/internal/config"
+	"github.com/lppduy/go-asynq-loadtest/internal/email"
+	"github.com/lppduy/go-asynq-loadtest/internal/events"
 	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+	"github.com/lppduy/go-asynq-loadtest/internal/storage"
 	"github.com/lppduy/go-asynq-loadtest/internal/tasks"
+	"github.com/lppduy/go-asynq-loadtest/internal/webhook"
 	"github.com/lppduy/go-asynq-loadtest/pkg/database"
+	"github.com/lppduy/go-asynq-loadtest/pkg/metrics"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
@@ -22,6 +32,10 @@ func main() {
 	if err != nil {
 		log.Fatal("Failed to load config:", err)
 	}
+	// The in-memory database belongs to the API process, tasks could never load its orders
+	if cfg.Database.Driver == database.DriverMemory {
+		log.Fatal("DB_DRIVER=memory is only supported by the API, the worker needs postgres or sqlite")
+	}
 
 	// Create Redis connection options
 	redisOpt := asynq.RedisClientOpt{
@@ -30,24 +44,82 @@ func main() {
 		DB:       cfg.Redis.DB,
 	}
 
-	// Connect to PostgreSQL (so tasks can update order status)
+	// Connect to the database (so tasks can update order status)
 	dbConfig := database.Config{
-		Host:     cfg.Database.Host,
-		Port:     cfg.Database.Port,
-		User:     cfg.Database.User,
-		Password: cfg.Database.Password,
-		DBName:   cfg.Database.DBName,
-		SSLMode:  cfg.Database.SSLMode,
+		Driver:     cfg.Database.Driver,
+		SQLitePath: cfg.Database.SQLitePath,
+		Host:       cfg.Database.Host,
+		Port:       cfg.Database.Port,
+		User:       cfg.Database.User,
+		Password:   cfg.Database.Password,
+		DBName:     cfg.Database.DBName,
+		SSLMode:    cfg.Database.SSLMode,
 	}
 	db, err := database.Connect(dbConfig)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
-	// Ensure schema exists
-	if err := database.AutoMigrate(db); err != nil {
-		log.Fatal("Failed to run migrations:", err)
+	// Schema changes are applied by cmd/migrate, never at boot
+	if err := database.CheckSchema(db); err != nil {
+		log.Fatal("Database schema check failed:", err)
+	}
+
+	// Publish every order update so API replicas can push them to SSE streams
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	defer redisClient.Close()
+
+	// Asynq client so status transitions can enqueue webhook deliveries
+	asynqClient := asynq.NewClient(redisOpt)
+	defer asynqClient.Close()
+
+	codec, err := tasks.CodecByName(cfg.Worker.PayloadCodec)
+	if err != nil {
+		log.Fatal("Invalid task payload codec:", err)
+	}
+	tasks.SetCodec(codec)
+
+	webhookRepo := repository.NewGormWebhookRepository(db)
+	dispatcher := webhook.NewDispatcher(webhookRepo, asynqClient)
+
+	// Order writes are published for live streams and announced to webhooks
+	var orderRepo repository.OrderRepository = repository.NewGormOrderRepository(db)
+	orderRepo = events.WithPublisher(orderRepo, events.NewPublisher(redisClient))
+	orderRepo = webhook.WithDispatcher(orderRepo, dispatcher)
+
+	// Stock is reserved by inventory:update, released on failure, committed on ship
+	inventoryRepo := repository.NewGormInventoryRepository(db)
+
+	// Local analytics store, written by analytics:flush
+	analyticsRepo := repository.NewGormAnalyticsRepository(db)
+
+	mailer, err := newMailer(cfg.Email)
+	if err != nil {
+		log.Fatal("Failed to set up email:", err)
+	}
+
+	// invoice:generate stores PDFs where the API serves them from
+	blobs, err := storage.New(context.Background(), cfg.Storage.Backend, cfg.Storage.Dir, storage.S3Config{
+		Endpoint:  cfg.Storage.S3Endpoint,
+		Region:    cfg.Storage.S3Region,
+		Bucket:    cfg.Storage.S3Bucket,
+		AccessKey: cfg.Storage.S3AccessKey,
+		SecretKey: cfg.Storage.S3SecretKey,
+		UseSSL:    cfg.Storage.S3UseSSL,
+	})
+	if err != nil {
+		log.Fatal("Failed to open blob storage:", err)
+	}
+
+	// warehouse:notify buys a label per parcel; the API applies the carrier's tracking callbacks
+	shipmentRepo := repository.NewGormShipmentRepository(db)
+	shippingCarrier, err := carrier.New(cfg.Carrier.Name, cfg.Carrier.WebhookSecret, cfg.Carrier.WebhookTolerance)
+	if err != nil {
+		log.Fatal("Failed to set up carrier:", err)
 	}
-	orderRepo := repository.NewGormOrderRepository(db)
 
 	// Create Asynq server with queue configuration
 	srv := asynq.NewServer(
@@ -56,52 +128,101 @@ func main() {
 			// Number of concurrent workers
 			Concurrency: cfg.Worker.Concurrency,
 
-		// Queue priority (higher number = higher priority)
-		Queues: map[string]int{
-			"critical": 6, // Highest priority (payment processing)
-			"high":     4, // High priority (inventory updates)
-			"default":  2, // Default priority (emails, invoices)
-			"low":      1, // Low priority (analytics, notifications)
-		},
+			// Queue priority (higher number = higher priority)
+			Queues: map[string]int{
+				"critical": 6, // Highest priority (payment processing)
+				"high":     4, // High priority (inventory updates)
+				"default":  2, // Default priority (emails, invoices)
+				"low":      1, // Low priority (analytics, notifications)
+			},
 
-		// Error handling
-		ErrorHandler: asynq.ErrorHandlerFunc(func(ctx context.Context, task *asynq.Task, err error) {
-			log.Printf("❌ [Error] Task %s failed: %v", task.Type(), err)
-		}),
+			// Error handling
+			ErrorHandler: asynq.ErrorHandlerFunc(func(ctx context.Context, task *asynq.Task, err error) {
+				log.Printf("❌ [Error] Task %s failed: %v", task.Type(), err)
+			}),
 
-		// Retry configuration
-		RetryDelayFunc: asynq.DefaultRetryDelayFunc,
-	},
+			// Retry configuration (exponential backoff for webhooks)
+			RetryDelayFunc: tasks.RetryDelay,
+
+			// Analytics events are grouped and written in batches by analytics:flush
+			GroupAggregator:  asynq.GroupAggregatorFunc(tasks.AggregateAnalytics),
+			GroupMaxSize:     cfg.Worker.AnalyticsBatchMaxSize,
+			GroupGracePeriod: cfg.Worker.AnalyticsBatchGracePeriod,
+			GroupMaxDelay:    cfg.Worker.AnalyticsBatchMaxDelay,
+		},
 	)
 
 	// Create task multiplexer (router)
 	mux := asynq.NewServeMux()
 
-	// Register task handlers
-	// Critical queue
-	mux.HandleFunc(tasks.TypePaymentProcess, tasks.NewPaymentProcessHandler(orderRepo))
+	// Every task is logged and timed; panics fail it permanently
+	router := tasks.NewRouter(mux,
+		tasks.Logging(),
+		tasks.Metrics(),
+		tasks.Recover(),
+	)
 
-	// High queue
-	mux.HandleFunc(tasks.TypeInventoryUpdate, tasks.NewInventoryUpdateHandler(orderRepo))
+	// orders:reconcile looks up payment tasks of stuck orders by their IDs
+	inspector := asynq.NewInspector(redisOpt)
+	defer inspector.Close()
 
-	// Default queue
-	mux.HandleFunc(tasks.TypeEmailConfirmation, tasks.HandleEmailConfirmationTask)
-	mux.HandleFunc(tasks.TypeInvoiceGenerate, tasks.NewInvoiceGenerateHandler(orderRepo))
+	// Register the handler of every task type declared with tasks.Define.
+	// Each also gets a Deadline of its default timeout, and order tasks RequireOrder.
+	registered := tasks.RegisterAll(router, tasks.Deps{
+		OrderRepo:     orderRepo,
+		InventoryRepo: inventoryRepo,
+		WebhookRepo:   webhookRepo,
+		AnalyticsRepo: analyticsRepo,
+		ShipmentRepo:  shipmentRepo,
+		Transactor:    repository.NewGormTransactor(db),
+		HTTPClient:    &http.Client{Timeout: 10 * time.Second},
+		Mailer:        mailer,
+		Blobs:         blobs,
+		Carrier:       shippingCarrier,
+		ParcelUnits:   cfg.Carrier.ParcelMaxUnits,
+		Client:        asynqClient,
+		Inspector:     inspector,
+		Reconcile: tasks.ReconcileSettings{
+			PendingAfter:           cfg.Reconcile.PendingAfter,
+			PaymentProcessingAfter: cfg.Reconcile.PaymentProcessingAfter,
+			FailAfter:              cfg.Reconcile.FailAfter,
+			BatchSize:              cfg.Reconcile.BatchSize,
+		},
+	})
 
-	// Low queue
-	mux.HandleFunc(tasks.TypeAnalyticsTrack, tasks.HandleAnalyticsTrackTask)
-	mux.HandleFunc(tasks.TypeWarehouseNotify, tasks.NewWarehouseNotifyHandler(orderRepo))
+	// Task durations for Prometheus (asynq_task_duration_seconds)
+	if cfg.Monitoring.PrometheusEnabled {
+		go func() {
+			metricsMux := http.NewServeMux()
+			metricsMux.Handle("/metrics", metrics.Handler())
+			if err := http.ListenAndServe(cfg.Monitoring.WorkerMetricsAddr, metricsMux); err != nil {
+				log.Printf("⚠️  Worker metrics server stopped: %v", err)
+			}
+		}()
+		log.Printf("📈 Worker metrics on %s/metrics", cfg.Monitoring.WorkerMetricsAddr)
+	}
 
 	log.Println("✅ Worker registered task handlers:")
-	log.Println("   💳 [Critical] payment:process")
-	log.Println("   📦 [High]     inventory:update")
-	log.Println("   📧 [Default]  email:confirmation")
-	log.Println("   🧾 [Default]  invoice:generate")
-	log.Println("   📊 [Low]      analytics:track")
-	log.Println("   🏭 [Low]      warehouse:notify")
+	for _, r := range registered {
+		log.Printf("   %-10s %s", "["+r.Queue+"]", r.Type)
+	}
 	log.Println("")
 	log.Printf("⚙️  Worker concurrency: %d", cfg.Worker.Concurrency)
 	log.Printf("🔴 Redis: %s", cfg.Redis.Addr)
+	log.Printf("📦 Task payload codec: %s", cfg.Worker.PayloadCodec)
+	log.Printf("📊 Analytics batches: up to %d events, %s grace period, %s max delay",
+		cfg.Worker.AnalyticsBatchMaxSize, cfg.Worker.AnalyticsBatchGracePeriod, cfg.Worker.AnalyticsBatchMaxDelay)
+	if cfg.Email.Transport == "smtp" {
+		log.Printf("📧 Emails: SMTP via %s:%s from %s", cfg.Email.SMTPHost, cfg.Email.SMTPPort, cfg.Email.From)
+	} else {
+		log.Printf("📧 Emails: maildir %s/new (no SMTP)", cfg.Email.FileDir)
+	}
+	if cfg.Storage.Backend == "s3" {
+		log.Printf("🗄️  Invoices: s3://%s at %s", cfg.Storage.S3Bucket, cfg.Storage.S3Endpoint)
+	} else {
+		log.Printf("🗄️  Invoices: %s/", cfg.Storage.Dir)
+	}
+	log.Printf("🚚 Carrier: %s, up to %d units per parcel", shippingCarrier.Name(), cfg.Carrier.ParcelMaxUnits)
 	log.Println("")
 	log.Println("🚀 Worker started! Waiting for tasks...")
 
@@ -125,3 +246,15 @@ func main() {
 
 	log.Println("✅ Worker stopped successfully")
 }
+
+// newMailer creates the mailer of EMAIL_TRANSPORT
+func newMailer(cfg config.EmailConfig) (email.Mailer, error) {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Transport == "smtp" {
+		return email.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, *from), nil
+	}
+	return email.NewFileMailer(cfg.FileDir, *from)
+}
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ScopeAdmin grants access to admin routes and to every customer's orders
+const ScopeAdmin = "admin"
+
+// Common errors
+var (
+	ErrMissingCredentials = errors.New("missing credentials")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Principal is the authenticated caller
+type Principal struct {
+	Subject    string // Stable caller identity, e.g. for rate limit buckets
+	CustomerID string // Empty for service/admin credentials
+	Scopes     []string
+	Method     string // api_key or jwt
+}
+
+// HasScope reports whether the principal was granted scope
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdmin reports whether the principal has the admin scope
+func (p *Principal) IsAdmin() bool {
+	return p.HasScope(ScopeAdmin)
+}
+
+// CanAccessCustomer reports whether the principal may act on customerID's orders
+func (p *Principal) CanAccessCustomer(customerID string) bool {
+	return p.IsAdmin() || (p.CustomerID != "" && p.CustomerID == customerID)
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the principal
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the principal stored in ctx, if any
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(*Principal)
+	return p, ok
+}
+
+// APIKey is a static credential sent in the X-API-Key header
+type APIKey struct {
+	Key        string
+	CustomerID string
+	Scopes     []string
+}
+
+// Config holds the accepted credentials
+type Config struct {
+	APIKeys      []APIKey
+	JWTSecret    []byte         // Enables HS256 when set
+	JWTPublicKey *rsa.PublicKey // Enables RS256 when set
+	JWTIssuer    string         // Optional "iss" check
+	JWTAudience  string         // Optional "aud" check
+}
+
+// Claims are the JWT claims understood by the API
+type Claims struct {
+	CustomerID string `json:"customer_id"`
+	Scope      string `json:"scope"` // Space-separated, OAuth2 style
+	jwt.RegisteredClaims
+}
+
+// Authenticator validates API keys and JWT bearer tokens
+type Authenticator struct {
+	cfg     Config
+	methods []string
+}
+
+// NewAuthenticator creates a new authenticator
+func NewAuthenticator(cfg Config) *Authenticator {
+	var methods []string
+	if len(cfg.JWTSecret) > 0 {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if cfg.JWTPublicKey != nil {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+	return &Authenticator{cfg: cfg, methods: methods}
+}
+
+// Authenticate extracts and validates credentials from the request
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return a.authenticateAPIKey(key)
+	}
+
+	header := r.Header.Get("Authorization")
+	if token, ok := strings.CutPrefix(header, "Bearer "); ok && token != "" {
+		return a.authenticateJWT(token)
+	}
+
+	return nil, ErrMissingCredentials
+}
+
+// authenticateAPIKey compares the key against every configured key in constant time
+func (a *Authenticator) authenticateAPIKey(key string) (*Principal, error) {
+	var match *APIKey
+	for i := range a.cfg.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(a.cfg.APIKeys[i].Key), []byte(key)) == 1 {
+			match = &a.cfg.APIKeys[i]
+		}
+	}
+	if match == nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	// Service keys have no customer; a fingerprint tells them apart without exposing the key
+	sum := sha256.Sum256([]byte(match.Key))
+	subject := "api-key:" + hex.EncodeToString(sum[:8])
+	if match.CustomerID != "" {
+		subject = "api-key:" + match.CustomerID
+	}
+	return &Principal{
+		Subject:    subject,
+		CustomerID: match.CustomerID,
+		Scopes:     match.Scopes,
+		Method:     "api_key",
+	}, nil
+}
+
+// authenticateJWT verifies signature, expiry and optional issuer/audience
+func (a *Authenticator) authenticateJWT(raw string) (*Principal, error) {
+	if len(a.methods) == 0 {
+		return nil, ErrInvalidCredentials
+	}
+
+	opts := []jwt.ParserOption{jwt.WithValidMethods(a.methods), jwt.WithExpirationRequired()}
+	if a.cfg.JWTIssuer != "" {
+		opts = append(opts, jwt.WithIssuer(a.cfg.JWTIssuer))
+	}
+	if a.cfg.JWTAudience != "" {
+		opts = append(opts, jwt.WithAudience(a.cfg.JWTAudience))
+	}
+
+	var claims Claims
+	_, err := jwt.ParseWithClaims(raw, &claims, func(t *jwt.Token) (interface{}, error) {
+		switch t.Method.Alg() {
+		case jwt.SigningMethodHS256.Alg():
+			return a.cfg.JWTSecret, nil
+		case jwt.SigningMethodRS256.Alg():
+			return a.cfg.JWTPublicKey, nil
+		}
+		return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	return &Principal{
+		Subject:    claims.Subject,
+		CustomerID: claims.CustomerID,
+		Scopes:     strings.Fields(claims.Scope),
+		Method:     "jwt",
+	}, nil
+}
+
+// ParseAPIKeys parses "key:customer_id:scope|scope,key2::admin" into API keys
+func ParseAPIKeys(spec string) ([]APIKey, error) {
+	var keys []APIKey
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 3)
+		if parts[0] == "" {
+			return nil, fmt.Errorf("API key entry %q has an empty key", entry)
+		}
+
+		key := APIKey{Key: parts[0]}
+		if len(parts) > 1 {
+			key.CustomerID = parts[1]
+		}
+		if len(parts) > 2 && parts[2] != "" {
+			key.Scopes = strings.Split(parts[2], "|")
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
@@ -0,0 +1,168 @@
+package backpressure
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/lppduy/go-asynq-loadtest/pkg/metrics"
+)
+
+// Decision is the admission outcome for a new order
+type Decision string
+
+const (
+	DecisionAccept Decision = "accept" // Accept order and enqueue all tasks
+	DecisionShed   Decision = "shed"   // Accept order but skip low-priority tasks
+	DecisionReject Decision = "reject" // Refuse order until the worker catches up
+)
+
+// Verdict is a decision together with the reason it was taken
+type Verdict struct {
+	Decision Decision
+	Reason   string
+}
+
+// Config holds the high-water marks used to decide admission.
+// A zero threshold disables that check.
+type Config struct {
+	Queues          []string
+	RefreshInterval time.Duration
+	ShedBacklog     int
+	RejectBacklog   int
+	ShedLatency     time.Duration
+	RejectLatency   time.Duration
+}
+
+// QueueStats is a cached snapshot of a single queue
+type QueueStats struct {
+	Backlog int
+	Latency time.Duration
+}
+
+// Monitor periodically polls Asynq for queue depth/latency and answers
+// admission questions from the cached snapshot (no Redis call per request).
+type Monitor struct {
+	inspector *asynq.Inspector
+	cfg       Config
+
+	mu        sync.RWMutex
+	stats     map[string]QueueStats
+	updatedAt time.Time
+}
+
+// NewMonitor creates a new queue monitor
+func NewMonitor(inspector *asynq.Inspector, cfg Config) *Monitor {
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = 2 * time.Second
+	}
+	return &Monitor{
+		inspector: inspector,
+		cfg:       cfg,
+		stats:     make(map[string]QueueStats),
+	}
+}
+
+// Start refreshes the snapshot until ctx is cancelled
+func (m *Monitor) Start(ctx context.Context) {
+	m.refresh()
+
+	go func() {
+		ticker := time.NewTicker(m.cfg.RefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.refresh()
+			}
+		}
+	}()
+}
+
+// refresh reads queue info from Redis and updates the cached snapshot
+func (m *Monitor) refresh() {
+	stats := make(map[string]QueueStats, len(m.cfg.Queues))
+	for _, queue := range m.cfg.Queues {
+		info, err := m.inspector.GetQueueInfo(queue)
+		if errors.Is(err, asynq.ErrQueueNotFound) {
+			// Nothing enqueued to this queue yet
+			stats[queue] = QueueStats{}
+			metrics.QueueBacklog.WithLabelValues(queue).Set(0)
+			metrics.QueueLatency.WithLabelValues(queue).Set(0)
+			continue
+		}
+		if err != nil {
+			// Keep the previous snapshot; it goes stale if Redis stays unreachable
+			log.Printf("⚠️  [Backpressure] Failed to inspect queue %s: %v", queue, err)
+			return
+		}
+
+		backlog := info.Pending + info.Scheduled + info.Active + info.Retry + info.Aggregating
+		stats[queue] = QueueStats{Backlog: backlog, Latency: info.Latency}
+
+		metrics.QueueBacklog.WithLabelValues(queue).Set(float64(backlog))
+		metrics.QueueLatency.WithLabelValues(queue).Set(info.Latency.Seconds())
+	}
+
+	m.mu.Lock()
+	m.stats = stats
+	m.updatedAt = time.Now()
+	m.mu.Unlock()
+}
+
+// Snapshot returns a copy of the cached queue stats
+func (m *Monitor) Snapshot() map[string]QueueStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snapshot := make(map[string]QueueStats, len(m.stats))
+	for queue, s := range m.stats {
+		snapshot[queue] = s
+	}
+	return snapshot
+}
+
+// Evaluate decides whether a new order should be accepted, accepted with
+// shedding, or rejected. A stale snapshot fails open (accept).
+func (m *Monitor) Evaluate() Verdict {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.updatedAt.IsZero() || time.Since(m.updatedAt) > 3*m.cfg.RefreshInterval {
+		return Verdict{Decision: DecisionAccept, Reason: "stale_stats"}
+	}
+
+	backlog := 0
+	var latency time.Duration
+	for _, s := range m.stats {
+		backlog += s.Backlog
+		if s.Latency > latency {
+			latency = s.Latency
+		}
+	}
+
+	switch {
+	case m.cfg.RejectBacklog > 0 && backlog >= m.cfg.RejectBacklog:
+		return Verdict{Decision: DecisionReject, Reason: "backlog"}
+	case m.cfg.RejectLatency > 0 && latency >= m.cfg.RejectLatency:
+		return Verdict{Decision: DecisionReject, Reason: "latency"}
+	case m.cfg.ShedBacklog > 0 && backlog >= m.cfg.ShedBacklog:
+		return Verdict{Decision: DecisionShed, Reason: "backlog"}
+	case m.cfg.ShedLatency > 0 && latency >= m.cfg.ShedLatency:
+		return Verdict{Decision: DecisionShed, Reason: "latency"}
+	}
+
+	return Verdict{Decision: DecisionAccept, Reason: "healthy"}
+}
+
+// LogConfig prints the configured thresholds
+func (m *Monitor) LogConfig() {
+	log.Printf("🚦 Backpressure: shed at backlog=%d/latency=%s, reject at backlog=%d/latency=%s (refresh %s)",
+		m.cfg.ShedBacklog, m.cfg.ShedLatency, m.cfg.RejectBacklog, m.cfg.RejectLatency, m.cfg.RefreshInterval)
+}
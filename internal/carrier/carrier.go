@@ -0,0 +1,64 @@
+// Package carrier buys shipping labels from parcel carriers and reads the
+// tracking callbacks they send back.
+package carrier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+)
+
+// Carrier errors
+var (
+	ErrUnknownServiceLevel = errors.New("service level not offered by carrier")
+	ErrInvalidWebhook      = errors.New("invalid carrier webhook")
+)
+
+// Carrier ships parcels and reports their progress through webhooks
+type Carrier interface {
+	// Name identifies the carrier in shipments and in its webhook URL
+	Name() string
+	// CreateShipment buys the label of one parcel
+	CreateShipment(ctx context.Context, req ShipmentRequest) (*Label, error)
+	// ParseWebhook authenticates a tracking callback and returns its events
+	ParseWebhook(header http.Header, body []byte) ([]TrackingEvent, error)
+}
+
+// ShipmentRequest describes a parcel to ship
+type ShipmentRequest struct {
+	// Reference is unique per parcel ("<order id>-<parcel>"); carriers use it
+	// to deduplicate retried label purchases
+	Reference    string
+	ServiceLevel string // standard, express, overnight
+	Recipient    string
+	Address      domain.Address
+	Items        []domain.ShipmentItem
+}
+
+// Label is what the carrier returns for a parcel
+type Label struct {
+	TrackingNumber    string
+	LabelURL          string
+	EstimatedDelivery time.Time
+}
+
+// TrackingEvent is a status update of one parcel, mapped to shipment statuses
+type TrackingEvent struct {
+	TrackingNumber string
+	Event          domain.ShipmentEvent
+}
+
+// New creates the carrier named name. webhookSecret authenticates its callbacks;
+// callbacks older than tolerance are rejected.
+func New(name, webhookSecret string, tolerance time.Duration) (Carrier, error) {
+	switch name {
+	case FakeName:
+		return NewFakeCarrier(webhookSecret, tolerance), nil
+	default:
+		return nil, fmt.Errorf("unknown carrier %q", name)
+	}
+}
@@ -0,0 +1,103 @@
+package carrier
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/pkg/webhooksig"
+)
+
+// FakeName is the name of the fake carrier
+const FakeName = "fake"
+
+// fakeTransitDays is how long each service level takes
+var fakeTransitDays = map[string]int{
+	domain.ShippingStandard:  5,
+	domain.ShippingExpress:   2,
+	domain.ShippingOvernight: 1,
+}
+
+// FakeEvent is the body of fake carrier callbacks. They are signed like our own
+// webhooks (see pkg/webhooksig); cmd/carrier-sim sends them.
+type FakeEvent struct {
+	TrackingNumber string                `json:"tracking_number"`
+	Status         domain.ShipmentStatus `json:"status"`
+	Location       string                `json:"location,omitempty"`
+	Description    string                `json:"description,omitempty"`
+	OccurredAt     time.Time             `json:"occurred_at"`
+}
+
+// FakeCarrier issues made-up tracking numbers without calling anything, for
+// local runs and load tests
+type FakeCarrier struct {
+	webhookSecret string
+	tolerance     time.Duration
+}
+
+// NewFakeCarrier creates a fake carrier. Callbacks are not verified when
+// webhookSecret is empty.
+func NewFakeCarrier(webhookSecret string, tolerance time.Duration) Carrier {
+	return &FakeCarrier{webhookSecret: webhookSecret, tolerance: tolerance}
+}
+
+// Name returns "fake"
+func (c *FakeCarrier) Name() string {
+	return FakeName
+}
+
+// CreateShipment returns a random FAKE tracking number and an ETA based on the service level
+func (c *FakeCarrier) CreateShipment(ctx context.Context, req ShipmentRequest) (*Label, error) {
+	days, ok := fakeTransitDays[req.ServiceLevel]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownServiceLevel, req.ServiceLevel)
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1e12))
+	if err != nil {
+		return nil, err
+	}
+	tracking := fmt.Sprintf("FAKE%012d", n)
+
+	return &Label{
+		TrackingNumber:    tracking,
+		LabelURL:          "https://labels.fake-carrier.test/" + tracking + ".pdf",
+		EstimatedDelivery: time.Now().AddDate(0, 0, days),
+	}, nil
+}
+
+// ParseWebhook verifies the signature headers and decodes one FakeEvent
+func (c *FakeCarrier) ParseWebhook(header http.Header, body []byte) ([]TrackingEvent, error) {
+	if c.webhookSecret != "" {
+		err := webhooksig.Verify(c.webhookSecret,
+			header.Get(webhooksig.HeaderTimestamp),
+			header.Get(webhooksig.HeaderSignature),
+			body, c.tolerance)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var e FakeEvent
+	if err := json.Unmarshal(body, &e); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWebhook, err)
+	}
+	if e.TrackingNumber == "" || e.Status == "" {
+		return nil, fmt.Errorf("%w: tracking_number and status are required", ErrInvalidWebhook)
+	}
+
+	return []TrackingEvent{{
+		TrackingNumber: e.TrackingNumber,
+		Event: domain.ShipmentEvent{
+			Status:      e.Status,
+			Location:    e.Location,
+			Description: e.Description,
+			OccurredAt:  e.OccurredAt,
+		},
+	}}, nil
+}
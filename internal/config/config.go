@@ -2,32 +2,51 @@ package config
 
 import (
 	"fmt"
+	"net/mail"
 	"os"
 	"strconv"
+	"time"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	Worker   WorkerConfig
+	Server       ServerConfig
+	Database     DatabaseConfig
+	Redis        RedisConfig
+	Worker       WorkerConfig
+	Backpressure BackpressureConfig
+	RateLimit    RateLimitConfig
+	Auth         AuthConfig
+	Monitoring   MonitoringConfig
+	Pricing      PricingConfig
+	OrderBatch   OrderBatchConfig
+	Reconcile    ReconcileConfig
+	Email        EmailConfig
+	Storage      StorageConfig
+	Carrier      CarrierConfig
 }
 
 // ServerConfig holds HTTP server configuration
 type ServerConfig struct {
 	Port string
 	Env  string // development, staging, production
+	// SSE order streams
+	StreamHeartbeat   time.Duration // Interval of keep-alive comments
+	StreamMaxDuration time.Duration // Streams are closed after this, clients reconnect
 }
 
-// DatabaseConfig holds PostgreSQL configuration
+// DatabaseConfig holds database configuration
 type DatabaseConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	// postgres, sqlite (local file, no Docker) or memory (API only: everything
+	// is kept in the process and lost on restart, no background tasks)
+	Driver     string
+	SQLitePath string
+	Host       string
+	Port       string
+	User       string
+	Password   string
+	DBName     string
+	SSLMode    string
 }
 
 // RedisConfig holds Redis configuration
@@ -43,22 +62,144 @@ type WorkerConfig struct {
 	// RetentionMinutes controls how long to keep completed/failed task records in Redis
 	// so they show up in Asynqmon (0 = do not keep).
 	RetentionMinutes int
+	// PayloadCodec encodes enqueued task payloads: json, msgpack or protobuf.
+	// Workers decode all three, whichever is set.
+	PayloadCodec string
+	// Analytics events are flushed in one batch per AnalyticsBatchMaxSize events,
+	// after AnalyticsBatchGracePeriod without new events, or at the latest after
+	// AnalyticsBatchMaxDelay (Asynq task groups)
+	AnalyticsBatchMaxSize     int
+	AnalyticsBatchGracePeriod time.Duration
+	AnalyticsBatchMaxDelay    time.Duration
+}
+
+// BackpressureConfig holds API admission control based on Asynq queue depth.
+// A zero threshold disables that check.
+type BackpressureConfig struct {
+	Enabled         bool
+	RefreshInterval time.Duration // How often queue stats are read from Redis
+	ShedBacklog     int           // Total unfinished tasks before analytics is shed
+	RejectBacklog   int           // Total unfinished tasks before new orders are rejected
+	ShedLatency     time.Duration // Oldest pending task age before analytics is shed
+	RejectLatency   time.Duration // Oldest pending task age before new orders are rejected
+	RejectStatus    int           // 503 or 429
+	RetryAfter      time.Duration // Value of the Retry-After header on rejection
+}
+
+// RateLimitConfig holds HTTP rate limiting configuration
+type RateLimitConfig struct {
+	Enabled bool
+	Backend string                    // memory (single replica) or redis (shared across replicas)
+	Groups  map[string]RateLimitGroup // Keyed by route group name
+}
+
+// RateLimitGroup holds token bucket limits for one route group (0 = unlimited)
+type RateLimitGroup struct {
+	ClientRPS   float64 // Per authenticated caller, or per IP
+	ClientBurst int
+	GlobalRPS   float64 // Shared by all clients
+	GlobalBurst int
+}
+
+// AuthConfig holds API authentication configuration
+type AuthConfig struct {
+	Enabled          bool
+	APIKeys          string // "key:customer_id:scope|scope,..." (customer_id may be empty)
+	JWTSecret        string // Enables HS256 tokens
+	JWTPublicKeyFile string // PEM file, enables RS256 tokens
+	JWTIssuer        string // Optional "iss" check
+	JWTAudience      string // Optional "aud" check
+}
+
+// MonitoringConfig holds observability configuration
+type MonitoringConfig struct {
+	PrometheusEnabled bool   // Expose GET /metrics
+	WorkerMetricsAddr string // Listen address of the worker's /metrics
+}
+
+// PricingConfig holds tax and shipping rules applied to order totals
+type PricingConfig struct {
+	TaxRates         string // "US:7.25,DE:19" percent by shipping country
+	DefaultTaxRate   string // Percent for countries not listed
+	ShippingFees     string // "standard:5.00,express:15.00,overnight:30.00" in the order currency
+	FreeShippingOver string // Discounted subtotal from which standard shipping is free ("" = never)
+}
+
+// OrderBatchConfig holds limits of POST /api/v1/orders/batch
+type OrderBatchConfig struct {
+	MaxOrders          int // Largest accepted batch
+	EnqueueConcurrency int // Orders whose tasks are enqueued at the same time
+}
+
+// ReconcileConfig holds settings for the stuck order reconciliation run by cmd/scheduler
+type ReconcileConfig struct {
+	Cron string // Schedule of orders:reconcile, in cron or "@every 5m" syntax
+	// Orders are checked once they have stayed pending or payment_processing this long
+	PendingAfter           time.Duration
+	PaymentProcessingAfter time.Duration
+	// FailAfter is the order age after which stuck orders are failed instead of re-enqueued
+	FailAfter time.Duration
+	BatchSize int // Orders checked per status and run
+}
+
+// EmailConfig holds how the worker delivers order emails
+type EmailConfig struct {
+	Transport string // smtp, or file: a maildir under FileDir for local runs
+	From      string // Sender address, optionally with a name: "Shop <orders@example.com>"
+	FileDir   string
+	// SMTP relay; PLAIN auth is used when SMTPUsername is set
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// StorageConfig holds where generated files (invoice PDFs) are kept. The API and
+// the worker must use the same store.
+type StorageConfig struct {
+	Backend string // file (local directory, API and worker on one host) or s3
+	Dir     string // Root of the file backend
+	// S3 or a compatible server such as MinIO
+	S3Endpoint  string // host:port
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3UseSSL    bool
+	// Lifetime of the presigned links GET /orders/:id/invoice redirects to (s3 only)
+	SignedURLExpiry time.Duration
+}
+
+// CarrierConfig holds the parcel carrier the worker buys labels from and the API
+// accepts tracking callbacks of
+type CarrierConfig struct {
+	Name string // fake: made-up tracking numbers, callbacks sent with cmd/carrier-sim
+	// Secret signing tracking callbacks; unset skips verification (not in production)
+	WebhookSecret    string
+	WebhookTolerance time.Duration // Oldest callback timestamp accepted
+	ParcelMaxUnits   int           // Units packed per parcel at most
 }
 
 // Load reads configuration from environment variables
 func Load() (*Config, error) {
+	env := getEnv("ENV", "development")
+
 	cfg := &Config{
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
-			Env:  getEnv("ENV", "development"),
+			Port:              getEnv("SERVER_PORT", "8080"),
+			Env:               env,
+			StreamHeartbeat:   getEnvAsDuration("SSE_HEARTBEAT", 15*time.Second),
+			StreamMaxDuration: getEnvAsDuration("SSE_MAX_DURATION", 10*time.Minute),
 		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", "admin"),
-			Password: getEnv("DB_PASSWORD", "secret123"),
-			DBName:   getEnv("DB_NAME", "taskqueue"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Driver:     getEnv("DB_DRIVER", "postgres"),
+			SQLitePath: getEnv("SQLITE_PATH", "taskqueue.db"),
+			Host:       getEnv("DB_HOST", "localhost"),
+			Port:       getEnv("DB_PORT", "5432"),
+			User:       getEnv("DB_USER", "admin"),
+			Password:   getEnv("DB_PASSWORD", "secret123"),
+			DBName:     getEnv("DB_NAME", "taskqueue"),
+			SSLMode:    getEnv("DB_SSLMODE", "disable"),
 		},
 		Redis: RedisConfig{
 			Addr:     getEnv("REDIS_ADDR", "localhost:6379"),
@@ -66,14 +207,187 @@ func Load() (*Config, error) {
 			DB:       getEnvAsInt("REDIS_DB", 0),
 		},
 		Worker: WorkerConfig{
-			Concurrency: getEnvAsInt("WORKER_CONCURRENCY", 20),
-			RetentionMinutes: getEnvAsInt("ASYNQ_RETENTION_MINUTES", 0),
+			Concurrency:      getEnvAsInt("WORKER_CONCURRENCY", 20),
+			RetentionMinutes: getEnvAsInt("ASYNQ_RETENTION_MINUTES", 30),
+			PayloadCodec:     getEnv("TASK_PAYLOAD_CODEC", "json"),
+
+			AnalyticsBatchMaxSize:     getEnvAsInt("ANALYTICS_BATCH_MAX_SIZE", 500),
+			AnalyticsBatchGracePeriod: getEnvAsDuration("ANALYTICS_BATCH_GRACE_PERIOD", 5*time.Second),
+			AnalyticsBatchMaxDelay:    getEnvAsDuration("ANALYTICS_BATCH_MAX_DELAY", 30*time.Second),
+		},
+		Backpressure: BackpressureConfig{
+			Enabled:         getEnvAsBool("BACKPRESSURE_ENABLED", true),
+			RefreshInterval: getEnvAsDuration("BACKPRESSURE_REFRESH_INTERVAL", 2*time.Second),
+			ShedBacklog:     getEnvAsInt("BACKPRESSURE_SHED_BACKLOG", 20000),
+			RejectBacklog:   getEnvAsInt("BACKPRESSURE_REJECT_BACKLOG", 50000),
+			ShedLatency:     getEnvAsDuration("BACKPRESSURE_SHED_LATENCY", 30*time.Second),
+			RejectLatency:   getEnvAsDuration("BACKPRESSURE_REJECT_LATENCY", 2*time.Minute),
+			RejectStatus:    getEnvAsInt("BACKPRESSURE_REJECT_STATUS", 503),
+			RetryAfter:      getEnvAsDuration("BACKPRESSURE_RETRY_AFTER", 5*time.Second),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: getEnvAsBool("RATE_LIMIT_ENABLED", true),
+			Backend: getEnv("RATE_LIMIT_BACKEND", "memory"),
+			Groups: map[string]RateLimitGroup{
+				"orders": loadRateLimitGroup("ORDERS", RateLimitGroup{
+					ClientRPS:   1000,
+					ClientBurst: 2000,
+				}),
+				"admin": loadRateLimitGroup("ADMIN", RateLimitGroup{
+					ClientRPS:   50,
+					ClientBurst: 100,
+				}),
+				"analytics": loadRateLimitGroup("ANALYTICS", RateLimitGroup{
+					ClientRPS:   10,
+					ClientBurst: 20,
+				}),
+				"carriers": loadRateLimitGroup("CARRIERS", RateLimitGroup{
+					ClientRPS:   200,
+					ClientBurst: 400,
+				}),
+			},
+		},
+		Auth: AuthConfig{
+			Enabled:          getEnvAsBool("AUTH_ENABLED", true),
+			APIKeys:          getEnv("API_KEYS", ""),
+			JWTSecret:        getEnv("JWT_SECRET", ""),
+			JWTPublicKeyFile: getEnv("JWT_PUBLIC_KEY_FILE", ""),
+			JWTIssuer:        getEnv("JWT_ISSUER", ""),
+			JWTAudience:      getEnv("JWT_AUDIENCE", ""),
+		},
+		Monitoring: MonitoringConfig{
+			PrometheusEnabled: getEnvAsBool("ENABLE_PROMETHEUS", true),
+			WorkerMetricsAddr: getEnv("WORKER_METRICS_ADDR", ":9091"),
+		},
+		Pricing: PricingConfig{
+			TaxRates:         getEnv("TAX_RATES", "US:7.25,USA:7.25,CA:5,GB:20,DE:19,FR:20,VN:10,JP:10"),
+			DefaultTaxRate:   getEnv("DEFAULT_TAX_RATE", "0"),
+			ShippingFees:     getEnv("SHIPPING_FEES", "standard:5.00,express:15.00,overnight:30.00"),
+			FreeShippingOver: getEnv("FREE_SHIPPING_OVER", ""),
+		},
+		OrderBatch: OrderBatchConfig{
+			MaxOrders:          getEnvAsInt("ORDER_BATCH_MAX", 100),
+			EnqueueConcurrency: getEnvAsInt("ORDER_BATCH_ENQUEUE_CONCURRENCY", 16),
+		},
+		Reconcile: ReconcileConfig{
+			Cron:                   getEnv("RECONCILE_CRON", "@every 5m"),
+			PendingAfter:           getEnvAsDuration("RECONCILE_PENDING_AFTER", 15*time.Minute),
+			PaymentProcessingAfter: getEnvAsDuration("RECONCILE_PAYMENT_PROCESSING_AFTER", 10*time.Minute),
+			FailAfter:              getEnvAsDuration("RECONCILE_FAIL_AFTER", 2*time.Hour),
+			BatchSize:              getEnvAsInt("RECONCILE_BATCH_SIZE", 500),
+		},
+		Email: EmailConfig{
+			Transport:    getEnv("EMAIL_TRANSPORT", "file"),
+			From:         getEnv("EMAIL_FROM", "Go Asynq Shop <orders@example.com>"),
+			FileDir:      getEnv("EMAIL_FILE_DIR", "mail"),
+			SMTPHost:     getEnv("SMTP_HOST", ""),
+			SMTPPort:     getEnv("SMTP_PORT", "587"),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		},
+		Storage: StorageConfig{
+			Backend:         getEnv("STORAGE_BACKEND", "file"),
+			Dir:             getEnv("STORAGE_DIR", "storage"),
+			S3Endpoint:      getEnv("S3_ENDPOINT", "localhost:9000"),
+			S3Region:        getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:        getEnv("S3_BUCKET", "taskqueue"),
+			S3AccessKey:     getEnv("S3_ACCESS_KEY", "minioadmin"),
+			S3SecretKey:     getEnv("S3_SECRET_KEY", "minioadmin"),
+			S3UseSSL:        getEnvAsBool("S3_USE_SSL", false),
+			SignedURLExpiry: getEnvAsDuration("STORAGE_SIGNED_URL_EXPIRY", 15*time.Minute),
+		},
+		Carrier: CarrierConfig{
+			Name:             getEnv("CARRIER", "fake"),
+			WebhookSecret:    getEnv("CARRIER_WEBHOOK_SECRET", ""),
+			WebhookTolerance: getEnvAsDuration("CARRIER_WEBHOOK_TOLERANCE", 5*time.Minute),
+			ParcelMaxUnits:   getEnvAsInt("PARCEL_MAX_UNITS", 10),
 		},
 	}
 
+	if cfg.Backpressure.RejectStatus != 503 && cfg.Backpressure.RejectStatus != 429 {
+		return nil, fmt.Errorf("BACKPRESSURE_REJECT_STATUS must be 503 or 429, got %d", cfg.Backpressure.RejectStatus)
+	}
+
+	switch cfg.Database.Driver {
+	case "postgres", "sqlite", "memory":
+	default:
+		return nil, fmt.Errorf("DB_DRIVER must be postgres, sqlite or memory, got %q", cfg.Database.Driver)
+	}
+
+	if cfg.OrderBatch.MaxOrders < 1 || cfg.OrderBatch.EnqueueConcurrency < 1 {
+		return nil, fmt.Errorf("ORDER_BATCH_MAX and ORDER_BATCH_ENQUEUE_CONCURRENCY must be at least 1")
+	}
+
+	// Asynq checks groups every GroupGracePeriod and raises shorter periods to a second
+	if cfg.Worker.AnalyticsBatchMaxSize < 1 || cfg.Worker.AnalyticsBatchGracePeriod < time.Second ||
+		cfg.Worker.AnalyticsBatchMaxDelay < cfg.Worker.AnalyticsBatchGracePeriod {
+		return nil, fmt.Errorf("ANALYTICS_BATCH_MAX_SIZE must be at least 1, ANALYTICS_BATCH_GRACE_PERIOD at least 1s and ANALYTICS_BATCH_MAX_DELAY at least the grace period")
+	}
+
+	if cfg.Reconcile.PendingAfter <= 0 || cfg.Reconcile.PaymentProcessingAfter <= 0 || cfg.Reconcile.BatchSize < 1 {
+		return nil, fmt.Errorf("RECONCILE_PENDING_AFTER, RECONCILE_PAYMENT_PROCESSING_AFTER and RECONCILE_BATCH_SIZE must be positive")
+	}
+	if cfg.Reconcile.FailAfter < cfg.Reconcile.PendingAfter || cfg.Reconcile.FailAfter < cfg.Reconcile.PaymentProcessingAfter {
+		return nil, fmt.Errorf("RECONCILE_FAIL_AFTER (%s) must not be shorter than the reconcile thresholds", cfg.Reconcile.FailAfter)
+	}
+
+	switch cfg.Worker.PayloadCodec {
+	case "json", "msgpack", "protobuf":
+	default:
+		return nil, fmt.Errorf("TASK_PAYLOAD_CODEC must be json, msgpack or protobuf, got %q", cfg.Worker.PayloadCodec)
+	}
+
+	switch cfg.Email.Transport {
+	case "smtp":
+		if cfg.Email.SMTPHost == "" {
+			return nil, fmt.Errorf("SMTP_HOST is required when EMAIL_TRANSPORT is smtp")
+		}
+	case "file":
+	default:
+		return nil, fmt.Errorf("EMAIL_TRANSPORT must be smtp or file, got %q", cfg.Email.Transport)
+	}
+	if _, err := mail.ParseAddress(cfg.Email.From); err != nil {
+		return nil, fmt.Errorf("invalid EMAIL_FROM %q: %w", cfg.Email.From, err)
+	}
+
+	switch cfg.Storage.Backend {
+	case "file", "s3":
+	default:
+		return nil, fmt.Errorf("STORAGE_BACKEND must be file or s3, got %q", cfg.Storage.Backend)
+	}
+	// S3 presigned URLs are valid for at most 7 days
+	if cfg.Storage.SignedURLExpiry < time.Second || cfg.Storage.SignedURLExpiry > 7*24*time.Hour {
+		return nil, fmt.Errorf("STORAGE_SIGNED_URL_EXPIRY must be between 1s and 168h, got %s", cfg.Storage.SignedURLExpiry)
+	}
+
+	if cfg.Carrier.Name != "fake" {
+		return nil, fmt.Errorf("CARRIER must be fake, got %q", cfg.Carrier.Name)
+	}
+	if cfg.Carrier.WebhookSecret == "" && env == "production" {
+		return nil, fmt.Errorf("CARRIER_WEBHOOK_SECRET is required in production")
+	}
+	if cfg.Carrier.WebhookTolerance <= 0 || cfg.Carrier.ParcelMaxUnits < 1 {
+		return nil, fmt.Errorf("CARRIER_WEBHOOK_TOLERANCE must be positive and PARCEL_MAX_UNITS at least 1")
+	}
+
+	if cfg.RateLimit.Backend != "memory" && cfg.RateLimit.Backend != "redis" {
+		return nil, fmt.Errorf("RATE_LIMIT_BACKEND must be memory or redis, got %q", cfg.RateLimit.Backend)
+	}
+
 	return cfg, nil
 }
 
+// loadRateLimitGroup reads RATE_LIMIT_<GROUP>_* overrides for a route group
+func loadRateLimitGroup(group string, defaults RateLimitGroup) RateLimitGroup {
+	prefix := "RATE_LIMIT_" + group + "_"
+	return RateLimitGroup{
+		ClientRPS:   getEnvAsFloat(prefix+"CLIENT_RPS", defaults.ClientRPS),
+		ClientBurst: getEnvAsInt(prefix+"CLIENT_BURST", defaults.ClientBurst),
+		GlobalRPS:   getEnvAsFloat(prefix+"GLOBAL_RPS", defaults.GlobalRPS),
+		GlobalBurst: getEnvAsInt(prefix+"GLOBAL_BURST", defaults.GlobalBurst),
+	}
+}
+
 // getEnv reads an environment variable or returns a default value
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -97,6 +411,51 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return value
 }
 
+// getEnvAsFloat reads an environment variable as float or returns a default value
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// getEnvAsBool reads an environment variable as boolean or returns a default value
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// getEnvAsDuration reads an environment variable as duration (e.g. "5s") or returns a default value
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := time.ParseDuration(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
 // GetDatabaseDSN returns PostgreSQL connection string
 func (c *Config) GetDatabaseDSN() string {
 	return fmt.Sprintf(
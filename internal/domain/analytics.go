@@ -0,0 +1,79 @@
+package domain
+
+import "time"
+
+// AnalyticsEvent is one order as recorded in the local analytics store
+type AnalyticsEvent struct {
+	OrderID       string    `json:"order_id"`
+	CustomerID    string    `json:"customer_id"`
+	TotalAmount   Money     `json:"total_amount"`
+	ItemCount     int       `json:"item_count"`
+	PaymentMethod string    `json:"payment_method"`
+	CreatedAt     time.Time `json:"created_at"`  // When the event was produced
+	RecordedAt    time.Time `json:"recorded_at"` // When its batch was written
+}
+
+// AnalyticsGranularity is the width of the time buckets analytics are rolled up in
+type AnalyticsGranularity string
+
+// Analytics granularities
+const (
+	GranularityMinute AnalyticsGranularity = "minute"
+	GranularityHour   AnalyticsGranularity = "hour"
+)
+
+// Duration returns the bucket width, or 0 for an unknown granularity
+func (g AnalyticsGranularity) Duration() time.Duration {
+	switch g {
+	case GranularityMinute:
+		return time.Minute
+	case GranularityHour:
+		return time.Hour
+	}
+	return 0
+}
+
+// AnalyticsBucket sums the events of one time bucket, currency and payment method,
+// as aggregated by the analytics store
+type AnalyticsBucket struct {
+	Start         time.Time
+	Currency      string
+	PaymentMethod string
+	Orders        int64
+	RevenueMinor  int64 // Minor units of Currency
+	Items         int64
+}
+
+// AnalyticsRollup summarizes the orders of one time bucket, or of a whole range
+type AnalyticsRollup struct {
+	Start          time.Time
+	Orders         int64
+	Revenue        map[string]Money // By currency
+	Items          int64
+	PaymentMethods map[string]int64 // Orders by payment method
+}
+
+// NewAnalyticsRollup creates an empty rollup of the bucket starting at start
+func NewAnalyticsRollup(start time.Time) *AnalyticsRollup {
+	return &AnalyticsRollup{
+		Start:          start,
+		Revenue:        map[string]Money{},
+		PaymentMethods: map[string]int64{},
+	}
+}
+
+// Add counts the events of b in the rollup
+func (r *AnalyticsRollup) Add(b AnalyticsBucket) {
+	r.Orders += b.Orders
+	r.Items += b.Items
+	r.PaymentMethods[b.PaymentMethod] += b.Orders
+	r.Revenue[b.Currency] = r.Revenue[b.Currency].Add(NewMoney(b.RevenueMinor, b.Currency))
+}
+
+// AverageItems returns the mean number of items per order
+func (r *AnalyticsRollup) AverageItems() float64 {
+	if r.Orders == 0 {
+		return 0
+	}
+	return float64(r.Items) / float64(r.Orders)
+}
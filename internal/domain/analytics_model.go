@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+// AnalyticsEventModel represents the analytics_events table, one row per order
+type AnalyticsEventModel struct {
+	OrderID       string    `gorm:"primaryKey;type:varchar(50)"`
+	CustomerID    string    `gorm:"type:varchar(100);not null"`
+	TotalMinor    int64     `gorm:"column:total_amount_minor;not null"` // Minor units (cents)
+	Currency      string    `gorm:"type:char(3);not null"`
+	ItemCount     int       `gorm:"not null"`
+	PaymentMethod string    `gorm:"type:varchar(50);not null"`
+	CreatedAt     time.Time `gorm:"not null;index"`
+	RecordedAt    time.Time `gorm:"not null"`
+}
+
+// TableName overrides the table name
+func (AnalyticsEventModel) TableName() string {
+	return "analytics_events"
+}
+
+// ToEvent converts AnalyticsEventModel to domain.AnalyticsEvent
+func (m *AnalyticsEventModel) ToEvent() *AnalyticsEvent {
+	return &AnalyticsEvent{
+		OrderID:       m.OrderID,
+		CustomerID:    m.CustomerID,
+		TotalAmount:   NewMoney(m.TotalMinor, m.Currency),
+		ItemCount:     m.ItemCount,
+		PaymentMethod: m.PaymentMethod,
+		CreatedAt:     m.CreatedAt,
+		RecordedAt:    m.RecordedAt,
+	}
+}
+
+// FromAnalyticsEvent converts domain.AnalyticsEvent to AnalyticsEventModel
+func FromAnalyticsEvent(e *AnalyticsEvent) *AnalyticsEventModel {
+	return &AnalyticsEventModel{
+		OrderID:       e.OrderID,
+		CustomerID:    e.CustomerID,
+		TotalMinor:    e.TotalAmount.Amount,
+		Currency:      e.TotalAmount.Currency,
+		ItemCount:     e.ItemCount,
+		PaymentMethod: e.PaymentMethod,
+		CreatedAt:     e.CreatedAt,
+		RecordedAt:    e.RecordedAt,
+	}
+}
@@ -0,0 +1,75 @@
+package domain
+
+import "time"
+
+// CouponModel represents the coupons table (GORM model).
+// Amounts are in Currency; used_count is only changed by conditional updates.
+type CouponModel struct {
+	Code          string     `gorm:"primaryKey;type:varchar(50)"`
+	Type          string     `gorm:"type:varchar(20);not null"`
+	PercentBP     int64      `gorm:"column:percent_bp;not null;default:0"`
+	AmountMinor   int64      `gorm:"column:amount_minor;not null;default:0"`
+	MinSpendMinor int64      `gorm:"column:min_spend_minor;not null;default:0"`
+	Currency      string     `gorm:"type:char(3);not null;default:'USD'"`
+	MaxUses       int        `gorm:"not null;default:0"` // 0 = unlimited
+	UsedCount     int        `gorm:"not null;default:0;check:chk_coupons_used_count,used_count >= 0"`
+	Active        bool       `gorm:"not null;default:true"`
+	ExpiresAt     *time.Time `gorm:""`
+	CreatedAt     time.Time  `gorm:"not null"`
+	UpdatedAt     time.Time  `gorm:"not null"`
+}
+
+// TableName overrides the table name
+func (CouponModel) TableName() string {
+	return "coupons"
+}
+
+// ToCoupon converts CouponModel to domain.Coupon
+func (m *CouponModel) ToCoupon() *Coupon {
+	return &Coupon{
+		Code:      m.Code,
+		Type:      CouponType(m.Type),
+		Percent:   BasisPoints(m.PercentBP),
+		Amount:    NewMoney(m.AmountMinor, m.Currency),
+		MinSpend:  NewMoney(m.MinSpendMinor, m.Currency),
+		MaxUses:   m.MaxUses,
+		UsedCount: m.UsedCount,
+		Active:    m.Active,
+		ExpiresAt: m.ExpiresAt,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	}
+}
+
+// FromCoupon converts domain.Coupon to CouponModel
+func FromCoupon(c *Coupon) *CouponModel {
+	return &CouponModel{
+		Code:          c.Code,
+		Type:          string(c.Type),
+		PercentBP:     int64(c.Percent),
+		AmountMinor:   c.Amount.Amount,
+		MinSpendMinor: c.MinSpend.Amount,
+		Currency:      c.Currency(),
+		MaxUses:       c.MaxUses,
+		UsedCount:     c.UsedCount,
+		Active:        c.Active,
+		ExpiresAt:     c.ExpiresAt,
+		CreatedAt:     c.CreatedAt,
+		UpdatedAt:     c.UpdatedAt,
+	}
+}
+
+// CouponRedemptionModel represents the coupon_redemptions table:
+// one row per order that used a coupon
+type CouponRedemptionModel struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	CouponCode string    `gorm:"type:varchar(50);not null;index"`
+	OrderID    string    `gorm:"type:varchar(50);not null;uniqueIndex"`
+	Discount   int64     `gorm:"column:discount_minor;not null"`
+	CreatedAt  time.Time `gorm:"not null"`
+}
+
+// TableName overrides the table name
+func (CouponRedemptionModel) TableName() string {
+	return "coupon_redemptions"
+}
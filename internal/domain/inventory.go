@@ -0,0 +1,42 @@
+package domain
+
+import "time"
+
+// Product represents a sellable item in the catalog
+type Product struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Price     Money     `json:"price"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// StockLevel is the inventory of one product.
+// Reserved units belong to orders that are not shipped yet.
+type StockLevel struct {
+	ProductID string    `json:"product_id"`
+	OnHand    int       `json:"on_hand"`
+	Reserved  int       `json:"reserved"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Available returns the units that can still be reserved
+func (s *StockLevel) Available() int {
+	return s.OnHand - s.Reserved
+}
+
+// ReservationStatus represents the state of a stock reservation
+type ReservationStatus string
+
+const (
+	ReservationStatusReserved  ReservationStatus = "reserved"  // Held for the order
+	ReservationStatusReleased  ReservationStatus = "released"  // Returned to stock (cancel, payment failure)
+	ReservationStatusCommitted ReservationStatus = "committed" // Left the warehouse (shipped)
+)
+
+// StockRequest is a quantity of one product requested by an order
+type StockRequest struct {
+	ProductID string
+	Quantity  int
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProductModel represents the products table (GORM model)
+type ProductModel struct {
+	ID         string          `gorm:"primaryKey;type:varchar(50)"`
+	Name       string          `gorm:"type:varchar(255);not null"`
+	PriceMinor int64           `gorm:"column:price_minor;not null;default:0"` // Minor units (cents)
+	Currency   string          `gorm:"type:char(3);not null;default:'USD'"`
+	Active     bool            `gorm:"not null;default:true"`
+	Inventory  *InventoryModel `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	CreatedAt  time.Time       `gorm:"not null"`
+	UpdatedAt  time.Time       `gorm:"not null"`
+	DeletedAt  gorm.DeletedAt  `gorm:"index"`
+}
+
+// TableName overrides the table name
+func (ProductModel) TableName() string {
+	return "products"
+}
+
+// ToProduct converts ProductModel to domain.Product
+func (m *ProductModel) ToProduct() *Product {
+	return &Product{
+		ID:        m.ID,
+		Name:      m.Name,
+		Price:     NewMoney(m.PriceMinor, m.Currency),
+		Active:    m.Active,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	}
+}
+
+// FromProduct converts domain.Product to ProductModel
+func FromProduct(p *Product) *ProductModel {
+	return &ProductModel{
+		ID:         p.ID,
+		Name:       p.Name,
+		PriceMinor: p.Price.Amount,
+		Currency:   p.Price.Currency,
+		Active:     p.Active,
+		CreatedAt:  p.CreatedAt,
+		UpdatedAt:  p.UpdatedAt,
+	}
+}
+
+// InventoryModel represents the inventory table (one row per product).
+// The check constraint makes overselling impossible at the database level.
+type InventoryModel struct {
+	ProductID string    `gorm:"primaryKey;type:varchar(50)"`
+	OnHand    int       `gorm:"not null;default:0;check:chk_inventory_on_hand,on_hand >= 0"`
+	Reserved  int       `gorm:"not null;default:0;check:chk_inventory_reserved,reserved >= 0 AND reserved <= on_hand"`
+	UpdatedAt time.Time `gorm:"not null"`
+}
+
+// TableName overrides the table name
+func (InventoryModel) TableName() string {
+	return "inventory"
+}
+
+// ToStockLevel converts InventoryModel to domain.StockLevel
+func (m *InventoryModel) ToStockLevel() *StockLevel {
+	return &StockLevel{
+		ProductID: m.ProductID,
+		OnHand:    m.OnHand,
+		Reserved:  m.Reserved,
+		UpdatedAt: m.UpdatedAt,
+	}
+}
+
+// InventoryReservationModel represents the inventory_reservations table:
+// units of a product held for an order
+type InventoryReservationModel struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	OrderID   string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_reservations_order_product,priority:1"`
+	ProductID string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_reservations_order_product,priority:2"`
+	Quantity  int       `gorm:"not null"`
+	Status    string    `gorm:"type:varchar(20);not null;index"`
+	CreatedAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+}
+
+// TableName overrides the table name
+func (InventoryReservationModel) TableName() string {
+	return "inventory_reservations"
+}
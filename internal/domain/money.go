@@ -0,0 +1,223 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used when a request does not name a currency
+const DefaultCurrency = "USD"
+
+// Money errors
+var (
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	ErrInvalidAmount       = errors.New("invalid amount")
+)
+
+// currencyExponents lists the supported ISO 4217 currencies and their minor unit digits
+var currencyExponents = map[string]int{
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"CAD": 2,
+	"AUD": 2,
+	"SGD": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+}
+
+// IsSupportedCurrency reports whether amounts in this currency can be handled
+func IsSupportedCurrency(code string) bool {
+	_, ok := currencyExponents[code]
+	return ok
+}
+
+// currencyExponent returns the minor unit digits of a currency (2 when unknown)
+func currencyExponent(code string) int {
+	if exp, ok := currencyExponents[code]; ok {
+		return exp
+	}
+	return 2
+}
+
+// Money is an exact amount in the minor unit of its currency (cents for USD).
+// In JSON it is {"amount": "19.99", "currency": "USD"}.
+type Money struct {
+	Amount   int64  // Minor units
+	Currency string // ISO 4217 code
+}
+
+// NewMoney creates an amount from minor units
+func NewMoney(minor int64, currency string) Money {
+	return Money{Amount: minor, Currency: currency}
+}
+
+// ParseMoney parses a decimal string such as "19.99" without going through float64
+func ParseMoney(s, currency string) (Money, error) {
+	if !IsSupportedCurrency(currency) {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnsupportedCurrency, currency)
+	}
+	minor, err := parseMinor(s, currencyExponent(currency))
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// parseMinor converts a decimal string to minor units; extra non-zero fraction digits are an error
+func parseMinor(s string, exp int) (int64, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	frac = strings.TrimRight(frac, "0")
+	if whole == "" || len(frac) > exp || strings.ContainsAny(whole+frac, "+-eE") {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+
+	minor, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if neg {
+		minor = -minor
+	}
+	return minor, nil
+}
+
+// Add returns m + other. Amounts in different currencies cannot be added;
+// a zero value without currency takes the other currency.
+func (m Money) Add(other Money) Money {
+	currency := m.Currency
+	if currency == "" {
+		currency = other.Currency
+	} else if other.Currency != "" && other.Currency != currency {
+		panic(fmt.Sprintf("money: cannot add %s to %s", other.Currency, currency))
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: currency}
+}
+
+// Sub returns m - other (same currency rules as Add)
+func (m Money) Sub(other Money) Money {
+	return m.Add(Money{Amount: -other.Amount, Currency: other.Currency})
+}
+
+// Mul returns m multiplied by a quantity
+func (m Money) Mul(n int64) Money {
+	return Money{Amount: m.Amount * n, Currency: m.Currency}
+}
+
+// Percent returns the given share of m in basis points (725 = 7.25%),
+// rounded half away from zero to the minor unit
+func (m Money) Percent(bp BasisPoints) Money {
+	product := m.Amount * int64(bp)
+	if product < 0 {
+		return Money{Amount: (product - 5000) / 10000, Currency: m.Currency}
+	}
+	return Money{Amount: (product + 5000) / 10000, Currency: m.Currency}
+}
+
+// Min returns the smaller of two amounts in the same currency
+func (m Money) Min(other Money) Money {
+	if other.Amount < m.Amount {
+		return other.OrDefaultCurrency(m.Currency)
+	}
+	return m
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Decimal formats the amount in major units, e.g. "19.99"
+func (m Money) Decimal() string {
+	exp := currencyExponent(m.Currency)
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	if exp == 0 {
+		return sign + strconv.FormatInt(amount, 10)
+	}
+
+	digits := fmt.Sprintf("%0*d", exp+1, amount)
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// String formats the amount with its currency, e.g. "19.99 USD"
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Decimal()
+	}
+	return m.Decimal() + " " + m.Currency
+}
+
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON encodes the amount as a decimal string so clients never see float rounding
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+// UnmarshalJSON decodes {"amount": "19.99", "currency": "USD"}. A bare number is
+// accepted for data written before amounts carried a currency (two decimals, no
+// currency); callers fill the currency in from context.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] != '{' {
+		var n json.Number
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidAmount, data)
+		}
+		minor, err := parseLegacyAmount(n.String())
+		if err != nil {
+			return err
+		}
+		*m = Money{Amount: minor}
+		return nil
+	}
+
+	var raw moneyJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	minor, err := parseMinor(raw.Amount, currencyExponent(raw.Currency))
+	if err != nil {
+		return err
+	}
+	*m = Money{Amount: minor, Currency: raw.Currency}
+	return nil
+}
+
+// parseLegacyAmount reads a float-era amount, rounding to cents
+func parseLegacyAmount(s string) (int64, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if f < 0 {
+		return -int64(-f*100 + 0.5), nil
+	}
+	return int64(f*100 + 0.5), nil
+}
+
+// OrDefaultCurrency returns m with currency set when it has none
+func (m Money) OrDefaultCurrency(currency string) Money {
+	if m.Currency == "" {
+		m.Currency = currency
+	}
+	return m
+}
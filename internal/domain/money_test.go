@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in       string
+		currency string
+		want     int64
+		err      error
+	}{
+		{"19.99", "USD", 1999, nil},
+		{"19.9", "USD", 1990, nil},
+		{"19.990", "USD", 1999, nil},
+		{"-0.01", "USD", -1, nil},
+		{"0.001", "USD", 0, ErrInvalidAmount},
+		{"1e3", "USD", 0, ErrInvalidAmount},
+		// Zero-decimal currencies take whole units only
+		{"1500", "JPY", 1500, nil},
+		{"1500.00", "JPY", 1500, nil},
+		{"1500.5", "JPY", 0, ErrInvalidAmount},
+		{"10", "XXX", 0, ErrUnsupportedCurrency},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in, tt.currency)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ParseMoney(%q, %s) error = %v, want %v", tt.in, tt.currency, err, tt.err)
+			continue
+		}
+		if err == nil && got != NewMoney(tt.want, tt.currency) {
+			t.Errorf("ParseMoney(%q, %s) = %d, want %d", tt.in, tt.currency, got.Amount, tt.want)
+		}
+	}
+}
+
+func TestMoneyDecimal(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{NewMoney(1999, "USD"), "19.99"},
+		{NewMoney(5, "USD"), "0.05"},
+		{NewMoney(-5, "USD"), "-0.05"},
+		{NewMoney(1500, "JPY"), "1500"},
+		{NewMoney(-1500, "KRW"), "-1500"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.Decimal(); got != tt.want {
+			t.Errorf("%d %s: Decimal() = %q, want %q", tt.m.Amount, tt.m.Currency, got, tt.want)
+		}
+	}
+}
+
+// Percent rounds half away from zero to the minor unit
+func TestMoneyPercent(t *testing.T) {
+	tests := []struct {
+		amount int64
+		bp     BasisPoints
+		want   int64
+	}{
+		{1000, 725, 73},   // 72.5 -> 73
+		{1010, 725, 73},   // 73.225 -> 73
+		{10, 5000, 5},     // exact
+		{1, 5000, 1},      // 0.5 -> 1
+		{3, 5000, 2},      // 1.5 -> 2
+		{-1, 5000, -1},    // -0.5 -> -1
+		{-3, 5000, -2},    // -1.5 -> -2
+		{1, 4999, 0},      // just under half
+		{1005, 1000, 101}, // 100.5 -> 101, e.g. 10% of 1005 JPY
+		{1999, 0, 0},
+	}
+	for _, tt := range tests {
+		got := NewMoney(tt.amount, "USD").Percent(tt.bp)
+		if got.Amount != tt.want {
+			t.Errorf("%d at %s%% = %d, want %d", tt.amount, tt.bp, got.Amount, tt.want)
+		}
+	}
+}
+
+func TestCouponDiscount(t *testing.T) {
+	tests := []struct {
+		name     string
+		coupon   Coupon
+		subtotal Money
+		want     Money
+	}{
+		{"percentage", Coupon{Type: CouponTypePercentage, Percent: 1000}, NewMoney(2599, "USD"), NewMoney(260, "USD")},
+		{"percentage rounds half up", Coupon{Type: CouponTypePercentage, Percent: 2500}, NewMoney(10, "USD"), NewMoney(3, "USD")},
+		{"percentage of a zero-decimal currency", Coupon{Type: CouponTypePercentage, Percent: 1500}, NewMoney(1010, "JPY"), NewMoney(152, "JPY")},
+		{"fixed", Coupon{Type: CouponTypeFixed, Amount: NewMoney(500, "USD")}, NewMoney(2000, "USD"), NewMoney(500, "USD")},
+		{"fixed larger than the subtotal", Coupon{Type: CouponTypeFixed, Amount: NewMoney(5000, "USD")}, NewMoney(1999, "USD"), NewMoney(1999, "USD")},
+		{"over 100 percent", Coupon{Type: CouponTypePercentage, Percent: 15000}, NewMoney(1000, "USD"), NewMoney(1000, "USD")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.coupon.Discount(tt.subtotal); got != tt.want {
+				t.Errorf("Discount(%s) = %s, want %s", tt.subtotal, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 // OrderStatus represents the current state of an order
 type OrderStatus string
@@ -16,42 +19,89 @@ const (
 	OrderStatusCancelled         OrderStatus = "cancelled"
 )
 
+// IsTerminal reports whether the order can no longer change status
+func (s OrderStatus) IsTerminal() bool {
+	return s == OrderStatusDelivered ||
+		s == OrderStatusCancelled ||
+		s == OrderStatusPaymentFailed
+}
+
+// orderProgress orders the statuses an order moves through on its way to the customer
+var orderProgress = map[OrderStatus]int{
+	OrderStatusPending:           0,
+	OrderStatusPaymentProcessing: 1,
+	OrderStatusConfirmed:         2,
+	OrderStatusProcessing:        3,
+	OrderStatusShipped:           4,
+	OrderStatusDelivered:         5,
+}
+
+// ErrInvalidStatusTransition is returned for status changes that would take an order back
+var ErrInvalidStatusTransition = errors.New("invalid order status transition")
+
+// CanMoveTo reports whether an order in status s may move to next. Orders only
+// move forward; cancellation and failed payment end any order that has not ended.
+func (s OrderStatus) CanMoveTo(next OrderStatus) bool {
+	switch {
+	case s == next:
+		return true
+	case s.IsTerminal():
+		return false
+	case next == OrderStatusCancelled || next == OrderStatusPaymentFailed:
+		return true
+	}
+	return orderProgress[next] > orderProgress[s]
+}
+
 // PaymentStatus represents the payment state
 type PaymentStatus string
 
 const (
-	PaymentStatusPending   PaymentStatus = "pending"
+	PaymentStatusPending    PaymentStatus = "pending"
 	PaymentStatusProcessing PaymentStatus = "processing"
-	PaymentStatusCompleted PaymentStatus = "completed"
-	PaymentStatusFailed    PaymentStatus = "failed"
-	PaymentStatusRefunded  PaymentStatus = "refunded"
+	PaymentStatusCompleted  PaymentStatus = "completed"
+	PaymentStatusFailed     PaymentStatus = "failed"
+	PaymentStatusRefunded   PaymentStatus = "refunded"
 )
 
 // Order represents an order in the system
 type Order struct {
-	ID              string        `json:"id"`
-	CustomerID      string        `json:"customer_id"`
-	CustomerEmail   string        `json:"customer_email"`
-	Items           []OrderItem   `json:"items"`
-	TotalAmount     float64       `json:"total_amount"`
-	ShippingAddress Address       `json:"shipping_address"`
-	Status          OrderStatus   `json:"status"`
-	PaymentStatus   PaymentStatus `json:"payment_status"`
-	PaymentMethod   string        `json:"payment_method"`
-	InvoiceURL      string        `json:"invoice_url,omitempty"`
-	TrackingNumber  string        `json:"tracking_number,omitempty"`
-	Notes           string        `json:"notes,omitempty"`
-	CreatedAt       time.Time     `json:"created_at"`
-	UpdatedAt       time.Time     `json:"updated_at"`
+	ID               string         `json:"id"`
+	CustomerID       string         `json:"customer_id"`
+	CustomerEmail    string         `json:"customer_email"`
+	CustomerName     string         `json:"customer_name,omitempty"`
+	Items            []OrderItem    `json:"items"`
+	TotalAmount      Money          `json:"total_amount"` // Pricing.Total
+	Pricing          PriceBreakdown `json:"pricing"`
+	ShippingAddress  Address        `json:"shipping_address"`
+	Status           OrderStatus    `json:"status"`
+	PaymentStatus    PaymentStatus  `json:"payment_status"`
+	PaymentMethod    string         `json:"payment_method"`
+	ShippingPriority string         `json:"shipping_priority"`
+	InvoiceURL       string         `json:"invoice_url,omitempty"`
+	TrackingNumber   string         `json:"tracking_number,omitempty"`
+	Notes            string         `json:"notes,omitempty"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+
+	// Status transitions made in memory and not yet announced (see TakeStatusChanges)
+	statusChanges []StatusChange
+}
+
+// StatusChange records one order status transition
+type StatusChange struct {
+	From OrderStatus
+	To   OrderStatus
+	At   time.Time
 }
 
 // OrderItem represents a product in an order
 type OrderItem struct {
-	ProductID   string  `json:"product_id"`
-	ProductName string  `json:"product_name"`
-	Quantity    int     `json:"quantity"`
-	UnitPrice   float64 `json:"unit_price"`
-	Subtotal    float64 `json:"subtotal"`
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+	UnitPrice   Money  `json:"unit_price"`
+	Subtotal    Money  `json:"subtotal"`
 }
 
 // Address represents a shipping/billing address
@@ -64,14 +114,20 @@ type Address struct {
 }
 
 // CalculateTotal calculates the total amount for all items
-func (o *Order) CalculateTotal() float64 {
-	total := 0.0
+func (o *Order) CalculateTotal() Money {
+	total := Money{Currency: o.TotalAmount.Currency}
 	for _, item := range o.Items {
-		total += item.Subtotal
+		total = total.Add(item.Subtotal)
 	}
 	return total
 }
 
+// CanEdit checks if items, address and notes may still change: only until
+// payment processing starts
+func (o *Order) CanEdit() bool {
+	return o.Status == OrderStatusPending && o.PaymentStatus == PaymentStatusPending
+}
+
 // CanCancel checks if order can be cancelled
 func (o *Order) CanCancel() bool {
 	return o.Status == OrderStatusPending ||
@@ -81,7 +137,7 @@ func (o *Order) CanCancel() bool {
 
 // Cancel cancels the order
 func (o *Order) Cancel() {
-	o.Status = OrderStatusCancelled
+	o.setStatus(OrderStatusCancelled)
 	o.UpdatedAt = time.Now()
 }
 
@@ -92,14 +148,30 @@ func (o *Order) UpdatePaymentStatus(status PaymentStatus) {
 
 	switch status {
 	case PaymentStatusCompleted:
-		o.Status = OrderStatusConfirmed
+		o.setStatus(OrderStatusConfirmed)
 	case PaymentStatusFailed:
-		o.Status = OrderStatusPaymentFailed
+		o.setStatus(OrderStatusPaymentFailed)
 	}
 }
 
 // UpdateStatus updates the order status
 func (o *Order) UpdateStatus(status OrderStatus) {
-	o.Status = status
+	o.setStatus(status)
 	o.UpdatedAt = time.Now()
 }
+
+// TakeStatusChanges returns the transitions recorded since the last call and clears them
+func (o *Order) TakeStatusChanges() []StatusChange {
+	changes := o.statusChanges
+	o.statusChanges = nil
+	return changes
+}
+
+// setStatus changes the status and records the transition
+func (o *Order) setStatus(status OrderStatus) {
+	if o.Status == status {
+		return
+	}
+	o.statusChanges = append(o.statusChanges, StatusChange{From: o.Status, To: status, At: time.Now()})
+	o.Status = status
+}
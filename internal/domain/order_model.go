@@ -1,7 +1,6 @@
 package domain
 
 import (
-	"encoding/json"
 	"time"
 
 	"gorm.io/gorm"
@@ -9,21 +8,30 @@ import (
 
 // OrderModel represents the order table in database (GORM model)
 type OrderModel struct {
-	ID              string         `gorm:"primaryKey;type:varchar(50)"`
-	CustomerID      string         `gorm:"type:varchar(100);not null;index"`
-	CustomerEmail   string         `gorm:"type:varchar(255);not null"`
-	ItemsJSON       string         `gorm:"type:text;not null"` // JSON string of items
-	TotalAmount     float64        `gorm:"type:decimal(10,2);not null"`
-	AddressJSON     string         `gorm:"type:text;not null"` // JSON string of address
-	Status          string         `gorm:"type:varchar(50);not null;default:'pending';index"`
-	PaymentStatus   string         `gorm:"type:varchar(50);not null;default:'pending'"`
-	PaymentMethod   string         `gorm:"type:varchar(50);not null"`
-	InvoiceURL      string         `gorm:"type:varchar(500)"`
-	TrackingNumber  string         `gorm:"type:varchar(100)"`
-	Notes           string         `gorm:"type:text"`
-	CreatedAt       time.Time      `gorm:"not null;index"`
-	UpdatedAt       time.Time      `gorm:"not null"`
-	DeletedAt       gorm.DeletedAt `gorm:"index"` // Soft delete support
+	ID               string             `gorm:"primaryKey;type:varchar(50)"`
+	CustomerID       string             `gorm:"type:varchar(100);not null;index"`
+	CustomerEmail    string             `gorm:"type:varchar(255);not null"`
+	CustomerName     string             `gorm:"type:varchar(255);not null;default:''"`
+	Items            []OrderItemModel   `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE"`
+	TotalMinor       int64              `gorm:"column:total_amount_minor;not null;default:0"` // Minor units (cents)
+	Currency         string             `gorm:"type:char(3);not null;default:'USD'"`
+	SubtotalMinor    int64              `gorm:"column:subtotal_minor;not null;default:0"`
+	DiscountMinor    int64              `gorm:"column:discount_minor;not null;default:0"`
+	ShippingMinor    int64              `gorm:"column:shipping_minor;not null;default:0"`
+	TaxMinor         int64              `gorm:"column:tax_minor;not null;default:0"`
+	TaxRateBP        int64              `gorm:"column:tax_rate_bp;not null;default:0"`
+	CouponCode       string             `gorm:"type:varchar(50);index"`
+	ShippingPriority string             `gorm:"type:varchar(20);not null;default:'standard'"`
+	Address          *OrderAddressModel `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE"`
+	Status           string             `gorm:"type:varchar(50);not null;default:'pending';index"`
+	PaymentStatus    string             `gorm:"type:varchar(50);not null;default:'pending'"`
+	PaymentMethod    string             `gorm:"type:varchar(50);not null"`
+	InvoiceURL       string             `gorm:"type:varchar(500)"`
+	TrackingNumber   string             `gorm:"type:varchar(100)"`
+	Notes            string             `gorm:"type:text"`
+	CreatedAt        time.Time          `gorm:"not null;index"`
+	UpdatedAt        time.Time          `gorm:"not null"`
+	DeletedAt        gorm.DeletedAt     `gorm:"index"` // Soft delete support
 }
 
 // TableName overrides the table name
@@ -31,62 +39,149 @@ func (OrderModel) TableName() string {
 	return "orders"
 }
 
-// ToOrder converts OrderModel to domain.Order
-func (m *OrderModel) ToOrder() (*Order, error) {
-	var items []OrderItem
-	if err := json.Unmarshal([]byte(m.ItemsJSON), &items); err != nil {
-		return nil, err
+// OrderItemModel represents the order_items table: one row per order line.
+// product_id is indexed but not a foreign key, since orders outlive catalog entries.
+type OrderItemModel struct {
+	OrderID        string `gorm:"primaryKey;type:varchar(50)"`
+	LineNo         int    `gorm:"primaryKey;autoIncrement:false"` // Position in the order, from 0
+	ProductID      string `gorm:"type:varchar(50);not null;index"`
+	ProductName    string `gorm:"type:varchar(255);not null"`
+	Quantity       int    `gorm:"not null;check:chk_order_items_quantity,quantity > 0"`
+	UnitPriceMinor int64  `gorm:"column:unit_price_minor;not null"`
+	SubtotalMinor  int64  `gorm:"column:subtotal_minor;not null"`
+	Currency       string `gorm:"type:char(3);not null"`
+}
+
+// TableName overrides the table name
+func (OrderItemModel) TableName() string {
+	return "order_items"
+}
+
+// OrderAddressModel represents the order_addresses table (shipping address, one per order)
+type OrderAddressModel struct {
+	OrderID    string `gorm:"primaryKey;type:varchar(50)"`
+	Street     string `gorm:"type:varchar(255);not null"`
+	City       string `gorm:"type:varchar(100);not null"`
+	State      string `gorm:"type:varchar(100);not null"`
+	PostalCode string `gorm:"type:varchar(20);not null"`
+	Country    string `gorm:"type:varchar(100);not null;index"`
+}
+
+// TableName overrides the table name
+func (OrderAddressModel) TableName() string {
+	return "order_addresses"
+}
+
+// ToOrder converts OrderModel to domain.Order; Items and Address must be loaded
+func (m *OrderModel) ToOrder() *Order {
+	items := make([]OrderItem, len(m.Items))
+	for i, item := range m.Items {
+		items[i] = OrderItem{
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+			UnitPrice:   NewMoney(item.UnitPriceMinor, item.Currency),
+			Subtotal:    NewMoney(item.SubtotalMinor, item.Currency),
+		}
 	}
 
 	var address Address
-	if err := json.Unmarshal([]byte(m.AddressJSON), &address); err != nil {
-		return nil, err
+	if m.Address != nil {
+		address = Address{
+			Street:     m.Address.Street,
+			City:       m.Address.City,
+			State:      m.Address.State,
+			PostalCode: m.Address.PostalCode,
+			Country:    m.Address.Country,
+		}
 	}
 
 	return &Order{
-		ID:              m.ID,
-		CustomerID:      m.CustomerID,
-		CustomerEmail:   m.CustomerEmail,
-		Items:           items,
-		TotalAmount:     m.TotalAmount,
-		ShippingAddress: address,
-		Status:          OrderStatus(m.Status),
-		PaymentStatus:   PaymentStatus(m.PaymentStatus),
-		PaymentMethod:   m.PaymentMethod,
-		InvoiceURL:      m.InvoiceURL,
-		TrackingNumber:  m.TrackingNumber,
-		Notes:           m.Notes,
-		CreatedAt:       m.CreatedAt,
-		UpdatedAt:       m.UpdatedAt,
-	}, nil
+		ID:            m.ID,
+		CustomerID:    m.CustomerID,
+		CustomerEmail: m.CustomerEmail,
+		CustomerName:  m.CustomerName,
+		Items:         items,
+		TotalAmount:   NewMoney(m.TotalMinor, m.Currency),
+		Pricing: PriceBreakdown{
+			Subtotal:   NewMoney(m.SubtotalMinor, m.Currency),
+			Discount:   NewMoney(m.DiscountMinor, m.Currency),
+			Shipping:   NewMoney(m.ShippingMinor, m.Currency),
+			Tax:        NewMoney(m.TaxMinor, m.Currency),
+			TaxRate:    BasisPoints(m.TaxRateBP),
+			Total:      NewMoney(m.TotalMinor, m.Currency),
+			CouponCode: m.CouponCode,
+		},
+		ShippingAddress:  address,
+		Status:           OrderStatus(m.Status),
+		PaymentStatus:    PaymentStatus(m.PaymentStatus),
+		PaymentMethod:    m.PaymentMethod,
+		ShippingPriority: m.ShippingPriority,
+		InvoiceURL:       m.InvoiceURL,
+		TrackingNumber:   m.TrackingNumber,
+		Notes:            m.Notes,
+		CreatedAt:        m.CreatedAt,
+		UpdatedAt:        m.UpdatedAt,
+	}
 }
 
-// FromOrder converts domain.Order to OrderModel
-func FromOrder(order *Order) (*OrderModel, error) {
-	itemsJSON, err := json.Marshal(order.Items)
-	if err != nil {
-		return nil, err
+// FromOrder converts domain.Order to OrderModel with its items and address
+func FromOrder(order *Order) *OrderModel {
+	return &OrderModel{
+		ID:               order.ID,
+		CustomerID:       order.CustomerID,
+		CustomerEmail:    order.CustomerEmail,
+		CustomerName:     order.CustomerName,
+		Items:            FromOrderItems(order.ID, order.Items, order.TotalAmount.Currency),
+		TotalMinor:       order.TotalAmount.Amount,
+		Currency:         order.TotalAmount.Currency,
+		SubtotalMinor:    order.Pricing.Subtotal.Amount,
+		DiscountMinor:    order.Pricing.Discount.Amount,
+		ShippingMinor:    order.Pricing.Shipping.Amount,
+		TaxMinor:         order.Pricing.Tax.Amount,
+		TaxRateBP:        int64(order.Pricing.TaxRate),
+		CouponCode:       order.Pricing.CouponCode,
+		ShippingPriority: order.ShippingPriority,
+		Address:          FromAddress(order.ID, order.ShippingAddress),
+		Status:           string(order.Status),
+		PaymentStatus:    string(order.PaymentStatus),
+		PaymentMethod:    order.PaymentMethod,
+		InvoiceURL:       order.InvoiceURL,
+		TrackingNumber:   order.TrackingNumber,
+		Notes:            order.Notes,
+		CreatedAt:        order.CreatedAt,
+		UpdatedAt:        order.UpdatedAt,
 	}
+}
 
-	addressJSON, err := json.Marshal(order.ShippingAddress)
-	if err != nil {
-		return nil, err
+// FromOrderItems converts order lines to OrderItemModels numbered from 0.
+// Items without a currency (stored before amounts carried one) take the order currency.
+func FromOrderItems(orderID string, items []OrderItem, currency string) []OrderItemModel {
+	models := make([]OrderItemModel, len(items))
+	for i, item := range items {
+		unitPrice := item.UnitPrice.OrDefaultCurrency(currency)
+		models[i] = OrderItemModel{
+			OrderID:        orderID,
+			LineNo:         i,
+			ProductID:      item.ProductID,
+			ProductName:    item.ProductName,
+			Quantity:       item.Quantity,
+			UnitPriceMinor: unitPrice.Amount,
+			SubtotalMinor:  item.Subtotal.Amount,
+			Currency:       unitPrice.Currency,
+		}
 	}
+	return models
+}
 
-	return &OrderModel{
-		ID:             order.ID,
-		CustomerID:     order.CustomerID,
-		CustomerEmail:  order.CustomerEmail,
-		ItemsJSON:      string(itemsJSON),
-		TotalAmount:    order.TotalAmount,
-		AddressJSON:    string(addressJSON),
-		Status:         string(order.Status),
-		PaymentStatus:  string(order.PaymentStatus),
-		PaymentMethod:  order.PaymentMethod,
-		InvoiceURL:     order.InvoiceURL,
-		TrackingNumber: order.TrackingNumber,
-		Notes:          order.Notes,
-		CreatedAt:      order.CreatedAt,
-		UpdatedAt:      order.UpdatedAt,
-	}, nil
+// FromAddress converts a shipping address to OrderAddressModel
+func FromAddress(orderID string, a Address) *OrderAddressModel {
+	return &OrderAddressModel{
+		OrderID:    orderID,
+		Street:     a.Street,
+		City:       a.City,
+		State:      a.State,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+	}
 }
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// BasisPoints is a rate in hundredths of a percent (725 = 7.25%)
+type BasisPoints int64
+
+// ParsePercent parses a percentage such as "7.25" exactly
+func ParsePercent(s string) (BasisPoints, error) {
+	bp, err := parseMinor(s, 2)
+	if err != nil {
+		return 0, err
+	}
+	return BasisPoints(bp), nil
+}
+
+// String formats the rate as a percentage, e.g. "7.25"
+func (b BasisPoints) String() string {
+	return strings.TrimSuffix(strings.TrimRight(Money{Amount: int64(b), Currency: "USD"}.Decimal(), "0"), ".")
+}
+
+// Shipping priorities offered at checkout (also the warehouse task priority)
+const (
+	ShippingStandard  = "standard"
+	ShippingExpress   = "express"
+	ShippingOvernight = "overnight"
+)
+
+// PriceBreakdown is how an order total was computed:
+// Total = Subtotal - Discount + Shipping + Tax
+type PriceBreakdown struct {
+	Subtotal   Money       `json:"subtotal"`
+	Discount   Money       `json:"discount"`
+	Shipping   Money       `json:"shipping"`
+	Tax        Money       `json:"tax"`
+	TaxRate    BasisPoints `json:"tax_rate_bp"`
+	Total      Money       `json:"total"`
+	CouponCode string      `json:"coupon_code,omitempty"`
+}
+
+// CouponType is how a coupon discount is computed
+type CouponType string
+
+const (
+	CouponTypePercentage CouponType = "percentage" // Percent of the subtotal
+	CouponTypeFixed      CouponType = "fixed"      // Fixed amount off, in the coupon currency
+)
+
+// Coupon is a discount code
+type Coupon struct {
+	Code      string      `json:"code"`
+	Type      CouponType  `json:"type"`
+	Percent   BasisPoints `json:"percent_bp,omitempty"` // Percentage coupons
+	Amount    Money       `json:"amount"`               // Fixed coupons
+	MinSpend  Money       `json:"min_spend"`            // Subtotal required (zero = none)
+	MaxUses   int         `json:"max_uses"`             // 0 = unlimited
+	UsedCount int         `json:"used_count"`
+	Active    bool        `json:"active"`
+	ExpiresAt *time.Time  `json:"expires_at,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// Currency returns the currency of the coupon's amounts
+func (c *Coupon) Currency() string {
+	if c.Amount.Currency != "" {
+		return c.Amount.Currency
+	}
+	if c.MinSpend.Currency != "" {
+		return c.MinSpend.Currency
+	}
+	return DefaultCurrency
+}
+
+// IsExpired reports whether the coupon can no longer be used at t
+func (c *Coupon) IsExpired(t time.Time) bool {
+	return c.ExpiresAt != nil && !t.Before(*c.ExpiresAt)
+}
+
+// Discount returns the discount for a subtotal, never more than the subtotal
+func (c *Coupon) Discount(subtotal Money) Money {
+	var discount Money
+	switch c.Type {
+	case CouponTypePercentage:
+		discount = subtotal.Percent(c.Percent)
+	case CouponTypeFixed:
+		discount = c.Amount
+	}
+	return discount.Min(subtotal)
+}
@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ShipmentStatus is where a parcel is, as reported by its carrier
+type ShipmentStatus string
+
+const (
+	ShipmentStatusLabelCreated   ShipmentStatus = "label_created"
+	ShipmentStatusInTransit      ShipmentStatus = "in_transit"
+	ShipmentStatusOutForDelivery ShipmentStatus = "out_for_delivery"
+	ShipmentStatusDelivered      ShipmentStatus = "delivered"
+	ShipmentStatusException      ShipmentStatus = "exception" // Delayed, damaged, address problem; may resume
+)
+
+// shipmentProgress orders the statuses a parcel moves through
+var shipmentProgress = map[ShipmentStatus]int{
+	ShipmentStatusLabelCreated:   0,
+	ShipmentStatusInTransit:      1,
+	ShipmentStatusOutForDelivery: 2,
+	ShipmentStatusDelivered:      3,
+}
+
+// ErrInvalidShipmentStatus is returned for statuses a shipment cannot have
+var ErrInvalidShipmentStatus = errors.New("invalid shipment status")
+
+// IsValid reports whether s is a known shipment status
+func (s ShipmentStatus) IsValid() bool {
+	_, ok := shipmentProgress[s]
+	return ok || s == ShipmentStatusException
+}
+
+// Shipment is one parcel of an order, handed to a carrier
+type Shipment struct {
+	ID                string          `json:"id"`
+	OrderID           string          `json:"order_id"`
+	Parcel            int             `json:"parcel"` // 1-based number within the order
+	Carrier           string          `json:"carrier"`
+	ServiceLevel      string          `json:"service_level"` // standard, express, overnight
+	TrackingNumber    string          `json:"tracking_number"`
+	LabelURL          string          `json:"label_url"`
+	Status            ShipmentStatus  `json:"status"`
+	EstimatedDelivery time.Time       `json:"estimated_delivery"`
+	Items             []ShipmentItem  `json:"items"`
+	Events            []ShipmentEvent `json:"events"` // Status history, oldest first
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+// ShipmentItem is the quantity of an order line packed in a parcel
+type ShipmentItem struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+}
+
+// ShipmentEvent is one entry of the status history
+type ShipmentEvent struct {
+	Status      ShipmentStatus `json:"status"`
+	Location    string         `json:"location,omitempty"`
+	Description string         `json:"description,omitempty"`
+	OccurredAt  time.Time      `json:"occurred_at"`
+}
+
+// IsDelivered reports whether the parcel has arrived
+func (s *Shipment) IsDelivered() bool {
+	return s.Status == ShipmentStatusDelivered
+}
+
+// Advance records a carrier event and moves the shipment to its status.
+// Carriers retry and reorder their callbacks, so events for a stage the parcel
+// has already passed, and anything after delivery, are ignored and reported as false.
+// An exception can happen at any stage; the parcel then resumes where it was.
+func (s *Shipment) Advance(event ShipmentEvent) (bool, error) {
+	if !event.Status.IsValid() {
+		return false, ErrInvalidShipmentStatus
+	}
+	if s.IsDelivered() {
+		return false, nil
+	}
+	for _, e := range s.Events {
+		if e.Status == event.Status && e.OccurredAt.Equal(event.OccurredAt) {
+			return false, nil // Redelivered callback
+		}
+	}
+	if event.Status != ShipmentStatusException && shipmentProgress[event.Status] <= s.progress() {
+		return false, nil
+	}
+
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	s.Events = append(s.Events, event)
+	s.Status = event.Status
+	s.UpdatedAt = time.Now()
+	return true, nil
+}
+
+// progress returns how far the parcel got, looking past exceptions
+func (s *Shipment) progress() int {
+	for i := len(s.Events) - 1; i >= 0; i-- {
+		if p, ok := shipmentProgress[s.Events[i].Status]; ok {
+			return p
+		}
+	}
+	return shipmentProgress[ShipmentStatusLabelCreated]
+}
+
+// PackParcels splits the items of an order into parcels of at most maxUnits
+// units, in order. Lines larger than a parcel are spread over several; a
+// maxUnits below 1 puts everything in one parcel.
+func PackParcels(items []OrderItem, maxUnits int) [][]ShipmentItem {
+	if maxUnits < 1 {
+		maxUnits = 0
+		for _, item := range items {
+			maxUnits += item.Quantity
+		}
+	}
+	var parcels [][]ShipmentItem
+	var current []ShipmentItem
+	free := maxUnits
+	for _, item := range items {
+		left := item.Quantity
+		for left > 0 {
+			if free == 0 {
+				parcels = append(parcels, current)
+				current, free = nil, maxUnits
+			}
+			n := min(left, free)
+			current = append(current, ShipmentItem{ProductID: item.ProductID, ProductName: item.ProductName, Quantity: n})
+			left -= n
+			free -= n
+		}
+	}
+	if len(current) > 0 {
+		parcels = append(parcels, current)
+	}
+	return parcels
+}
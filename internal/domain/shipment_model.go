@@ -0,0 +1,139 @@
+package domain
+
+import "time"
+
+// ShipmentModel represents the shipments table. An order has one row per parcel;
+// tracking numbers are unique per carrier.
+type ShipmentModel struct {
+	ID                string               `gorm:"primaryKey;type:varchar(50)"`
+	OrderID           string               `gorm:"type:varchar(50);not null;uniqueIndex:idx_shipments_order_parcel,priority:1"`
+	Parcel            int                  `gorm:"not null;uniqueIndex:idx_shipments_order_parcel,priority:2"`
+	Carrier           string               `gorm:"type:varchar(50);not null;uniqueIndex:idx_shipments_carrier_tracking,priority:1"`
+	ServiceLevel      string               `gorm:"type:varchar(20);not null"`
+	TrackingNumber    string               `gorm:"type:varchar(100);not null;uniqueIndex:idx_shipments_carrier_tracking,priority:2"`
+	LabelURL          string               `gorm:"type:varchar(500);not null"`
+	Status            string               `gorm:"type:varchar(50);not null;index"`
+	EstimatedDelivery time.Time            `gorm:"not null"`
+	Items             []ShipmentItemModel  `gorm:"foreignKey:ShipmentID;constraint:OnDelete:CASCADE"`
+	Events            []ShipmentEventModel `gorm:"foreignKey:ShipmentID;constraint:OnDelete:CASCADE"`
+	CreatedAt         time.Time            `gorm:"not null"`
+	UpdatedAt         time.Time            `gorm:"not null"`
+}
+
+// TableName overrides the table name
+func (ShipmentModel) TableName() string {
+	return "shipments"
+}
+
+// ShipmentItemModel represents the shipment_items table: the units packed in a parcel
+type ShipmentItemModel struct {
+	ShipmentID  string `gorm:"primaryKey;type:varchar(50)"`
+	LineNo      int    `gorm:"primaryKey;autoIncrement:false"` // Position in the parcel, from 0
+	ProductID   string `gorm:"type:varchar(50);not null"`
+	ProductName string `gorm:"type:varchar(255);not null"`
+	Quantity    int    `gorm:"not null;check:chk_shipment_items_quantity,quantity > 0"`
+}
+
+// TableName overrides the table name
+func (ShipmentItemModel) TableName() string {
+	return "shipment_items"
+}
+
+// ShipmentEventModel represents the shipment_events table (status history)
+type ShipmentEventModel struct {
+	ShipmentID  string    `gorm:"primaryKey;type:varchar(50)"`
+	Seq         int       `gorm:"primaryKey;autoIncrement:false"` // Position in the history, from 0
+	Status      string    `gorm:"type:varchar(50);not null"`
+	Location    string    `gorm:"type:varchar(255);not null"`
+	Description string    `gorm:"type:varchar(500);not null"`
+	OccurredAt  time.Time `gorm:"not null"`
+}
+
+// TableName overrides the table name
+func (ShipmentEventModel) TableName() string {
+	return "shipment_events"
+}
+
+// ToShipment converts ShipmentModel to domain.Shipment; Items and Events must be loaded
+func (m *ShipmentModel) ToShipment() *Shipment {
+	items := make([]ShipmentItem, len(m.Items))
+	for i, item := range m.Items {
+		items[i] = ShipmentItem{
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+		}
+	}
+
+	events := make([]ShipmentEvent, len(m.Events))
+	for i, e := range m.Events {
+		events[i] = ShipmentEvent{
+			Status:      ShipmentStatus(e.Status),
+			Location:    e.Location,
+			Description: e.Description,
+			OccurredAt:  e.OccurredAt,
+		}
+	}
+
+	return &Shipment{
+		ID:                m.ID,
+		OrderID:           m.OrderID,
+		Parcel:            m.Parcel,
+		Carrier:           m.Carrier,
+		ServiceLevel:      m.ServiceLevel,
+		TrackingNumber:    m.TrackingNumber,
+		LabelURL:          m.LabelURL,
+		Status:            ShipmentStatus(m.Status),
+		EstimatedDelivery: m.EstimatedDelivery,
+		Items:             items,
+		Events:            events,
+		CreatedAt:         m.CreatedAt,
+		UpdatedAt:         m.UpdatedAt,
+	}
+}
+
+// FromShipment converts domain.Shipment to ShipmentModel with its items and events
+func FromShipment(s *Shipment) *ShipmentModel {
+	items := make([]ShipmentItemModel, len(s.Items))
+	for i, item := range s.Items {
+		items[i] = ShipmentItemModel{
+			ShipmentID:  s.ID,
+			LineNo:      i,
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+		}
+	}
+
+	return &ShipmentModel{
+		ID:                s.ID,
+		OrderID:           s.OrderID,
+		Parcel:            s.Parcel,
+		Carrier:           s.Carrier,
+		ServiceLevel:      s.ServiceLevel,
+		TrackingNumber:    s.TrackingNumber,
+		LabelURL:          s.LabelURL,
+		Status:            string(s.Status),
+		EstimatedDelivery: s.EstimatedDelivery,
+		Items:             items,
+		Events:            FromShipmentEvents(s.ID, s.Events),
+		CreatedAt:         s.CreatedAt,
+		UpdatedAt:         s.UpdatedAt,
+	}
+}
+
+// FromShipmentEvents converts a status history to ShipmentEventModels numbered from 0
+func FromShipmentEvents(shipmentID string, events []ShipmentEvent) []ShipmentEventModel {
+	models := make([]ShipmentEventModel, len(events))
+	for i, e := range events {
+		models[i] = ShipmentEventModel{
+			ShipmentID:  shipmentID,
+			Seq:         i,
+			Status:      string(e.Status),
+			Location:    e.Location,
+			Description: e.Description,
+			OccurredAt:  e.OccurredAt,
+		}
+	}
+	return models
+}
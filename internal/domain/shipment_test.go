@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPackParcels(t *testing.T) {
+	a := OrderItem{ProductID: "prod-a", ProductName: "A", Quantity: 3}
+	b := OrderItem{ProductID: "prod-b", ProductName: "B", Quantity: 25}
+	c := OrderItem{ProductID: "prod-c", ProductName: "C", Quantity: 1}
+	packed := func(item OrderItem, n int) ShipmentItem {
+		return ShipmentItem{ProductID: item.ProductID, ProductName: item.ProductName, Quantity: n}
+	}
+
+	tests := []struct {
+		name     string
+		items    []OrderItem
+		maxUnits int
+		want     [][]ShipmentItem
+	}{
+		{"fits one parcel", []OrderItem{a, c}, 10,
+			[][]ShipmentItem{{packed(a, 3), packed(c, 1)}}},
+		{"exactly full", []OrderItem{a}, 3,
+			[][]ShipmentItem{{packed(a, 3)}}},
+		{"line larger than a parcel", []OrderItem{b}, 10,
+			[][]ShipmentItem{{packed(b, 10)}, {packed(b, 10)}, {packed(b, 5)}}},
+		{"lines share parcels in order", []OrderItem{a, b, c}, 10,
+			[][]ShipmentItem{{packed(a, 3), packed(b, 7)}, {packed(b, 10)}, {packed(b, 8), packed(c, 1)}}},
+		{"one unit per parcel", []OrderItem{a}, 1,
+			[][]ShipmentItem{{packed(a, 1)}, {packed(a, 1)}, {packed(a, 1)}}},
+		{"zero max units puts everything in one parcel", []OrderItem{a, b}, 0,
+			[][]ShipmentItem{{packed(a, 3), packed(b, 25)}}},
+		{"negative max units puts everything in one parcel", []OrderItem{b, c}, -5,
+			[][]ShipmentItem{{packed(b, 25), packed(c, 1)}}},
+		{"no items", nil, 10, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PackParcels(tt.items, tt.maxUnits); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PackParcels = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShipmentAdvance(t *testing.T) {
+	t0 := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	at := func(status ShipmentStatus, hours int) ShipmentEvent {
+		return ShipmentEvent{Status: status, OccurredAt: t0.Add(time.Duration(hours) * time.Hour)}
+	}
+
+	type step struct {
+		event   ShipmentEvent
+		applied bool
+		status  ShipmentStatus // After the event
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"in order", []step{
+			{at(ShipmentStatusInTransit, 1), true, ShipmentStatusInTransit},
+			{at(ShipmentStatusOutForDelivery, 2), true, ShipmentStatusOutForDelivery},
+			{at(ShipmentStatusDelivered, 3), true, ShipmentStatusDelivered},
+		}},
+		{"skipped stage", []step{
+			{at(ShipmentStatusDelivered, 3), true, ShipmentStatusDelivered},
+		}},
+		{"out of order events are ignored", []step{
+			{at(ShipmentStatusOutForDelivery, 2), true, ShipmentStatusOutForDelivery},
+			{at(ShipmentStatusInTransit, 1), false, ShipmentStatusOutForDelivery},
+			{at(ShipmentStatusLabelCreated, 0), false, ShipmentStatusOutForDelivery},
+			{at(ShipmentStatusDelivered, 3), true, ShipmentStatusDelivered},
+		}},
+		{"repeated delivered event", []step{
+			{at(ShipmentStatusDelivered, 3), true, ShipmentStatusDelivered},
+			{at(ShipmentStatusDelivered, 3), false, ShipmentStatusDelivered},
+			{at(ShipmentStatusDelivered, 4), false, ShipmentStatusDelivered},
+		}},
+		{"nothing after delivery", []step{
+			{at(ShipmentStatusDelivered, 3), true, ShipmentStatusDelivered},
+			{at(ShipmentStatusException, 4), false, ShipmentStatusDelivered},
+			{at(ShipmentStatusOutForDelivery, 5), false, ShipmentStatusDelivered},
+		}},
+		{"redelivered callback", []step{
+			{at(ShipmentStatusInTransit, 1), true, ShipmentStatusInTransit},
+			{at(ShipmentStatusInTransit, 1), false, ShipmentStatusInTransit},
+		}},
+		{"exception resumes where the parcel was", []step{
+			{at(ShipmentStatusOutForDelivery, 2), true, ShipmentStatusOutForDelivery},
+			{at(ShipmentStatusException, 3), true, ShipmentStatusException},
+			{at(ShipmentStatusInTransit, 4), false, ShipmentStatusException},
+			{at(ShipmentStatusDelivered, 5), true, ShipmentStatusDelivered},
+		}},
+		{"repeated exceptions", []step{
+			{at(ShipmentStatusException, 1), true, ShipmentStatusException},
+			{at(ShipmentStatusException, 2), true, ShipmentStatusException},
+			{at(ShipmentStatusException, 2), false, ShipmentStatusException},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Shipment{Status: ShipmentStatusLabelCreated, Events: []ShipmentEvent{at(ShipmentStatusLabelCreated, 0)}}
+			recorded := len(s.Events)
+			for i, step := range tt.steps {
+				applied, err := s.Advance(step.event)
+				if err != nil {
+					t.Fatalf("step %d: %v", i, err)
+				}
+				if applied {
+					recorded++
+				}
+				if applied != step.applied || s.Status != step.status || len(s.Events) != recorded {
+					t.Fatalf("step %d (%s): applied %t, status %s, %d events; want %t, %s, %d",
+						i, step.event.Status, applied, s.Status, len(s.Events), step.applied, step.status, recorded)
+				}
+			}
+		})
+	}
+
+	t.Run("unknown status", func(t *testing.T) {
+		s := &Shipment{Status: ShipmentStatusInTransit}
+		if _, err := s.Advance(ShipmentEvent{Status: "lost_in_space"}); !errors.Is(err, ErrInvalidShipmentStatus) {
+			t.Errorf("Advance = %v, want ErrInvalidShipmentStatus", err)
+		}
+		if s.Status != ShipmentStatusInTransit || len(s.Events) != 0 {
+			t.Errorf("rejected event changed the shipment: %s, %d events", s.Status, len(s.Events))
+		}
+	})
+
+	t.Run("missing time is filled in", func(t *testing.T) {
+		s := &Shipment{Status: ShipmentStatusLabelCreated}
+		if applied, err := s.Advance(ShipmentEvent{Status: ShipmentStatusInTransit}); err != nil || !applied {
+			t.Fatalf("Advance = %t, %v", applied, err)
+		}
+		if s.Events[0].OccurredAt.IsZero() {
+			t.Error("event recorded without a time")
+		}
+	})
+}
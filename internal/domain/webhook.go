@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Webhook event types
+const (
+	WebhookEventOrderCreated = "order.created"
+	WebhookEventTest         = "webhook.test"
+	WebhookEventAll          = "*" // Subscribe to every event
+)
+
+// OrderStatusEventType returns the event type announcing a transition to status
+func OrderStatusEventType(status OrderStatus) string {
+	return "order." + string(status)
+}
+
+// WebhookSubscription is an endpoint that receives signed order events
+type WebhookSubscription struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"-"`
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Matches reports whether the subscription wants events of eventType
+func (s *WebhookSubscription) Matches(eventType string) bool {
+	if !s.Active {
+		return false
+	}
+	for _, t := range s.EventTypes {
+		if t == WebhookEventAll || t == eventType {
+			return true
+		}
+		// "order.*" matches every order event
+		if prefix, ok := strings.CutSuffix(t, "*"); ok && strings.HasPrefix(eventType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEvent is the JSON body POSTed to subscribers
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// OrderEventData is the data of order.* events
+type OrderEventData struct {
+	OrderID        string `json:"order_id"`
+	CustomerID     string `json:"customer_id"`
+	Status         string `json:"status"`
+	PreviousStatus string `json:"previous_status,omitempty"`
+	PaymentStatus  string `json:"payment_status"`
+	TotalAmount    Money  `json:"total_amount"`
+	InvoiceURL     string `json:"invoice_url,omitempty"`
+	TrackingNumber string `json:"tracking_number,omitempty"`
+}
+
+// WebhookDelivery is one delivery attempt of an event to a subscription
+type WebhookDelivery struct {
+	ID             uint      `json:"id"`
+	SubscriptionID string    `json:"subscription_id"`
+	EventID        string    `json:"event_id"`
+	EventType      string    `json:"event_type"`
+	Attempt        int       `json:"attempt"`
+	StatusCode     int       `json:"status_code,omitempty"`
+	Success        bool      `json:"success"`
+	Error          string    `json:"error,omitempty"`
+	DurationMs     int64     `json:"duration_ms"`
+	CreatedAt      time.Time `json:"created_at"`
+}
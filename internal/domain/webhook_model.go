@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookSubscriptionModel represents the webhook_subscriptions table (GORM model)
+type WebhookSubscriptionModel struct {
+	ID         string         `gorm:"primaryKey;type:varchar(50)"`
+	URL        string         `gorm:"type:varchar(2000);not null"`
+	Secret     string         `gorm:"type:varchar(255);not null"`
+	EventTypes string         `gorm:"type:text;not null"` // Comma-separated
+	Active     bool           `gorm:"not null;default:true"`
+	CreatedAt  time.Time      `gorm:"not null"`
+	UpdatedAt  time.Time      `gorm:"not null"`
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+}
+
+// TableName overrides the table name
+func (WebhookSubscriptionModel) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// ToSubscription converts WebhookSubscriptionModel to domain.WebhookSubscription
+func (m *WebhookSubscriptionModel) ToSubscription() *WebhookSubscription {
+	return &WebhookSubscription{
+		ID:         m.ID,
+		URL:        m.URL,
+		Secret:     m.Secret,
+		EventTypes: strings.Split(m.EventTypes, ","),
+		Active:     m.Active,
+		CreatedAt:  m.CreatedAt,
+		UpdatedAt:  m.UpdatedAt,
+	}
+}
+
+// FromSubscription converts domain.WebhookSubscription to WebhookSubscriptionModel
+func FromSubscription(s *WebhookSubscription) *WebhookSubscriptionModel {
+	return &WebhookSubscriptionModel{
+		ID:         s.ID,
+		URL:        s.URL,
+		Secret:     s.Secret,
+		EventTypes: strings.Join(s.EventTypes, ","),
+		Active:     s.Active,
+		CreatedAt:  s.CreatedAt,
+		UpdatedAt:  s.UpdatedAt,
+	}
+}
+
+// WebhookDeliveryModel represents the webhook_deliveries table (delivery log)
+type WebhookDeliveryModel struct {
+	ID             uint      `gorm:"primaryKey;autoIncrement"`
+	SubscriptionID string    `gorm:"type:varchar(50);not null;index:idx_webhook_deliveries_sub_created,priority:1"`
+	EventID        string    `gorm:"type:varchar(50);not null;index"`
+	EventType      string    `gorm:"type:varchar(100);not null"`
+	Attempt        int       `gorm:"not null"`
+	StatusCode     int       `gorm:"not null;default:0"`
+	Success        bool      `gorm:"not null"`
+	Error          string    `gorm:"type:text"`
+	DurationMs     int64     `gorm:"not null"`
+	CreatedAt      time.Time `gorm:"not null;index:idx_webhook_deliveries_sub_created,priority:2"`
+}
+
+// TableName overrides the table name
+func (WebhookDeliveryModel) TableName() string {
+	return "webhook_deliveries"
+}
+
+// ToDelivery converts WebhookDeliveryModel to domain.WebhookDelivery
+func (m *WebhookDeliveryModel) ToDelivery() *WebhookDelivery {
+	return &WebhookDelivery{
+		ID:             m.ID,
+		SubscriptionID: m.SubscriptionID,
+		EventID:        m.EventID,
+		EventType:      m.EventType,
+		Attempt:        m.Attempt,
+		StatusCode:     m.StatusCode,
+		Success:        m.Success,
+		Error:          m.Error,
+		DurationMs:     m.DurationMs,
+		CreatedAt:      m.CreatedAt,
+	}
+}
+
+// FromDelivery converts domain.WebhookDelivery to WebhookDeliveryModel
+func FromDelivery(d *WebhookDelivery) *WebhookDeliveryModel {
+	return &WebhookDeliveryModel{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Attempt:        d.Attempt,
+		StatusCode:     d.StatusCode,
+		Success:        d.Success,
+		Error:          d.Error,
+		DurationMs:     d.DurationMs,
+		CreatedAt:      d.CreatedAt,
+	}
+}
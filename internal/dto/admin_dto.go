@@ -0,0 +1,22 @@
+package dto
+
+// QueueInfoResponse represents the state of one Asynq queue
+type QueueInfoResponse struct {
+	Queue       string  `json:"queue"`
+	Paused      bool    `json:"paused"`
+	Pending     int     `json:"pending"`
+	Active      int     `json:"active"`
+	Scheduled   int     `json:"scheduled"`
+	Retry       int     `json:"retry"`
+	Archived    int     `json:"archived"`
+	Completed   int     `json:"completed"`
+	Aggregating int     `json:"aggregating"`
+	LatencySec  float64 `json:"latency_seconds"`
+	Processed   int     `json:"processed_today"`
+	Failed      int     `json:"failed_today"`
+}
+
+// QueueListResponse represents the response for the admin queue overview
+type QueueListResponse struct {
+	Queues []QueueInfoResponse `json:"queues"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+import "github.com/lppduy/go-asynq-loadtest/internal/domain"
+
+// AnalyticsRollupResponse represents the orders of one time bucket, or of the whole range
+type AnalyticsRollupResponse struct {
+	Start          string           `json:"start,omitempty"`
+	Orders         int64            `json:"orders"`
+	Revenue        []domain.Money   `json:"revenue"` // One amount per currency
+	AverageItems   float64          `json:"average_items"`
+	PaymentMethods map[string]int64 `json:"payment_methods"` // Orders per payment method
+}
+
+// AnalyticsSummaryResponse represents the response of GET /api/v1/analytics/summary
+type AnalyticsSummaryResponse struct {
+	From        string                    `json:"from"`
+	To          string                    `json:"to"`
+	Granularity string                    `json:"granularity"`
+	Total       AnalyticsRollupResponse   `json:"total"`
+	Rollups     []AnalyticsRollupResponse `json:"rollups"`
+}
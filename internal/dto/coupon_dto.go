@@ -0,0 +1,45 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+)
+
+// CreateCouponRequest represents the request to create a discount code
+type CreateCouponRequest struct {
+	Code      string      `json:"code" binding:"required,max=50,alphanum"`
+	Type      string      `json:"type" binding:"required,oneof=percentage fixed"`
+	Percent   json.Number `json:"percent"`                              // Percentage coupons, e.g. 15 or 7.5
+	Amount    json.Number `json:"amount"`                               // Fixed coupons, decimal in Currency
+	MinSpend  json.Number `json:"min_spend"`                            // Subtotal required, decimal in Currency
+	Currency  string      `json:"currency" binding:"omitempty,iso4217"` // Defaults to USD
+	MaxUses   int         `json:"max_uses" binding:"min=0"`             // 0 = unlimited
+	ExpiresAt *time.Time  `json:"expires_at"`
+}
+
+// UpdateCouponRequest represents the request to enable or disable a coupon
+type UpdateCouponRequest struct {
+	Active *bool `json:"active" binding:"required"`
+}
+
+// CouponResponse represents a coupon and its usage
+type CouponResponse struct {
+	Code      string        `json:"code"`
+	Type      string        `json:"type"`
+	Percent   string        `json:"percent,omitempty"`
+	Amount    *domain.Money `json:"amount,omitempty"`
+	MinSpend  domain.Money  `json:"min_spend"`
+	MaxUses   int           `json:"max_uses"`
+	UsedCount int           `json:"used_count"`
+	Active    bool          `json:"active"`
+	ExpiresAt string        `json:"expires_at,omitempty"`
+	CreatedAt string        `json:"created_at"`
+}
+
+// CouponListResponse represents the response for list of coupons
+type CouponListResponse struct {
+	Total   int              `json:"total"`
+	Coupons []CouponResponse `json:"coupons"`
+}
@@ -0,0 +1,67 @@
+package dto
+
+import (
+	"encoding/json"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+)
+
+// CreateProductRequest represents the request to add a product to the catalog
+type CreateProductRequest struct {
+	ID           string      `json:"id" binding:"required,max=50"`
+	Name         string      `json:"name" binding:"required"`
+	Price        json.Number `json:"price" binding:"required"`             // Decimal in major units, e.g. 19.99
+	Currency     string      `json:"currency" binding:"omitempty,iso4217"` // Defaults to USD
+	InitialStock int         `json:"initial_stock" binding:"min=0"`
+}
+
+// UpdateProductRequest represents the request to update a product.
+// Omitted fields keep their current value.
+type UpdateProductRequest struct {
+	Name   *string      `json:"name" binding:"omitempty,min=1"`
+	Price  *json.Number `json:"price"` // In the product's currency
+	Active *bool        `json:"active"`
+}
+
+// ProductResponse represents a product with its stock level
+type ProductResponse struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Price     domain.Money   `json:"price"`
+	Active    bool           `json:"active"`
+	Stock     *StockResponse `json:"stock,omitempty"`
+	CreatedAt string         `json:"created_at"`
+	UpdatedAt string         `json:"updated_at"`
+}
+
+// ProductListResponse represents the response for list of products
+type ProductListResponse struct {
+	Total    int               `json:"total"`
+	Products []ProductResponse `json:"products"`
+}
+
+// StockResponse represents the stock level of a product
+type StockResponse struct {
+	ProductID string `json:"product_id"`
+	OnHand    int    `json:"on_hand"`
+	Reserved  int    `json:"reserved"`
+	Available int    `json:"available"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// StockListResponse represents the response for list of stock levels
+type StockListResponse struct {
+	Total int             `json:"total"`
+	Stock []StockResponse `json:"stock"`
+}
+
+// SetStockRequest represents the request to set on-hand units (e.g. after a stock count)
+type SetStockRequest struct {
+	OnHand *int `json:"on_hand" binding:"required,min=0"`
+}
+
+// AdjustStockRequest represents the request to add or remove on-hand units
+type AdjustStockRequest struct {
+	Delta  int    `json:"delta" binding:"required"`
+	Reason string `json:"reason"`
+}
@@ -1,50 +1,90 @@
 package dto
 
-import "github.com/lppduy/go-asynq-loadtest/internal/domain"
+import (
+	"encoding/json"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+)
 
 // CreateOrderRequest represents the request to create an order
 type CreateOrderRequest struct {
-	CustomerID      string                `json:"customer_id" binding:"required"`
-	CustomerEmail   string                `json:"customer_email" binding:"required,email"`
-	Items           []CreateOrderItemRequest `json:"items" binding:"required,min=1,dive"`
-	ShippingAddress domain.Address        `json:"shipping_address" binding:"required"`
-	PaymentMethod   string                `json:"payment_method" binding:"required,oneof=credit_card debit_card bank_transfer"`
-	Notes           string                `json:"notes"`
+	CustomerID       string                   `json:"customer_id" binding:"required"`
+	CustomerEmail    string                   `json:"customer_email" binding:"required,email"`
+	CustomerName     string                   `json:"customer_name" binding:"omitempty,max=255"` // Used in emails and invoices
+	Items            []CreateOrderItemRequest `json:"items" binding:"required,min=1,dive"`
+	ShippingAddress  domain.Address           `json:"shipping_address" binding:"required"`
+	PaymentMethod    string                   `json:"payment_method" binding:"required,oneof=credit_card debit_card bank_transfer"`
+	Currency         string                   `json:"currency" binding:"omitempty,iso4217"` // Defaults to USD
+	CouponCode       string                   `json:"coupon_code" binding:"omitempty,max=50"`
+	ShippingPriority string                   `json:"shipping_priority" binding:"omitempty,oneof=standard express overnight"` // Defaults to standard
+	Notes            string                   `json:"notes"`
 }
 
-// CreateOrderItemRequest represents an item in the order creation request
+// CreateOrderItemRequest represents an item in the order creation request.
+// Name and price are taken from the product catalog; a unit_price that is
+// sent (decimal in the order currency) must match the catalog price.
 type CreateOrderItemRequest struct {
-	ProductID   string  `json:"product_id" binding:"required"`
-	ProductName string  `json:"product_name" binding:"required"`
-	Quantity    int     `json:"quantity" binding:"required,min=1"`
-	UnitPrice   float64 `json:"unit_price" binding:"required,gt=0"`
+	ProductID   string      `json:"product_id" binding:"required"`
+	ProductName string      `json:"product_name"`
+	Quantity    int         `json:"quantity" binding:"required,min=1"`
+	UnitPrice   json.Number `json:"unit_price"`
+}
+
+// CreateOrderBatchRequest creates several orders in one call. Each order is
+// validated on its own, so one bad order does not hide the others.
+type CreateOrderBatchRequest struct {
+	Mode   string               `json:"mode" binding:"omitempty,oneof=atomic partial"` // Defaults to atomic
+	Orders []CreateOrderRequest `json:"orders" binding:"required,min=1"`
+}
+
+// UpdateOrderRequest represents an edit of a pending order. Omitted fields are
+// kept; items replace the whole item list. Totals are recalculated.
+type UpdateOrderRequest struct {
+	Items           []CreateOrderItemRequest `json:"items" binding:"omitempty,min=1,dive"`
+	ShippingAddress *domain.Address          `json:"shipping_address"`
+	Notes           *string                  `json:"notes"`
 }
 
 // OrderResponse represents the response for an order
 type OrderResponse struct {
-	ID              string                `json:"id"`
-	CustomerID      string                `json:"customer_id"`
-	CustomerEmail   string                `json:"customer_email"`
-	Items           []OrderItemResponse   `json:"items"`
-	TotalAmount     float64               `json:"total_amount"`
-	ShippingAddress domain.Address        `json:"shipping_address"`
-	Status          string                `json:"status"`
-	PaymentStatus   string                `json:"payment_status"`
-	PaymentMethod   string                `json:"payment_method"`
-	InvoiceURL      string                `json:"invoice_url,omitempty"`
-	TrackingNumber  string                `json:"tracking_number,omitempty"`
-	Notes           string                `json:"notes,omitempty"`
-	CreatedAt       string                `json:"created_at"`
-	UpdatedAt       string                `json:"updated_at"`
+	ID               string                 `json:"id"`
+	CustomerID       string                 `json:"customer_id"`
+	CustomerEmail    string                 `json:"customer_email"`
+	CustomerName     string                 `json:"customer_name,omitempty"`
+	Items            []OrderItemResponse    `json:"items"`
+	TotalAmount      domain.Money           `json:"total_amount"`
+	Pricing          PriceBreakdownResponse `json:"pricing"`
+	ShippingAddress  domain.Address         `json:"shipping_address"`
+	Status           string                 `json:"status"`
+	PaymentStatus    string                 `json:"payment_status"`
+	PaymentMethod    string                 `json:"payment_method"`
+	ShippingPriority string                 `json:"shipping_priority"`
+	InvoiceURL       string                 `json:"invoice_url,omitempty"`
+	TrackingNumber   string                 `json:"tracking_number,omitempty"`
+	Notes            string                 `json:"notes,omitempty"`
+	CreatedAt        string                 `json:"created_at"`
+	UpdatedAt        string                 `json:"updated_at"`
+}
+
+// PriceBreakdownResponse shows how the order total was computed:
+// total = subtotal - discount + shipping + tax
+type PriceBreakdownResponse struct {
+	Subtotal   domain.Money `json:"subtotal"`
+	Discount   domain.Money `json:"discount"`
+	CouponCode string       `json:"coupon_code,omitempty"`
+	Shipping   domain.Money `json:"shipping"`
+	Tax        domain.Money `json:"tax"`
+	TaxRate    string       `json:"tax_rate"` // Percent, e.g. "7.25"
+	Total      domain.Money `json:"total"`
 }
 
 // OrderItemResponse represents an item in the order response
 type OrderItemResponse struct {
-	ProductID   string  `json:"product_id"`
-	ProductName string  `json:"product_name"`
-	Quantity    int     `json:"quantity"`
-	UnitPrice   float64 `json:"unit_price"`
-	Subtotal    float64 `json:"subtotal"`
+	ProductID   string       `json:"product_id"`
+	ProductName string       `json:"product_name"`
+	Quantity    int          `json:"quantity"`
+	UnitPrice   domain.Money `json:"unit_price"`
+	Subtotal    domain.Money `json:"subtotal"`
 }
 
 // OrderListResponse represents the response for list of orders
@@ -60,10 +100,12 @@ type CancelOrderRequest struct {
 
 // OrderStatusResponse represents the response for order status
 type OrderStatusResponse struct {
-	OrderID       string `json:"order_id"`
-	Status        string `json:"status"`
-	PaymentStatus string `json:"payment_status"`
-	UpdatedAt     string `json:"updated_at"`
+	OrderID        string `json:"order_id"`
+	Status         string `json:"status"`
+	PaymentStatus  string `json:"payment_status"`
+	InvoiceURL     string `json:"invoice_url,omitempty"`
+	TrackingNumber string `json:"tracking_number,omitempty"`
+	UpdatedAt      string `json:"updated_at"`
 }
 
 // ErrorResponse represents an error response
@@ -73,6 +115,49 @@ type ErrorResponse struct {
 	Code    string `json:"code,omitempty"`
 }
 
+// OrderItemError represents why one item of an order was refused
+type OrderItemError struct {
+	Index     int    `json:"index"`
+	ProductID string `json:"product_id"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+}
+
+// OrderValidationErrorResponse represents a 422 response with per-item errors
+type OrderValidationErrorResponse struct {
+	Error   string           `json:"error"`
+	Message string           `json:"message,omitempty"`
+	Code    string           `json:"code"`
+	Items   []OrderItemError `json:"items"`
+}
+
+// OrderBatchResponse reports the outcome of every order of a batch, in request order
+type OrderBatchResponse struct {
+	Mode    string             `json:"mode"`
+	Created int                `json:"created"`
+	Failed  int                `json:"failed"`
+	Results []OrderBatchResult `json:"results"`
+}
+
+// OrderBatchResult is the outcome of one order of a batch
+type OrderBatchResult struct {
+	Index  int              `json:"index"`
+	Status string           `json:"status"` // created, failed or not_created (atomic batch rolled back)
+	Order  *OrderResponse   `json:"order,omitempty"`
+	Error  *OrderBatchError `json:"error,omitempty"`
+	// Set on a created order when some of its background tasks could not be
+	// enqueued; the order exists, reconciliation re-enqueues a missing payment
+	TasksError string `json:"tasks_error,omitempty"`
+}
+
+// OrderBatchError explains why one order of a batch was not created
+type OrderBatchError struct {
+	Error   string           `json:"error"`
+	Message string           `json:"message,omitempty"`
+	Code    string           `json:"code,omitempty"`
+	Items   []OrderItemError `json:"items,omitempty"`
+}
+
 // SuccessResponse represents a generic success response
 type SuccessResponse struct {
 	Message string      `json:"message"`
@@ -0,0 +1,41 @@
+package dto
+
+import "github.com/lppduy/go-asynq-loadtest/internal/domain"
+
+// ShipmentResponse represents one parcel of an order
+type ShipmentResponse struct {
+	ID                string                  `json:"id"`
+	Parcel            int                     `json:"parcel"`
+	Carrier           string                  `json:"carrier"`
+	ServiceLevel      string                  `json:"service_level"`
+	TrackingNumber    string                  `json:"tracking_number"`
+	LabelURL          string                  `json:"label_url"`
+	Status            string                  `json:"status"`
+	EstimatedDelivery string                  `json:"estimated_delivery"`
+	Items             []domain.ShipmentItem   `json:"items"`
+	Events            []ShipmentEventResponse `json:"events"` // Oldest first
+	CreatedAt         string                  `json:"created_at"`
+	UpdatedAt         string                  `json:"updated_at"`
+}
+
+// ShipmentEventResponse represents one entry of a shipment's status history
+type ShipmentEventResponse struct {
+	Status      string `json:"status"`
+	Location    string `json:"location,omitempty"`
+	Description string `json:"description,omitempty"`
+	OccurredAt  string `json:"occurred_at"`
+}
+
+// ShipmentListResponse represents the shipments of an order
+type ShipmentListResponse struct {
+	OrderID   string             `json:"order_id"`
+	Total     int                `json:"total"`
+	Shipments []ShipmentResponse `json:"shipments"`
+}
+
+// CarrierWebhookResponse reports what a carrier callback changed
+type CarrierWebhookResponse struct {
+	Received int `json:"received"` // Tracking events in the callback
+	Applied  int `json:"applied"`  // Events that moved a shipment
+	Unknown  int `json:"unknown"`  // Events for tracking numbers we did not ship
+}
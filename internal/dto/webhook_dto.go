@@ -0,0 +1,48 @@
+package dto
+
+// CreateWebhookRequest represents the request to create a webhook subscription
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" binding:"required,url"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,dive,required"`
+	Secret     string   `json:"secret" binding:"omitempty,min=16"` // Generated when empty
+}
+
+// WebhookResponse represents a webhook subscription
+type WebhookResponse struct {
+	ID         string   `json:"id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Active     bool     `json:"active"`
+	Secret     string   `json:"secret,omitempty"` // Only returned on creation
+	CreatedAt  string   `json:"created_at"`
+}
+
+// WebhookListResponse represents the response for list of webhook subscriptions
+type WebhookListResponse struct {
+	Total    int               `json:"total"`
+	Webhooks []WebhookResponse `json:"webhooks"`
+}
+
+// WebhookDeliveryResponse represents one delivery attempt
+type WebhookDeliveryResponse struct {
+	EventID    string `json:"event_id"`
+	EventType  string `json:"event_type"`
+	Attempt    int    `json:"attempt"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// WebhookDeliveryListResponse represents the delivery log of a subscription
+type WebhookDeliveryListResponse struct {
+	Total      int                       `json:"total"`
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
+}
+
+// WebhookTestResponse represents the response for a test delivery
+type WebhookTestResponse struct {
+	EventID   string `json:"event_id"`
+	EventType string `json:"event_type"`
+}
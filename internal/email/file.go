@@ -0,0 +1,60 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// FileMailer writes every message into a maildir, for local runs without an
+// SMTP server. Any mail client that reads maildirs (mutt -f mail/) shows them.
+type FileMailer struct {
+	dir      string
+	from     mail.Address
+	hostname string
+	seq      atomic.Int64
+}
+
+// NewFileMailer creates the tmp, new and cur folders of the maildir at dir
+func NewFileMailer(dir string, from mail.Address) (Mailer, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create maildir %s: %w", dir, err)
+		}
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+	return &FileMailer{dir: dir, from: from, hostname: hostname}, nil
+}
+
+// Send writes the message to tmp/ and moves it into new/, so readers never see
+// a partial file
+func (m *FileMailer) Send(ctx context.Context, msg *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	setFrom(msg, m.from)
+
+	body, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	// Unique name per the maildir convention: time.pid_seq.host
+	name := fmt.Sprintf("%d.%d_%d.%s", time.Now().Unix(), os.Getpid(), m.seq.Add(1), m.hostname)
+	tmp := filepath.Join(m.dir, "tmp", name)
+	if err := os.WriteFile(tmp, body, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, filepath.Join(m.dir, "new", name)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to deliver %s: %w", name, err)
+	}
+	return nil
+}
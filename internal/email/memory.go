@@ -0,0 +1,50 @@
+package email
+
+import (
+	"context"
+	"net/mail"
+	"sync"
+)
+
+// MemoryMailer keeps sent messages in memory, for tests and checks that assert
+// on what was sent
+type MemoryMailer struct {
+	mu   sync.Mutex
+	from mail.Address
+	sent []Message
+}
+
+// NewMemoryMailer creates an empty in-memory mailer
+func NewMemoryMailer(from mail.Address) *MemoryMailer {
+	return &MemoryMailer{from: from}
+}
+
+// Send records a copy of the message after checking that it encodes
+func (m *MemoryMailer) Send(ctx context.Context, msg *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	setFrom(msg, m.from)
+	if _, err := msg.Bytes(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, *msg)
+	return nil
+}
+
+// Sent returns the messages sent so far, oldest first
+func (m *MemoryMailer) Sent() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.sent...)
+}
+
+// Reset forgets the sent messages
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = nil
+}
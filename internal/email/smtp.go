@@ -0,0 +1,50 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+)
+
+// SMTPMailer sends through an SMTP relay, with STARTTLS when the server offers it
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth // nil when no username is configured
+	from mail.Address
+}
+
+// NewSMTPMailer creates a mailer for host:port. PLAIN auth is used when username is set.
+func NewSMTPMailer(host, port, username, password string, from mail.Address) Mailer {
+	m := &SMTPMailer{addr: net.JoinHostPort(host, port), from: from}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+// Send delivers the message. net/smtp has no context support, so ctx is only
+// checked before dialing; the task timeout bounds the rest.
+func (m *SMTPMailer) Send(ctx context.Context, msg *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	setFrom(msg, m.from)
+
+	body, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	if err := smtp.SendMail(m.addr, m.auth, msg.From.Address, []string{msg.To.Address}, body); err != nil {
+		return fmt.Errorf("smtp %s: %w", m.addr, err)
+	}
+	return nil
+}
+
+// setFrom fills in the configured sender when the message has none
+func setFrom(msg *Message, from mail.Address) {
+	if msg.From.Address == "" {
+		msg.From = from
+	}
+}
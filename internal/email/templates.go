@@ -0,0 +1,88 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+)
+
+// Kind names an email template
+type Kind string
+
+const (
+	KindConfirmation Kind = "confirmation"
+	KindCancellation Kind = "cancellation"
+	KindRefund       Kind = "refund"
+	KindShipping     Kind = "shipping"
+)
+
+// Kinds lists every template, in the order of the order lifecycle
+var Kinds = []Kind{KindConfirmation, KindCancellation, KindRefund, KindShipping}
+
+// Data is what the templates render
+type Data struct {
+	CustomerName string // Greeting falls back to "there" when empty
+	Order        *domain.Order
+	Amount       domain.Money // Order total, or the refunded amount
+}
+
+// view adds the rendered subject for the HTML <title>
+type view struct {
+	Data
+	Subject string
+}
+
+// Each kind has <kind>.txt, defining "subject" and the text "content", and
+// <kind>.html with the HTML "content". layout.txt and layout.html wrap the
+// content and define the shared "items" table.
+//
+//go:embed templates
+var templateFS embed.FS
+
+type templateSet struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+var templates = parseTemplates()
+
+func parseTemplates() map[Kind]templateSet {
+	set := make(map[Kind]templateSet, len(Kinds))
+	for _, kind := range Kinds {
+		set[kind] = templateSet{
+			text: texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/layout.txt", "templates/"+string(kind)+".txt")),
+			html: htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/layout.html", "templates/"+string(kind)+".html")),
+		}
+	}
+	return set
+}
+
+// Render renders the subject and both bodies of kind. From and To are left to the caller.
+func Render(kind Kind, data Data) (*Message, error) {
+	set, ok := templates[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template %q", kind)
+	}
+	if data.Order == nil {
+		return nil, fmt.Errorf("%s email needs an order", kind)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := set.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s subject: %w", kind, err)
+	}
+	v := view{Data: data, Subject: strings.TrimSpace(subject.String())}
+	if err := set.text.ExecuteTemplate(&text, "layout.txt", v); err != nil {
+		return nil, fmt.Errorf("failed to render %s text: %w", kind, err)
+	}
+	if err := set.html.ExecuteTemplate(&html, "layout.html", v); err != nil {
+		return nil, fmt.Errorf("failed to render %s HTML: %w", kind, err)
+	}
+
+	return &Message{Subject: v.Subject, Text: text.String(), HTML: html.String()}, nil
+}
@@ -0,0 +1,103 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Hub fans out order updates from Redis to local subscribers (SSE streams).
+// A replica only subscribes to channels of orders it is currently streaming.
+type Hub struct {
+	pubsub *redis.PubSub
+
+	mu   sync.Mutex
+	subs map[string]map[chan OrderUpdate]struct{}
+}
+
+// NewHub creates a hub using a single Redis pub/sub connection
+func NewHub(ctx context.Context, client *redis.Client) *Hub {
+	return &Hub{
+		pubsub: client.Subscribe(ctx),
+		subs:   make(map[string]map[chan OrderUpdate]struct{}),
+	}
+}
+
+// Run dispatches incoming messages until ctx is cancelled
+func (h *Hub) Run(ctx context.Context) {
+	messages := h.pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			h.pubsub.Close()
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var update OrderUpdate
+			if err := json.Unmarshal([]byte(msg.Payload), &update); err != nil {
+				log.Printf("⚠️  [Events] Invalid update on %s: %v", msg.Channel, err)
+				continue
+			}
+			h.dispatch(strings.TrimPrefix(msg.Channel, orderChannel("")), update)
+		}
+	}
+}
+
+// Subscribe registers for updates of one order. The returned channel only
+// holds the latest update; call cancel when done.
+func (h *Hub) Subscribe(ctx context.Context, orderID string) (<-chan OrderUpdate, func(), error) {
+	ch := make(chan OrderUpdate, 1)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subs[orderID] == nil {
+		if err := h.pubsub.Subscribe(ctx, orderChannel(orderID)); err != nil {
+			return nil, nil, err
+		}
+		h.subs[orderID] = make(map[chan OrderUpdate]struct{})
+	}
+	h.subs[orderID][ch] = struct{}{}
+
+	cancel := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		delete(h.subs[orderID], ch)
+		if len(h.subs[orderID]) == 0 {
+			delete(h.subs, orderID)
+			if err := h.pubsub.Unsubscribe(context.Background(), orderChannel(orderID)); err != nil {
+				log.Printf("⚠️  [Events] Failed to unsubscribe from order %s: %v", orderID, err)
+			}
+		}
+	}
+	return ch, cancel, nil
+}
+
+// dispatch delivers an update without blocking; slow subscribers get the latest state only
+func (h *Hub) dispatch(orderID string, update OrderUpdate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subs[orderID] {
+		select {
+		case ch <- update:
+		default:
+			// Replace the stale pending update with the newer one
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- update:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+)
+
+// OrderUpdate is the order state broadcast to live streams
+type OrderUpdate struct {
+	OrderID        string    `json:"order_id"`
+	Status         string    `json:"status"`
+	PaymentStatus  string    `json:"payment_status"`
+	InvoiceURL     string    `json:"invoice_url,omitempty"`
+	TrackingNumber string    `json:"tracking_number,omitempty"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// NewOrderUpdate captures the streamed fields of an order
+func NewOrderUpdate(order *domain.Order) OrderUpdate {
+	return OrderUpdate{
+		OrderID:        order.ID,
+		Status:         string(order.Status),
+		PaymentStatus:  string(order.PaymentStatus),
+		InvoiceURL:     order.InvoiceURL,
+		TrackingNumber: order.TrackingNumber,
+		UpdatedAt:      order.UpdatedAt,
+	}
+}
+
+// SameState reports whether two updates carry the same visible state
+func (u OrderUpdate) SameState(other OrderUpdate) bool {
+	return u.Status == other.Status &&
+		u.PaymentStatus == other.PaymentStatus &&
+		u.InvoiceURL == other.InvoiceURL &&
+		u.TrackingNumber == other.TrackingNumber
+}
+
+// IsTerminal reports whether no further updates are expected
+func (u OrderUpdate) IsTerminal() bool {
+	return domain.OrderStatus(u.Status).IsTerminal()
+}
+
+// orderChannel is the Redis pub/sub channel for one order
+func orderChannel(orderID string) string {
+	return "orders:updates:" + orderID
+}
+
+// Publisher broadcasts order updates to every API replica via Redis pub/sub
+type Publisher struct {
+	client *redis.Client
+}
+
+// NewPublisher creates a new Redis publisher
+func NewPublisher(client *redis.Client) *Publisher {
+	return &Publisher{client: client}
+}
+
+// Publish broadcasts an order update
+func (p *Publisher) Publish(ctx context.Context, update OrderUpdate) error {
+	data, err := json.Marshal(update)
+	if err != nil {
+		return fmt.Errorf("failed to marshal order update: %w", err)
+	}
+	return p.client.Publish(ctx, orderChannel(update.OrderID), data).Err()
+}
+
+// publishingOrderRepository publishes every committed update
+type publishingOrderRepository struct {
+	repository.OrderRepository
+	publisher *Publisher
+}
+
+// WithPublisher wraps repo so every Update is broadcast to live streams.
+// Publishing is best effort: a failure is logged, the update still succeeds.
+// Inside a repository transaction, the update waits for the commit.
+func WithPublisher(repo repository.OrderRepository, publisher *Publisher) repository.OrderRepository {
+	return &publishingOrderRepository{OrderRepository: repo, publisher: publisher}
+}
+
+// Update updates the order and publishes its new state
+func (r *publishingOrderRepository) Update(ctx context.Context, order *domain.Order) error {
+	if err := r.OrderRepository.Update(ctx, order); err != nil {
+		return err
+	}
+
+	// Streams only see committed states; a rolled back write is never published
+	update := NewOrderUpdate(order)
+	repository.AfterCommit(ctx, func(ctx context.Context) {
+		if err := r.publisher.Publish(ctx, update); err != nil {
+			log.Printf("⚠️  [Events] Failed to publish update for order %s: %v", update.OrderID, err)
+		}
+	})
+	return nil
+}
@@ -0,0 +1,107 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+	"github.com/lppduy/go-asynq-loadtest/pkg/database"
+)
+
+// publishRecorder answers PUBLISH commands itself and records their channels
+type publishRecorder struct {
+	mu       sync.Mutex
+	channels []string
+}
+
+func (r *publishRecorder) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, errors.New("no redis in tests")
+	}
+}
+
+func (r *publishRecorder) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() != "publish" {
+			return next(ctx, cmd)
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.channels = append(r.channels, cmd.Args()[1].(string))
+		return nil
+	}
+}
+
+func (r *publishRecorder) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func (r *publishRecorder) published() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.channels...)
+}
+
+func TestPublisherWaitsForCommit(t *testing.T) {
+	db, err := database.Connect(database.Config{
+		Driver:     database.DriverSQLite,
+		SQLitePath: filepath.Join(t.TempDir(), "orders.db"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactor := repository.NewGormTransactor(db)
+
+	recorder := &publishRecorder{}
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	client.AddHook(recorder)
+	t.Cleanup(func() { client.Close() })
+
+	repo := WithPublisher(repository.NewMemoryOrderRepository(), NewPublisher(client))
+	ctx := context.Background()
+	order := &domain.Order{ID: "ORD-9c8d7e6f", CustomerID: "cust-42", Status: domain.OrderStatusPending, CreatedAt: time.Now()}
+	if err := repo.Create(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+
+	// A write that rolls back is never seen by streams
+	errAbort := errors.New("abort")
+	err = transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		changed := *order
+		changed.Status = domain.OrderStatusConfirmed
+		if err := repo.Update(ctx, &changed); err != nil {
+			return err
+		}
+		if got := recorder.published(); len(got) != 0 {
+			t.Errorf("published %v before the commit", got)
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithinTransaction = %v, want %v", err, errAbort)
+	}
+	if got := recorder.published(); len(got) != 0 {
+		t.Fatalf("rolled back update was published on %v", got)
+	}
+
+	// A committed one is published once, after the commit
+	err = transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		changed := *order
+		changed.Status = domain.OrderStatusConfirmed
+		return repo.Update(ctx, &changed)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := recorder.published(); len(got) != 1 || got[0] != orderChannel(order.ID) {
+		t.Fatalf("published on %v, want once on %s", got, orderChannel(order.ID))
+	}
+}
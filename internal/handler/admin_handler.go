@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+	"github.com/lppduy/go-asynq-loadtest/internal/dto"
+)
+
+// AdminHandler handles operator-only HTTP requests
+type AdminHandler struct {
+	inspector *asynq.Inspector
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(inspector *asynq.Inspector) *AdminHandler {
+	return &AdminHandler{inspector: inspector}
+}
+
+// ListQueues handles GET /api/v1/admin/queues
+func (h *AdminHandler) ListQueues(c *gin.Context) {
+	queues, err := h.inspector.Queues()
+	if err != nil {
+		log.Printf("Failed to list queues: %v", err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "Failed to list queues",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	resp := dto.QueueListResponse{Queues: make([]dto.QueueInfoResponse, 0, len(queues))}
+	for _, queue := range queues {
+		info, err := h.inspector.GetQueueInfo(queue)
+		if err != nil {
+			log.Printf("Failed to inspect queue %s: %v", queue, err)
+			continue
+		}
+		resp.Queues = append(resp.Queues, dto.QueueInfoResponse{
+			Queue:       info.Queue,
+			Paused:      info.Paused,
+			Pending:     info.Pending,
+			Active:      info.Active,
+			Scheduled:   info.Scheduled,
+			Retry:       info.Retry,
+			Archived:    info.Archived,
+			Completed:   info.Completed,
+			Aggregating: info.Aggregating,
+			LatencySec:  info.Latency.Seconds(),
+			Processed:   info.Processed,
+			Failed:      info.Failed,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/dto"
+	"github.com/lppduy/go-asynq-loadtest/internal/service"
+)
+
+// AnalyticsHandler handles analytics reporting HTTP requests
+type AnalyticsHandler struct {
+	service service.AnalyticsService
+}
+
+// NewAnalyticsHandler creates a new analytics handler
+func NewAnalyticsHandler(service service.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{service: service}
+}
+
+// Summary handles GET /api/v1/analytics/summary?from=&to=&granularity=
+// from and to are RFC 3339 times, defaulting to the last hour; granularity is
+// minute (default) or hour.
+func (h *AnalyticsHandler) Summary(c *gin.Context) {
+	to, err := queryTime(c, "to", time.Now())
+	if err != nil {
+		respondAnalyticsError(c, err)
+		return
+	}
+	from, err := queryTime(c, "from", to.Add(-time.Hour))
+	if err != nil {
+		respondAnalyticsError(c, err)
+		return
+	}
+	granularity := domain.AnalyticsGranularity(c.DefaultQuery("granularity", string(domain.GranularityMinute)))
+
+	summary, err := h.service.Summary(c.Request.Context(), from, to, granularity)
+	if err != nil {
+		respondAnalyticsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toAnalyticsSummaryResponse(summary))
+}
+
+// respondAnalyticsError maps service errors to HTTP responses
+func respondAnalyticsError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidAnalyticsRange) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	log.Printf("Failed to summarize analytics: %v", err)
+	c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+		Error:   "Failed to summarize analytics",
+		Message: err.Error(),
+	})
+}
+
+// queryTime parses an RFC 3339 query parameter, returning fallback when it is absent
+func queryTime(c *gin.Context, name string, fallback time.Time) (time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return fallback, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s must be an RFC 3339 time, got %q", service.ErrInvalidAnalyticsRange, name, value)
+	}
+	return t, nil
+}
+
+func toAnalyticsSummaryResponse(summary *service.AnalyticsSummary) dto.AnalyticsSummaryResponse {
+	resp := dto.AnalyticsSummaryResponse{
+		From:        summary.From.UTC().Format(time.RFC3339),
+		To:          summary.To.UTC().Format(time.RFC3339),
+		Granularity: string(summary.Granularity),
+		Total:       toAnalyticsRollupResponse(summary.Total),
+		Rollups:     make([]dto.AnalyticsRollupResponse, 0, len(summary.Rollups)),
+	}
+	resp.Total.Start = "" // The range is in From and To
+	for _, r := range summary.Rollups {
+		resp.Rollups = append(resp.Rollups, toAnalyticsRollupResponse(r))
+	}
+	return resp
+}
+
+func toAnalyticsRollupResponse(r *domain.AnalyticsRollup) dto.AnalyticsRollupResponse {
+	revenue := make([]domain.Money, 0, len(r.Revenue))
+	for _, m := range r.Revenue {
+		revenue = append(revenue, m)
+	}
+	sort.Slice(revenue, func(i, j int) bool { return revenue[i].Currency < revenue[j].Currency })
+
+	return dto.AnalyticsRollupResponse{
+		Start:          r.Start.UTC().Format(time.RFC3339),
+		Orders:         r.Orders,
+		Revenue:        revenue,
+		AverageItems:   math.Round(r.AverageItems()*100) / 100,
+		PaymentMethods: r.PaymentMethods,
+	}
+}
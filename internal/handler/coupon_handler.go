@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/dto"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+	"github.com/lppduy/go-asynq-loadtest/internal/service"
+)
+
+// CouponHandler handles discount code HTTP requests
+type CouponHandler struct {
+	service service.CouponService
+}
+
+// NewCouponHandler creates a new coupon handler
+func NewCouponHandler(service service.CouponService) *CouponHandler {
+	return &CouponHandler{service: service}
+}
+
+// CreateCoupon handles POST /api/v1/coupons
+func (h *CouponHandler) CreateCoupon(c *gin.Context) {
+	var req dto.CreateCouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	coupon, err := h.service.CreateCoupon(c.Request.Context(), req)
+	if err != nil {
+		respondCouponError(c, req.Code, "Failed to create coupon", err)
+		return
+	}
+
+	log.Printf("🎟️  Coupon created: %s (%s, max uses %d)", coupon.Code, coupon.Type, coupon.MaxUses)
+	c.JSON(http.StatusCreated, toCouponResponse(coupon))
+}
+
+// ListCoupons handles GET /api/v1/coupons
+func (h *CouponHandler) ListCoupons(c *gin.Context) {
+	coupons, err := h.service.ListCoupons(c.Request.Context())
+	if err != nil {
+		log.Printf("Failed to list coupons: %v", err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "Failed to list coupons",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	resp := dto.CouponListResponse{Total: len(coupons), Coupons: make([]dto.CouponResponse, len(coupons))}
+	for i, coupon := range coupons {
+		resp.Coupons[i] = toCouponResponse(coupon)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetCoupon handles GET /api/v1/coupons/:code
+func (h *CouponHandler) GetCoupon(c *gin.Context) {
+	code := c.Param("code")
+
+	coupon, err := h.service.GetCoupon(c.Request.Context(), code)
+	if err != nil {
+		respondCouponError(c, code, "Failed to get coupon", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toCouponResponse(coupon))
+}
+
+// UpdateCoupon handles PATCH /api/v1/coupons/:code
+func (h *CouponHandler) UpdateCoupon(c *gin.Context) {
+	code := c.Param("code")
+
+	var req dto.UpdateCouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	coupon, err := h.service.SetActive(c.Request.Context(), code, *req.Active)
+	if err != nil {
+		respondCouponError(c, code, "Failed to update coupon", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toCouponResponse(coupon))
+}
+
+// respondCouponError maps repository errors to HTTP responses
+func respondCouponError(c *gin.Context, code, message string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidAmount), errors.Is(err, domain.ErrUnsupportedCurrency):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	case errors.Is(err, repository.ErrCouponNotFound):
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "Coupon not found",
+			Message: fmt.Sprintf("Coupon %s does not exist", code),
+		})
+		return
+	case errors.Is(err, repository.ErrCouponExists):
+		c.JSON(http.StatusConflict, dto.ErrorResponse{
+			Error:   "Coupon already exists",
+			Message: fmt.Sprintf("Coupon %s already exists", code),
+		})
+		return
+	}
+
+	log.Printf("%s: %v", message, err)
+	c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+		Error:   message,
+		Message: err.Error(),
+	})
+}
+
+// Helper function to convert domain.Coupon to dto.CouponResponse
+func toCouponResponse(coupon *domain.Coupon) dto.CouponResponse {
+	resp := dto.CouponResponse{
+		Code:      coupon.Code,
+		Type:      string(coupon.Type),
+		MinSpend:  coupon.MinSpend,
+		MaxUses:   coupon.MaxUses,
+		UsedCount: coupon.UsedCount,
+		Active:    coupon.Active,
+		CreatedAt: coupon.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	switch coupon.Type {
+	case domain.CouponTypePercentage:
+		resp.Percent = coupon.Percent.String()
+	case domain.CouponTypeFixed:
+		amount := coupon.Amount
+		resp.Amount = &amount
+	}
+	if coupon.ExpiresAt != nil {
+		resp.ExpiresAt = coupon.ExpiresAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return resp
+}
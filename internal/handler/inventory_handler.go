@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/dto"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+	"github.com/lppduy/go-asynq-loadtest/internal/service"
+)
+
+// InventoryHandler handles product catalog and stock HTTP requests
+type InventoryHandler struct {
+	service service.InventoryService
+}
+
+// NewInventoryHandler creates a new inventory handler
+func NewInventoryHandler(service service.InventoryService) *InventoryHandler {
+	return &InventoryHandler{service: service}
+}
+
+// CreateProduct handles POST /api/v1/products
+func (h *InventoryHandler) CreateProduct(c *gin.Context) {
+	var req dto.CreateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	product, err := h.service.CreateProduct(c.Request.Context(), req)
+	if err != nil {
+		respondInventoryError(c, req.ID, "Failed to create product", err)
+		return
+	}
+
+	log.Printf("🏷️  Product created: %s (%s) stock=%d", product.ID, product.Name, req.InitialStock)
+
+	stock, _ := h.service.GetStock(c.Request.Context(), product.ID)
+	c.JSON(http.StatusCreated, toProductResponse(product, stock))
+}
+
+// ListProducts handles GET /api/v1/products
+func (h *InventoryHandler) ListProducts(c *gin.Context) {
+	products, err := h.service.ListProducts(c.Request.Context())
+	if err != nil {
+		log.Printf("Failed to list products: %v", err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "Failed to list products",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	resp := dto.ProductListResponse{Total: len(products), Products: make([]dto.ProductResponse, len(products))}
+	for i, product := range products {
+		resp.Products[i] = toProductResponse(product, nil)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetProduct handles GET /api/v1/products/:id
+func (h *InventoryHandler) GetProduct(c *gin.Context) {
+	id := c.Param("id")
+
+	product, err := h.service.GetProduct(c.Request.Context(), id)
+	if err != nil {
+		respondInventoryError(c, id, "Failed to get product", err)
+		return
+	}
+
+	stock, err := h.service.GetStock(c.Request.Context(), id)
+	if err != nil {
+		respondInventoryError(c, id, "Failed to get stock", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toProductResponse(product, stock))
+}
+
+// UpdateProduct handles PUT /api/v1/products/:id
+func (h *InventoryHandler) UpdateProduct(c *gin.Context) {
+	id := c.Param("id")
+
+	var req dto.UpdateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	product, err := h.service.UpdateProduct(c.Request.Context(), id, req)
+	if err != nil {
+		respondInventoryError(c, id, "Failed to update product", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toProductResponse(product, nil))
+}
+
+// DeleteProduct handles DELETE /api/v1/products/:id
+func (h *InventoryHandler) DeleteProduct(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.service.DeleteProduct(c.Request.Context(), id); err != nil {
+		respondInventoryError(c, id, "Failed to delete product", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "Product deleted successfully"})
+}
+
+// ListStock handles GET /api/v1/inventory
+func (h *InventoryHandler) ListStock(c *gin.Context) {
+	levels, err := h.service.ListStock(c.Request.Context())
+	if err != nil {
+		log.Printf("Failed to list stock: %v", err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "Failed to list stock",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	resp := dto.StockListResponse{Total: len(levels), Stock: make([]dto.StockResponse, len(levels))}
+	for i, level := range levels {
+		resp.Stock[i] = toStockResponse(level)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetStock handles GET /api/v1/inventory/:product_id
+func (h *InventoryHandler) GetStock(c *gin.Context) {
+	productID := c.Param("product_id")
+
+	level, err := h.service.GetStock(c.Request.Context(), productID)
+	if err != nil {
+		respondInventoryError(c, productID, "Failed to get stock", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toStockResponse(level))
+}
+
+// SetStock handles PUT /api/v1/inventory/:product_id
+func (h *InventoryHandler) SetStock(c *gin.Context) {
+	productID := c.Param("product_id")
+
+	var req dto.SetStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	level, err := h.service.SetStock(c.Request.Context(), productID, *req.OnHand)
+	if err != nil {
+		respondInventoryError(c, productID, "Failed to set stock", err)
+		return
+	}
+
+	log.Printf("📦 Stock set: %s on_hand=%d reserved=%d", productID, level.OnHand, level.Reserved)
+	c.JSON(http.StatusOK, toStockResponse(level))
+}
+
+// AdjustStock handles POST /api/v1/inventory/:product_id/adjust
+func (h *InventoryHandler) AdjustStock(c *gin.Context) {
+	productID := c.Param("product_id")
+
+	var req dto.AdjustStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	level, err := h.service.AdjustStock(c.Request.Context(), productID, req.Delta)
+	if err != nil {
+		respondInventoryError(c, productID, "Failed to adjust stock", err)
+		return
+	}
+
+	log.Printf("📦 Stock adjusted: %s delta=%+d on_hand=%d (%s)", productID, req.Delta, level.OnHand, req.Reason)
+	c.JSON(http.StatusOK, toStockResponse(level))
+}
+
+// respondInventoryError maps repository errors to HTTP responses
+func respondInventoryError(c *gin.Context, productID, message string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidAmount), errors.Is(err, domain.ErrUnsupportedCurrency):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	case errors.Is(err, repository.ErrProductNotFound):
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "Product not found",
+			Message: fmt.Sprintf("Product %s does not exist", productID),
+		})
+		return
+	case errors.Is(err, repository.ErrProductExists):
+		c.JSON(http.StatusConflict, dto.ErrorResponse{
+			Error:   "Product already exists",
+			Message: fmt.Sprintf("Product %s already exists", productID),
+		})
+		return
+	case errors.Is(err, repository.ErrInvalidStockLevel):
+		c.JSON(http.StatusConflict, dto.ErrorResponse{
+			Error:   "Invalid stock level",
+			Message: err.Error(),
+			Code:    "STOCK_BELOW_RESERVED",
+		})
+		return
+	}
+
+	log.Printf("%s: %v", message, err)
+	c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+		Error:   message,
+		Message: err.Error(),
+	})
+}
+
+// Helper function to convert domain.Product to dto.ProductResponse
+func toProductResponse(product *domain.Product, stock *domain.StockLevel) dto.ProductResponse {
+	resp := dto.ProductResponse{
+		ID:        product.ID,
+		Name:      product.Name,
+		Price:     product.Price,
+		Active:    product.Active,
+		CreatedAt: product.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: product.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if stock != nil {
+		s := toStockResponse(stock)
+		resp.Stock = &s
+	}
+	return resp
+}
+
+// Helper function to convert domain.StockLevel to dto.StockResponse
+func toStockResponse(level *domain.StockLevel) dto.StockResponse {
+	return dto.StockResponse{
+		ProductID: level.ProductID,
+		OnHand:    level.OnHand,
+		Reserved:  level.Reserved,
+		Available: level.Available(),
+		UpdatedAt: level.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
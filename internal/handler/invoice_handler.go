@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lppduy/go-asynq-loadtest/internal/dto"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+	"github.com/lppduy/go-asynq-loadtest/internal/service"
+)
+
+// InvoiceHandler serves the invoice PDFs rendered by invoice:generate
+type InvoiceHandler struct {
+	orders   service.OrderService
+	invoices service.InvoiceService
+}
+
+// NewInvoiceHandler creates a new invoice handler
+func NewInvoiceHandler(orders service.OrderService, invoices service.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{orders: orders, invoices: invoices}
+}
+
+// GetInvoice handles GET /api/v1/orders/:id/invoice
+// It redirects to a signed link when the store has them (S3), and streams the
+// PDF otherwise.
+func (h *InvoiceHandler) GetInvoice(c *gin.Context) {
+	orderID := c.Param("id")
+	ctx := c.Request.Context()
+
+	order, err := h.orders.GetOrder(ctx, orderID)
+	if err == nil && !canAccessOrder(c, order) {
+		err = repository.ErrOrderNotFound
+	}
+	if err != nil {
+		if err == repository.ErrOrderNotFound {
+			respondOrderNotFound(c, orderID)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "Failed to get order",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	download, err := h.invoices.Download(ctx, order)
+	if err != nil {
+		if errors.Is(err, service.ErrInvoiceNotReady) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Error:   "Invoice not ready",
+				Message: fmt.Sprintf("The invoice of order %s has not been generated yet", orderID),
+				Code:    "INVOICE_NOT_READY",
+			})
+			return
+		}
+		log.Printf("Failed to get invoice of order %s: %v", orderID, err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "Failed to get invoice",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if download.URL != "" {
+		c.Redirect(http.StatusFound, download.URL)
+		return
+	}
+	defer download.Body.Close()
+
+	c.Header("Cache-Control", "private, no-cache") // Regenerated when the order is edited
+	c.DataFromReader(http.StatusOK, download.Info.Size, download.Info.ContentType, download.Body, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`inline; filename="invoice-%s.pdf"`, orderID),
+		"Last-Modified":       download.Info.ModTime.UTC().Format(http.TimeFormat),
+	})
+}
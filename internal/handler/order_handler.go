@@ -1,38 +1,80 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/hibiken/asynq"
+	"github.com/lppduy/go-asynq-loadtest/internal/auth"
+	"github.com/lppduy/go-asynq-loadtest/internal/backpressure"
 	"github.com/lppduy/go-asynq-loadtest/internal/domain"
 	"github.com/lppduy/go-asynq-loadtest/internal/dto"
 	"github.com/lppduy/go-asynq-loadtest/internal/repository"
 	"github.com/lppduy/go-asynq-loadtest/internal/service"
 	"github.com/lppduy/go-asynq-loadtest/internal/tasks"
+	"github.com/lppduy/go-asynq-loadtest/pkg/metrics"
 )
 
 // OrderHandler handles order HTTP requests
 type OrderHandler struct {
-	service     service.OrderService
-	asynqClient *asynq.Client
+	service       service.OrderService
+	asynqClient   *asynq.Client    // nil: orders get no background tasks
+	inspector     *asynq.Inspector // Finds scheduled tasks of edited orders
 	taskRetention time.Duration
+	admission     *AdmissionControl
+	batch         BatchLimits
+
+	// enqueueTasks is enqueueOrderTasks; tests replace it
+	enqueueTasks func(order *domain.Order, shed bool) error
+}
+
+// AdmissionControl configures backpressure for order creation
+type AdmissionControl struct {
+	Monitor      *backpressure.Monitor
+	RejectStatus int           // 503 or 429
+	RetryAfter   time.Duration // Retry-After header value on rejection
+}
+
+// BatchLimits bounds POST /api/v1/orders/batch
+type BatchLimits struct {
+	MaxOrders          int // Largest accepted batch
+	EnqueueConcurrency int // Orders whose tasks are enqueued at the same time
 }
 
-// NewOrderHandler creates a new order handler
-func NewOrderHandler(service service.OrderService, asynqClient *asynq.Client, taskRetention time.Duration) *OrderHandler {
-	return &OrderHandler{
-		service:     service,
-		asynqClient: asynqClient,
+// NewOrderHandler creates a new order handler.
+// admission may be nil to accept every order unconditionally. asynqClient may be
+// nil when no worker can see the orders (DB_DRIVER=memory): orders are then
+// created, edited and cancelled without enqueueing any task, and stay pending.
+func NewOrderHandler(service service.OrderService, asynqClient *asynq.Client, inspector *asynq.Inspector, taskRetention time.Duration, admission *AdmissionControl, batch BatchLimits) *OrderHandler {
+	h := &OrderHandler{
+		service:       service,
+		asynqClient:   asynqClient,
+		inspector:     inspector,
 		taskRetention: taskRetention,
+		admission:     admission,
+		batch:         batch,
 	}
+	h.enqueueTasks = h.enqueueOrderTasks
+	return h
 }
 
 // CreateOrder handles POST /api/v1/orders
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
+	// Admission control: refuse work early when the worker is far behind
+	verdict := h.admit()
+	if verdict.Decision == backpressure.DecisionReject {
+		h.respondOverloaded(c, verdict)
+		return
+	}
+
 	var req dto.CreateOrderRequest
 
 	// Bind and validate request
@@ -44,9 +86,38 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
+	// Customers may only order for themselves
+	if p := principalFrom(c); p != nil && !p.CanAccessCustomer(req.CustomerID) {
+		respondForbidden(c, "Cannot create orders for another customer")
+		return
+	}
+
 	// Create order
 	order, err := h.service.CreateOrder(c.Request.Context(), req)
 	if err != nil {
+		var invalid *service.ErrOrderValidation
+		if errors.As(err, &invalid) {
+			respondOrderValidation(c, invalid)
+			return
+		}
+		var badCoupon *service.ErrInvalidCoupon
+		if errors.As(err, &badCoupon) {
+			metrics.OrdersRejected.WithLabelValues(badCoupon.Code).Inc()
+			c.JSON(http.StatusUnprocessableEntity, dto.ErrorResponse{
+				Error:   "Coupon cannot be applied",
+				Message: badCoupon.Message,
+				Code:    badCoupon.Code,
+			})
+			return
+		}
+		if errors.Is(err, domain.ErrUnsupportedCurrency) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Error:   "Invalid request",
+				Message: err.Error(),
+			})
+			return
+		}
+
 		log.Printf("Failed to create order: %v", err)
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 			Error:   "Failed to create order",
@@ -55,17 +126,130 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
-	// Enqueue background tasks (non-blocking, fast response)
-	go h.enqueueOrderTasks(order)
+	// Enqueued before responding: the client learns the order ID from the
+	// response, so an edit can never find the tasks missing and race the enqueue
+	shed := verdict.Decision == backpressure.DecisionShed
+	h.enqueueTasks(order, shed)
 
-	log.Printf("✅ Order created: %s | Total: $%.2f | Items: %d", 
+	log.Printf("✅ Order created: %s | Total: %s | Items: %d",
 		order.ID, order.TotalAmount, len(order.Items))
-	log.Printf("📋 Background tasks enqueued asynchronously")
 
-	// Return response immediately (fast response!)
 	c.JSON(http.StatusCreated, toOrderResponse(order))
 }
 
+// CreateOrderBatch handles POST /api/v1/orders/batch.
+// Every order is validated first. In atomic mode (default) one invalid order
+// refuses the batch and the rest is created in a single transaction; in partial
+// mode every order is created or refused on its own. 201 when all were created,
+// 207 when only some, 422 when none.
+func (h *OrderHandler) CreateOrderBatch(c *gin.Context) {
+	verdict := h.admit()
+	if verdict.Decision == backpressure.DecisionReject {
+		h.respondOverloaded(c, verdict)
+		return
+	}
+
+	var req dto.CreateOrderBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+	if len(req.Orders) > h.batch.MaxOrders {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "Invalid request",
+			Message: fmt.Sprintf("Batch has %d orders, at most %d are accepted", len(req.Orders), h.batch.MaxOrders),
+			Code:    "BATCH_TOO_LARGE",
+		})
+		return
+	}
+	mode := req.Mode
+	if mode == "" {
+		mode = service.BatchModeAtomic
+	}
+
+	// Per-order validation, so one bad order does not hide the others
+	results := make([]dto.OrderBatchResult, len(req.Orders))
+	var valid []dto.CreateOrderRequest
+	var validIndex []int
+	for i := range req.Orders {
+		results[i].Index = i
+		if invalid := checkBatchOrder(c, &req.Orders[i]); invalid != nil {
+			results[i].Status = "failed"
+			results[i].Error = invalid
+			continue
+		}
+		valid = append(valid, req.Orders[i])
+		validIndex = append(validIndex, i)
+	}
+
+	var orders []*domain.Order
+	var orderIndex []int // Result index of each created order
+	if mode == service.BatchModeAtomic && len(valid) < len(req.Orders) {
+		for _, i := range validIndex {
+			results[i].Status = "not_created"
+			results[i].Error = batchOrderError(service.ErrBatchRolledBack)
+		}
+	} else if len(valid) > 0 {
+		created, err := h.service.CreateOrders(c.Request.Context(), valid, mode)
+		if err != nil {
+			log.Printf("Failed to create order batch: %v", err)
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Error:   "Failed to create orders",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		for j, result := range created {
+			i := validIndex[j]
+			switch {
+			case result.Order != nil:
+				order := toOrderResponse(result.Order)
+				results[i].Status = "created"
+				results[i].Order = &order
+				orders = append(orders, result.Order)
+				orderIndex = append(orderIndex, i)
+			case errors.Is(result.Err, service.ErrBatchRolledBack):
+				results[i].Status = "not_created"
+				results[i].Error = batchOrderError(result.Err)
+			default:
+				results[i].Status = "failed"
+				results[i].Error = batchOrderError(result.Err)
+			}
+		}
+	}
+
+	// Enqueued before responding, like single orders. The orders exist either
+	// way; failures are reported on the order whose tasks are missing.
+	shed := verdict.Decision == backpressure.DecisionShed
+	for j, err := range h.enqueueBatchTasks(orders, shed) {
+		if err != nil {
+			results[orderIndex[j]].TasksError = err.Error()
+		}
+	}
+
+	response := dto.OrderBatchResponse{Mode: mode, Created: len(orders), Results: results}
+	for _, result := range results {
+		if result.Status == "failed" {
+			response.Failed++
+		}
+	}
+	log.Printf("✅ Order batch (%s): %d created, %d failed, %d not created",
+		mode, response.Created, response.Failed, len(results)-response.Created-response.Failed)
+
+	status := http.StatusMultiStatus
+	switch len(orders) {
+	case len(results):
+		status = http.StatusCreated
+	case 0:
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, response)
+}
+
 // GetOrder handles GET /api/v1/orders/:id
 func (h *OrderHandler) GetOrder(c *gin.Context) {
 	orderID := c.Param("id")
@@ -73,10 +257,7 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 	order, err := h.service.GetOrder(c.Request.Context(), orderID)
 	if err != nil {
 		if err == repository.ErrOrderNotFound {
-			c.JSON(http.StatusNotFound, dto.ErrorResponse{
-				Error:   "Order not found",
-				Message: fmt.Sprintf("Order %s does not exist", orderID),
-			})
+			respondOrderNotFound(c, orderID)
 			return
 		}
 
@@ -88,6 +269,11 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 		return
 	}
 
+	if !canAccessOrder(c, order) {
+		respondOrderNotFound(c, orderID)
+		return
+	}
+
 	c.JSON(http.StatusOK, toOrderResponse(order))
 }
 
@@ -96,6 +282,15 @@ func (h *OrderHandler) ListOrders(c *gin.Context) {
 	// Optional: filter by customer_id
 	customerID := c.Query("customer_id")
 
+	// Customers only see their own orders
+	if p := principalFrom(c); p != nil && !p.IsAdmin() {
+		if p.CustomerID == "" || (customerID != "" && customerID != p.CustomerID) {
+			respondForbidden(c, "Cannot list orders of another customer")
+			return
+		}
+		customerID = p.CustomerID
+	}
+
 	orders, err := h.service.ListOrders(c.Request.Context(), customerID)
 	if err != nil {
 		log.Printf("Failed to list orders: %v", err)
@@ -118,6 +313,94 @@ func (h *OrderHandler) ListOrders(c *gin.Context) {
 	})
 }
 
+// UpdateOrder handles PATCH /api/v1/orders/:id. Items, shipping address and
+// notes can change until payment starts; totals are recalculated and the
+// scheduled payment, inventory, invoice and warehouse tasks are replaced.
+func (h *OrderHandler) UpdateOrder(c *gin.Context) {
+	orderID := c.Param("id")
+
+	var req dto.UpdateOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+	if req.Items == nil && req.ShippingAddress == nil && req.Notes == nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "Invalid request",
+			Message: "Nothing to update: send items, shipping_address and/or notes",
+		})
+		return
+	}
+
+	existing, err := h.service.GetOrder(c.Request.Context(), orderID)
+	if err == nil && !canAccessOrder(c, existing) {
+		err = repository.ErrOrderNotFound
+	}
+	if err != nil {
+		if err == repository.ErrOrderNotFound {
+			respondOrderNotFound(c, orderID)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "Failed to update order",
+			Message: err.Error(),
+		})
+		return
+	}
+	if !existing.CanEdit() {
+		respondOrderNotEditable(c, fmt.Sprintf("Orders can only be edited before payment starts (current status: %s)", existing.Status))
+		return
+	}
+
+	order, err := h.service.UpdateOrder(c.Request.Context(), orderID, req, h.replaceOrderTasks)
+	if err != nil {
+		var invalid *service.ErrOrderValidation
+		var badCoupon *service.ErrInvalidCoupon
+		switch {
+		case errors.As(err, &invalid):
+			respondOrderValidation(c, invalid)
+		case errors.As(err, &badCoupon):
+			c.JSON(http.StatusUnprocessableEntity, dto.ErrorResponse{
+				Error:   "Coupon cannot be applied",
+				Message: badCoupon.Message,
+				Code:    badCoupon.Code,
+			})
+		case errors.Is(err, service.ErrOrderNotEditable):
+			respondOrderNotEditable(c, err.Error())
+		case errors.Is(err, errTaskStarted):
+			respondOrderNotEditable(c, "Payment or fulfilment of this order has already started")
+		case errors.Is(err, errTasksUnavailable):
+			log.Printf("❌ Failed to replace tasks of order %s: %v", orderID, err)
+			c.JSON(http.StatusServiceUnavailable, dto.ErrorResponse{
+				Error:   "Failed to update order",
+				Message: "Scheduled tasks could not be replaced, try again",
+			})
+		case errors.Is(err, asynq.ErrTaskIDConflict):
+			c.JSON(http.StatusConflict, dto.ErrorResponse{
+				Error:   "Order cannot be edited",
+				Message: "Tasks of this order were enqueued concurrently, try again",
+				Code:    "ORDER_EDIT_CONFLICT",
+			})
+		case errors.Is(err, repository.ErrOrderNotFound):
+			respondOrderNotFound(c, orderID)
+		default:
+			log.Printf("Failed to update order: %v", err)
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Error:   "Failed to update order",
+				Message: err.Error(),
+			})
+		}
+		return
+	}
+
+	log.Printf("✏️  Order updated: %s | Total: %s | Items: %d", order.ID, order.TotalAmount, len(order.Items))
+
+	c.JSON(http.StatusOK, toOrderResponse(order))
+}
+
 // CancelOrder handles POST /api/v1/orders/:id/cancel
 func (h *OrderHandler) CancelOrder(c *gin.Context) {
 	orderID := c.Param("id")
@@ -131,13 +414,29 @@ func (h *OrderHandler) CancelOrder(c *gin.Context) {
 		return
 	}
 
+	// Check ownership before mutating anything
+	if p := principalFrom(c); p != nil && !p.IsAdmin() {
+		existing, err := h.service.GetOrder(c.Request.Context(), orderID)
+		if err == nil && !canAccessOrder(c, existing) {
+			err = repository.ErrOrderNotFound
+		}
+		if err != nil {
+			if err == repository.ErrOrderNotFound {
+				respondOrderNotFound(c, orderID)
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Error:   "Failed to cancel order",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
 	order, err := h.service.CancelOrder(c.Request.Context(), orderID, req.Reason)
 	if err != nil {
 		if err == repository.ErrOrderNotFound {
-			c.JSON(http.StatusNotFound, dto.ErrorResponse{
-				Error:   "Order not found",
-				Message: fmt.Sprintf("Order %s does not exist", orderID),
-			})
+			respondOrderNotFound(c, orderID)
 			return
 		}
 
@@ -148,8 +447,7 @@ func (h *OrderHandler) CancelOrder(c *gin.Context) {
 		return
 	}
 
-	// TODO: Enqueue refund task
-	// - payment:refund (critical queue)
+	h.enqueueCancellationTasks(order)
 
 	log.Printf("⚠️ Order cancelled: %s | Reason: %s", order.ID, req.Reason)
 
@@ -166,10 +464,7 @@ func (h *OrderHandler) GetOrderStatus(c *gin.Context) {
 	order, err := h.service.GetOrderStatus(c.Request.Context(), orderID)
 	if err != nil {
 		if err == repository.ErrOrderNotFound {
-			c.JSON(http.StatusNotFound, dto.ErrorResponse{
-				Error:   "Order not found",
-				Message: fmt.Sprintf("Order %s does not exist", orderID),
-			})
+			respondOrderNotFound(c, orderID)
 			return
 		}
 
@@ -180,129 +475,471 @@ func (h *OrderHandler) GetOrderStatus(c *gin.Context) {
 		return
 	}
 
+	if !canAccessOrder(c, order) {
+		respondOrderNotFound(c, orderID)
+		return
+	}
+
 	c.JSON(http.StatusOK, dto.OrderStatusResponse{
-		OrderID:       order.ID,
-		Status:        string(order.Status),
-		PaymentStatus: string(order.PaymentStatus),
-		UpdatedAt:     order.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		OrderID:        order.ID,
+		Status:         string(order.Status),
+		PaymentStatus:  string(order.PaymentStatus),
+		InvoiceURL:     order.InvoiceURL,
+		TrackingNumber: order.TrackingNumber,
+		UpdatedAt:      order.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	})
 }
 
-// enqueueOrderTasks enqueues all background tasks for order processing
-func (h *OrderHandler) enqueueOrderTasks(order *domain.Order) {
-	var enqueueOpts []asynq.Option
-	if h.taskRetention > 0 {
-		enqueueOpts = append(enqueueOpts, asynq.Retention(h.taskRetention))
+// respondOverloaded refuses new orders while the worker is far behind
+func (h *OrderHandler) respondOverloaded(c *gin.Context, verdict backpressure.Verdict) {
+	retryAfter := int(h.admission.RetryAfter.Round(time.Second).Seconds())
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(h.admission.RejectStatus, dto.ErrorResponse{
+		Error:   "Service overloaded",
+		Message: fmt.Sprintf("Background queues are over capacity (%s), retry in %ds", verdict.Reason, retryAfter),
+		Code:    "BACKPRESSURE",
+	})
+}
+
+// admit evaluates backpressure for a new order and records the decision
+func (h *OrderHandler) admit() backpressure.Verdict {
+	verdict := backpressure.Verdict{Decision: backpressure.DecisionAccept, Reason: "disabled"}
+	if h.admission != nil && h.admission.Monitor != nil {
+		verdict = h.admission.Monitor.Evaluate()
 	}
 
-	// 1. Payment Processing (Critical Queue - highest priority)
-	paymentTask, err := tasks.NewPaymentProcessTask(order.ID, order.TotalAmount, order.PaymentMethod)
-	if err != nil {
-		log.Printf("❌ Failed to create payment task: %v", err)
-	} else {
-		if _, err := h.asynqClient.Enqueue(paymentTask, enqueueOpts...); err != nil {
-			log.Printf("❌ Failed to enqueue payment task: %v", err)
-		} else {
-			log.Printf("📤 [Enqueued] Payment task for order: %s", order.ID)
-		}
+	metrics.AdmissionDecisions.WithLabelValues(string(verdict.Decision), verdict.Reason).Inc()
+	if verdict.Decision != backpressure.DecisionAccept {
+		log.Printf("🚦 [Backpressure] Decision: %s (reason: %s)", verdict.Decision, verdict.Reason)
 	}
+	return verdict
+}
 
-	// 2. Inventory Update (High Queue)
-	inventoryItems := make([]tasks.InventoryItem, len(order.Items))
-	for i, item := range order.Items {
-		inventoryItems[i] = tasks.InventoryItem{
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
+// Errors of replaceOrderTasks
+var (
+	// errTaskStarted means a task of an order is running or has run and may not run again
+	errTaskStarted = errors.New("task has already started")
+	// errTasksUnavailable means Redis could not be asked about or given the tasks
+	errTasksUnavailable = errors.New("scheduled tasks unavailable")
+)
+
+// replaceableTaskTypes lists the types of tasks.ReplaceableOrderTasks
+func replaceableTaskTypes() []string {
+	types := make([]string, len(tasks.ReplaceableOrderTasks))
+	for i, t := range tasks.ReplaceableOrderTasks {
+		types[i] = t.Type
+	}
+	return types
+}
+
+// withdrawReplaceableTasks deletes the payment, inventory, invoice and warehouse
+// tasks of an order by their deterministic IDs and returns the types it deleted. Tasks that
+// are missing (never enqueued, or expired) are skipped. It stops with
+// errTaskStarted when one is running or payment has left the schedule.
+func (h *OrderHandler) withdrawReplaceableTasks(orderID string) ([]string, error) {
+	var withdrawn []string
+	for _, t := range tasks.ReplaceableOrderTasks {
+		id := tasks.OrderTaskID(t.Type, orderID)
+		info, err := h.inspector.GetTaskInfo(t.Queue, id)
+		if errors.Is(err, asynq.ErrTaskNotFound) || errors.Is(err, asynq.ErrQueueNotFound) {
+			continue
 		}
+		if err != nil {
+			return withdrawn, fmt.Errorf("%s: %v: %w", id, err, errTasksUnavailable)
+		}
+
+		waiting := info.State == asynq.TaskStatePending || info.State == asynq.TaskStateScheduled
+		if info.State == asynq.TaskStateActive || (!t.Rerunnable && !waiting) {
+			return withdrawn, fmt.Errorf("%s is %s: %w", id, info.State, errTaskStarted)
+		}
+
+		if err := h.inspector.DeleteTask(t.Queue, id); err != nil && !errors.Is(err, asynq.ErrTaskNotFound) {
+			// Picked up by a worker since GetTaskInfo
+			return withdrawn, fmt.Errorf("%s: %v: %w", id, err, errTaskStarted)
+		}
+		log.Printf("🗑️  [Withdrawn] %s task of order %s (%s)", t.Type, orderID, info.State)
+		withdrawn = append(withdrawn, t.Type)
 	}
-	inventoryTask, err := tasks.NewInventoryUpdateTask(order.ID, inventoryItems)
-	if err != nil {
-		log.Printf("❌ Failed to create inventory task: %v", err)
-	} else {
-		if _, err := h.asynqClient.Enqueue(inventoryTask, enqueueOpts...); err != nil {
-			log.Printf("❌ Failed to enqueue inventory task: %v", err)
-		} else {
-			log.Printf("📤 [Enqueued] Inventory task for order: %s", order.ID)
+	return withdrawn, nil
+}
+
+// replaceOrderTasks is the service.TaskReplacer of order edits. It withdraws the
+// tasks carrying the old items and totals and enqueues them again for the edited
+// order. Any failure rolls the edit back, and the rollback puts the old tasks back.
+// With retention on, finished tasks keep their ID until they expire, so one
+// that must not run twice is still found.
+func (h *OrderHandler) replaceOrderTasks(ctx context.Context, before, after *domain.Order) error {
+	if h.asynqClient == nil {
+		return nil
+	}
+	var withdrawn, replaced []string
+	repository.OnRollback(ctx, func() {
+		for _, taskType := range replaced {
+			id := tasks.OrderTaskID(taskType, before.ID)
+			if err := h.inspector.DeleteTask(replaceableTaskQueue(taskType), id); err != nil && !errors.Is(err, asynq.ErrTaskNotFound) {
+				log.Printf("⚠️  [Rollback] Failed to withdraw %s: %v", id, err)
+			}
 		}
+		if _, err := h.enqueueReplaceableTasks(before, withdrawn); err != nil {
+			log.Printf("⚠️  [Rollback] Failed to restore tasks of order %s: %v", before.ID, err)
+		}
+	})
+
+	var err error
+	withdrawn, err = h.withdrawReplaceableTasks(before.ID)
+	if err != nil {
+		return err
 	}
 
-	// 3. Email Confirmation (Default Queue)
-	emailTask, err := tasks.NewEmailConfirmationTask(
-		order.ID,
-		order.CustomerEmail,
-		order.CustomerID, // Using customer ID as name for demo
-		order.TotalAmount,
-	)
+	// A conflict means another enqueue got in since the withdrawal; its task
+	// may carry a stale total, so the edit fails instead of skipping it
+	replaced, err = h.enqueueReplaceableTasks(after, replaceableTaskTypes())
 	if err != nil {
-		log.Printf("❌ Failed to create email task: %v", err)
-	} else {
-		if _, err := h.asynqClient.Enqueue(emailTask, enqueueOpts...); err != nil {
-			log.Printf("❌ Failed to enqueue email task: %v", err)
-		} else {
-			log.Printf("📤 [Enqueued] Email task for order: %s", order.ID)
+		if errors.Is(err, asynq.ErrTaskIDConflict) {
+			return err
 		}
+		return fmt.Errorf("%v: %w", err, errTasksUnavailable)
 	}
+	return nil
+}
 
-	// 4. Invoice Generation (Default Queue)
-	invoiceTask, err := tasks.NewInvoiceGenerateTask(
-		order.ID,
-		order.CustomerID,
-		order.CustomerEmail,
-		order.TotalAmount,
-	)
-	if err != nil {
-		log.Printf("❌ Failed to create invoice task: %v", err)
-	} else {
-		if _, err := h.asynqClient.Enqueue(invoiceTask, enqueueOpts...); err != nil {
-			log.Printf("❌ Failed to enqueue invoice task: %v", err)
-		} else {
-			log.Printf("📤 [Enqueued] Invoice task for order: %s", order.ID)
+// replaceableTaskQueue returns the queue of one of tasks.ReplaceableOrderTasks
+func replaceableTaskQueue(taskType string) string {
+	for _, t := range tasks.ReplaceableOrderTasks {
+		if t.Type == taskType {
+			return t.Queue
 		}
 	}
+	return ""
+}
 
-	// 5. Analytics Tracking (Low Queue)
-	analyticsTask, err := tasks.NewAnalyticsTrackTask(
-		order.ID,
-		order.CustomerID,
-		order.TotalAmount,
-		len(order.Items),
-		order.PaymentMethod,
-	)
-	if err != nil {
-		log.Printf("❌ Failed to create analytics task: %v", err)
-	} else {
-		if _, err := h.asynqClient.Enqueue(analyticsTask, enqueueOpts...); err != nil {
-			log.Printf("❌ Failed to enqueue analytics task: %v", err)
-		} else {
-			log.Printf("📤 [Enqueued] Analytics task for order: %s", order.ID)
+// enqueueReplaceableTasks enqueues the given tasks.ReplaceableOrderTasks of an order
+// and returns the types it enqueued. It tries every type; the error joins the
+// failures, including asynq.ErrTaskIDConflict for tasks that already exist.
+func (h *OrderHandler) enqueueReplaceableTasks(order *domain.Order, types []string) ([]string, error) {
+	ctx := context.Background()
+	enqueueOpts := h.enqueueOptions()
+	var enqueued []string
+	var errs []error
+	for _, taskType := range types {
+		var err error
+		switch taskType {
+		case tasks.TypePaymentProcess:
+			_, err = tasks.PaymentProcess.Enqueue(ctx, h.asynqClient, tasks.PaymentPayload{
+				OrderID:       order.ID,
+				Amount:        order.TotalAmount,
+				PaymentMethod: order.PaymentMethod,
+			}, enqueueOpts...)
+		case tasks.TypeInventoryUpdate:
+			_, err = tasks.InventoryUpdate.Enqueue(ctx, h.asynqClient, tasks.InventoryPayload{
+				OrderID: order.ID,
+				Items:   inventoryItems(order),
+			}, enqueueOpts...)
+		case tasks.TypeInvoiceGenerate:
+			_, err = tasks.InvoiceGenerate.Enqueue(ctx, h.asynqClient, tasks.InvoicePayload{
+				OrderID:       order.ID,
+				CustomerName:  order.CustomerName,
+				CustomerEmail: order.CustomerEmail,
+				TotalAmount:   order.TotalAmount,
+			}, enqueueOpts...)
+		case tasks.TypeWarehouseNotify:
+			_, err = tasks.WarehouseNotify.Enqueue(ctx, h.asynqClient, tasks.WarehousePayload{
+				OrderID:         order.ID,
+				CustomerName:    order.CustomerName,
+				ShippingAddress: shippingAddress(order),
+				ItemCount:       len(order.Items),
+				Priority:        order.ShippingPriority,
+			}, enqueueOpts...)
+		default:
+			continue
+		}
+
+		if err != nil {
+			if errors.Is(err, asynq.ErrTaskIDConflict) {
+				log.Printf("⏭️  [Enqueue] %s task for order %s already exists", taskType, order.ID)
+			} else {
+				log.Printf("❌ Failed to enqueue %s task: %v", taskType, err)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", taskType, err))
+			continue
 		}
+		log.Printf("📤 [Enqueued] %s task for order: %s", taskType, order.ID)
+		enqueued = append(enqueued, taskType)
 	}
+	return enqueued, errors.Join(errs...)
+}
 
-	// 6. Warehouse Notification (Low Queue)
-	shippingAddr := fmt.Sprintf("%s, %s, %s %s", 
+// shippingAddress formats the address for warehouse:notify
+func shippingAddress(order *domain.Order) string {
+	return fmt.Sprintf("%s, %s, %s %s",
 		order.ShippingAddress.Street,
 		order.ShippingAddress.City,
 		order.ShippingAddress.State,
 		order.ShippingAddress.PostalCode,
 	)
-	warehouseTask, err := tasks.NewWarehouseNotifyTask(
-		order.ID,
-		order.CustomerID,
-		shippingAddr,
-		len(order.Items),
-		"standard", // Default priority
-	)
-	if err != nil {
-		log.Printf("❌ Failed to create warehouse task: %v", err)
-	} else {
-		if _, err := h.asynqClient.Enqueue(warehouseTask, enqueueOpts...); err != nil {
-			log.Printf("❌ Failed to enqueue warehouse task: %v", err)
-		} else {
-			log.Printf("📤 [Enqueued] Warehouse task for order: %s", order.ID)
+}
+
+// inventoryItems lists the stock an order needs, for inventory:update
+func inventoryItems(order *domain.Order) []tasks.InventoryItem {
+	items := make([]tasks.InventoryItem, len(order.Items))
+	for i, item := range order.Items {
+		items[i] = tasks.InventoryItem{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
 		}
 	}
+	return items
+}
+
+// enqueueOptions are added to every task enqueued by the handler
+func (h *OrderHandler) enqueueOptions() []asynq.Option {
+	var enqueueOpts []asynq.Option
+	if h.taskRetention > 0 {
+		enqueueOpts = append(enqueueOpts, asynq.Retention(h.taskRetention))
+	}
+	return enqueueOpts
+}
 
+// enqueueCancellationTasks tells the customer about a cancelled order and, when
+// it was already paid, refunds its total. payment:refund sends the refund email
+// once the money is back. An order still being charged is refunded by its
+// payment task instead.
+func (h *OrderHandler) enqueueCancellationTasks(order *domain.Order) {
+	if h.asynqClient == nil {
+		return
+	}
+	ctx := context.Background()
+	enqueueOpts := h.enqueueOptions()
+
+	if _, err := tasks.EmailCancellation.Enqueue(ctx, h.asynqClient, tasks.EmailPayload{
+		OrderID:       order.ID,
+		CustomerEmail: order.CustomerEmail,
+		CustomerName:  order.CustomerName,
+		TotalAmount:   order.TotalAmount,
+	}, enqueueOpts...); err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		log.Printf("❌ Failed to enqueue %s task for order %s: %v", tasks.TypeEmailCancellation, order.ID, err)
+	}
+
+	if order.PaymentStatus != domain.PaymentStatusCompleted {
+		return
+	}
+	if _, err := tasks.PaymentRefund.Enqueue(ctx, h.asynqClient, tasks.PaymentPayload{
+		OrderID:       order.ID,
+		Amount:        order.TotalAmount,
+		PaymentMethod: order.PaymentMethod,
+	}, enqueueOpts...); err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		log.Printf("❌ Failed to enqueue %s task for order %s: %v", tasks.TypePaymentRefund, order.ID, err)
+	} else {
+		log.Printf("📤 [Enqueued] Refund task for order: %s", order.ID)
+	}
+}
+
+// enqueueOrderTasks enqueues all background tasks for order processing.
+// When shed is true, low-priority tasks (analytics) are skipped. Failures are
+// logged and returned joined; tasks that already exist are not failures.
+func (h *OrderHandler) enqueueOrderTasks(order *domain.Order, shed bool) error {
+	if h.asynqClient == nil {
+		return nil
+	}
+	ctx := context.Background()
+	enqueueOpts := h.enqueueOptions()
+	var errs []error
+
+	// 1-4. Payment (critical), inventory (high), invoice (default) and warehouse (low)
+	// carry the items, total and address, and are replaced when the order is edited.
+	// The reconciler picks up orders whose payment never ran.
+	if _, err := h.enqueueReplaceableTasks(order, replaceableTaskTypes()); err != nil {
+		errs = append(errs, withoutTaskConflicts(err))
+	}
+
+	// 5. Email Confirmation (Default Queue)
+	if _, err := tasks.EmailConfirmation.Enqueue(ctx, h.asynqClient, tasks.EmailPayload{
+		OrderID:       order.ID,
+		CustomerEmail: order.CustomerEmail,
+		CustomerName:  order.CustomerName,
+		TotalAmount:   order.TotalAmount,
+	}, enqueueOpts...); err != nil {
+		log.Printf("❌ Failed to enqueue email task: %v", err)
+		errs = append(errs, fmt.Errorf("%s: %w", tasks.TypeEmailConfirmation, err))
+	} else {
+		log.Printf("📤 [Enqueued] Email task for order: %s", order.ID)
+	}
+
+	// 6. Analytics Tracking (Low Queue) - first to go under load
+	if shed {
+		metrics.TasksShed.WithLabelValues(tasks.TypeAnalyticsTrack).Inc()
+		log.Printf("🚦 [Shed] Analytics task skipped for order: %s", order.ID)
+	} else if _, err := tasks.AnalyticsTrack.Enqueue(ctx, h.asynqClient, tasks.AnalyticsPayload{
+		OrderID:       order.ID,
+		CustomerID:    order.CustomerID,
+		TotalAmount:   order.TotalAmount,
+		ItemCount:     len(order.Items),
+		PaymentMethod: order.PaymentMethod,
+		CreatedAt:     time.Now().Format(time.RFC3339),
+	}, enqueueOpts...); err != nil {
+		log.Printf("❌ Failed to enqueue analytics task: %v", err)
+		errs = append(errs, fmt.Errorf("%s: %w", tasks.TypeAnalyticsTrack, err))
+	} else {
+		log.Printf("📤 [Enqueued] Analytics task for order: %s", order.ID)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
 	log.Printf("✅ All background tasks enqueued for order: %s", order.ID)
+	return nil
+}
+
+// withoutTaskConflicts drops asynq.ErrTaskIDConflict from the joined errors of
+// enqueueReplaceableTasks, and returns nil when nothing else is left
+func withoutTaskConflicts(err error) error {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		if errors.Is(err, asynq.ErrTaskIDConflict) {
+			return nil
+		}
+		return err
+	}
+	var errs []error
+	for _, e := range joined.Unwrap() {
+		if !errors.Is(e, asynq.ErrTaskIDConflict) {
+			errs = append(errs, e)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// enqueueBatchTasks enqueues the tasks of a batch, EnqueueConcurrency orders at a
+// time, and returns the error of each order (nil when all its tasks were
+// enqueued) in the order of orders. asynq has no pipelined or batch enqueue
+// (every Enqueue runs its own Redis script), so the round trips are overlapped
+// on the client's connection pool instead.
+func (h *OrderHandler) enqueueBatchTasks(orders []*domain.Order, shed bool) []error {
+	errs := make([]error, len(orders))
+	if len(orders) == 0 || h.asynqClient == nil {
+		return errs
+	}
+	start := time.Now()
+
+	slots := make(chan struct{}, max(h.batch.EnqueueConcurrency, 1))
+	var wg sync.WaitGroup
+	for i, order := range orders {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			errs[i] = h.enqueueTasks(order, shed)
+		}()
+	}
+	wg.Wait()
+
+	log.Printf("📋 Background tasks enqueued for %d batch order(s) in %s",
+		len(orders), time.Since(start).Round(time.Millisecond))
+	return errs
+}
+
+// principalFrom returns the authenticated caller, or nil when auth is disabled
+func principalFrom(c *gin.Context) *auth.Principal {
+	p, _ := auth.FromContext(c.Request.Context())
+	return p
+}
+
+// canAccessOrder reports whether the caller may see the order
+func canAccessOrder(c *gin.Context, order *domain.Order) bool {
+	p := principalFrom(c)
+	return p == nil || p.CanAccessCustomer(order.CustomerID)
+}
+
+// respondOrderNotFound is also used for orders owned by someone else,
+// so their existence is not leaked
+func respondOrderNotFound(c *gin.Context, orderID string) {
+	c.JSON(http.StatusNotFound, dto.ErrorResponse{
+		Error:   "Order not found",
+		Message: fmt.Sprintf("Order %s does not exist", orderID),
+	})
+}
+
+// respondOrderNotEditable is returned once payment has started
+func respondOrderNotEditable(c *gin.Context, message string) {
+	c.JSON(http.StatusConflict, dto.ErrorResponse{
+		Error:   "Order cannot be edited",
+		Message: message,
+		Code:    "ORDER_NOT_EDITABLE",
+	})
+}
+
+func respondForbidden(c *gin.Context, message string) {
+	c.JSON(http.StatusForbidden, dto.ErrorResponse{
+		Error:   "Forbidden",
+		Message: message,
+		Code:    "FORBIDDEN",
+	})
+}
+
+// checkBatchOrder validates one order of a batch like CreateOrder binding would
+func checkBatchOrder(c *gin.Context, order *dto.CreateOrderRequest) *dto.OrderBatchError {
+	if err := binding.Validator.ValidateStruct(order); err != nil {
+		return &dto.OrderBatchError{Error: "Invalid request", Message: err.Error(), Code: "INVALID_REQUEST"}
+	}
+	if p := principalFrom(c); p != nil && !p.CanAccessCustomer(order.CustomerID) {
+		return &dto.OrderBatchError{Error: "Forbidden", Message: "Cannot create orders for another customer", Code: "FORBIDDEN"}
+	}
+	return nil
+}
+
+// batchOrderError describes why the service refused one order of a batch,
+// with the same codes CreateOrder responds with
+func batchOrderError(err error) *dto.OrderBatchError {
+	var invalid *service.ErrOrderValidation
+	if errors.As(err, &invalid) {
+		metrics.OrdersRejected.WithLabelValues(invalid.Items[0].Code).Inc()
+		items := make([]dto.OrderItemError, len(invalid.Items))
+		for i, item := range invalid.Items {
+			items[i] = dto.OrderItemError{
+				Index:     item.Index,
+				ProductID: item.ProductID,
+				Code:      item.Code,
+				Message:   item.Message,
+			}
+		}
+		return &dto.OrderBatchError{Error: "Order cannot be fulfilled", Message: invalid.Error(), Code: "ORDER_VALIDATION_FAILED", Items: items}
+	}
+	var badCoupon *service.ErrInvalidCoupon
+	if errors.As(err, &badCoupon) {
+		metrics.OrdersRejected.WithLabelValues(badCoupon.Code).Inc()
+		return &dto.OrderBatchError{Error: "Coupon cannot be applied", Message: badCoupon.Message, Code: badCoupon.Code}
+	}
+	switch {
+	case errors.Is(err, domain.ErrUnsupportedCurrency):
+		return &dto.OrderBatchError{Error: "Invalid request", Message: err.Error(), Code: "INVALID_REQUEST"}
+	case errors.Is(err, service.ErrBatchRolledBack):
+		return &dto.OrderBatchError{Error: "Order not created", Message: err.Error(), Code: "BATCH_ROLLED_BACK"}
+	}
+
+	log.Printf("Failed to create order: %v", err)
+	return &dto.OrderBatchError{Error: "Failed to create order", Message: err.Error(), Code: "INTERNAL_ERROR"}
+}
+
+// respondOrderValidation returns 422 with one entry per refused item
+func respondOrderValidation(c *gin.Context, invalid *service.ErrOrderValidation) {
+	items := make([]dto.OrderItemError, len(invalid.Items))
+	for i, item := range invalid.Items {
+		items[i] = dto.OrderItemError{
+			Index:     item.Index,
+			ProductID: item.ProductID,
+			Code:      item.Code,
+			Message:   item.Message,
+		}
+	}
+
+	metrics.OrdersRejected.WithLabelValues(invalid.Items[0].Code).Inc()
+	c.JSON(http.StatusUnprocessableEntity, dto.OrderValidationErrorResponse{
+		Error:   "Order cannot be fulfilled",
+		Message: invalid.Error(),
+		Code:    "ORDER_VALIDATION_FAILED",
+		Items:   items,
+	})
 }
 
 // Helper function to convert domain.Order to dto.OrderResponse
@@ -319,19 +956,30 @@ func toOrderResponse(order *domain.Order) dto.OrderResponse {
 	}
 
 	return dto.OrderResponse{
-		ID:              order.ID,
-		CustomerID:      order.CustomerID,
-		CustomerEmail:   order.CustomerEmail,
-		Items:           items,
-		TotalAmount:     order.TotalAmount,
-		ShippingAddress: order.ShippingAddress,
-		Status:          string(order.Status),
-		PaymentStatus:   string(order.PaymentStatus),
-		PaymentMethod:   order.PaymentMethod,
-		InvoiceURL:      order.InvoiceURL,
-		TrackingNumber:  order.TrackingNumber,
-		Notes:           order.Notes,
-		CreatedAt:       order.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:       order.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		ID:            order.ID,
+		CustomerID:    order.CustomerID,
+		CustomerEmail: order.CustomerEmail,
+		CustomerName:  order.CustomerName,
+		Items:         items,
+		TotalAmount:   order.TotalAmount,
+		Pricing: dto.PriceBreakdownResponse{
+			Subtotal:   order.Pricing.Subtotal,
+			Discount:   order.Pricing.Discount,
+			CouponCode: order.Pricing.CouponCode,
+			Shipping:   order.Pricing.Shipping,
+			Tax:        order.Pricing.Tax,
+			TaxRate:    order.Pricing.TaxRate.String(),
+			Total:      order.Pricing.Total,
+		},
+		ShippingAddress:  order.ShippingAddress,
+		Status:           string(order.Status),
+		PaymentStatus:    string(order.PaymentStatus),
+		PaymentMethod:    order.PaymentMethod,
+		ShippingPriority: order.ShippingPriority,
+		InvoiceURL:       order.InvoiceURL,
+		TrackingNumber:   order.TrackingNumber,
+		Notes:            order.Notes,
+		CreatedAt:        order.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:        order.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
 }
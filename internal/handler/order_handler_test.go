@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/dto"
+	"github.com/lppduy/go-asynq-loadtest/internal/service"
+)
+
+// batchOrderService creates every order of a batch it is given
+type batchOrderService struct {
+	service.OrderService
+}
+
+func (batchOrderService) CreateOrders(_ context.Context, reqs []dto.CreateOrderRequest, _ string) ([]service.BatchOrderResult, error) {
+	results := make([]service.BatchOrderResult, len(reqs))
+	for i, req := range reqs {
+		results[i].Order = &domain.Order{
+			ID:            fmt.Sprintf("ORD-%08d", i),
+			CustomerID:    req.CustomerID,
+			CustomerEmail: req.CustomerEmail,
+			Status:        domain.OrderStatusPending,
+		}
+	}
+	return results, nil
+}
+
+// Tasks that fail to enqueue are reported on their own order; the other orders
+// of the batch, and the batch itself, still succeed
+func TestCreateOrderBatchReportsTaskFailuresPerOrder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	client := asynq.NewClient(asynq.RedisClientOpt{Addr: "localhost:6379"})
+	t.Cleanup(func() { client.Close() })
+
+	h := NewOrderHandler(batchOrderService{}, client, nil, 0, nil, BatchLimits{MaxOrders: 10, EnqueueConcurrency: 2})
+	var running, peak atomic.Int32
+	h.enqueueTasks = func(order *domain.Order, _ bool) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		if order.ID == "ORD-00000002" || order.ID == "ORD-00000004" {
+			return errors.New("payment:process: redis: connection refused")
+		}
+		return nil
+	}
+
+	order := dto.CreateOrderRequest{
+		CustomerID:      "cust-42",
+		CustomerEmail:   "cust-42@example.com",
+		Items:           []dto.CreateOrderItemRequest{{ProductID: "prod-1", Quantity: 1}},
+		ShippingAddress: domain.Address{Street: "1 Main St", City: "Springfield", State: "IL", PostalCode: "62701", Country: "US"},
+		PaymentMethod:   "credit_card",
+	}
+	body, err := json.Marshal(dto.CreateOrderBatchRequest{
+		Mode:   service.BatchModePartial,
+		Orders: []dto.CreateOrderRequest{order, order, order, order, order},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.POST("/orders/batch", h.CreateOrderBatch)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders/batch", bytes.NewReader(body)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+	var resp dto.OrderBatchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Created != 5 || len(resp.Results) != 5 {
+		t.Fatalf("created %d with %d results, want 5 and 5", resp.Created, len(resp.Results))
+	}
+	for i, result := range resp.Results {
+		failed := i == 2 || i == 4
+		if result.Status != "created" || (result.TasksError != "") != failed {
+			t.Errorf("result %d: status %s, tasks_error %q; want created, with a tasks error: %t",
+				i, result.Status, result.TasksError, failed)
+		}
+	}
+	if p := peak.Load(); p > 2 {
+		t.Errorf("%d orders enqueued at the same time, want at most EnqueueConcurrency (2)", p)
+	}
+}
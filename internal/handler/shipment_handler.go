@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lppduy/go-asynq-loadtest/internal/carrier"
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/dto"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+	"github.com/lppduy/go-asynq-loadtest/internal/service"
+	"github.com/lppduy/go-asynq-loadtest/pkg/webhooksig"
+)
+
+// maxCarrierWebhookBody caps the size of carrier callbacks
+const maxCarrierWebhookBody = 1 << 20
+
+// ShipmentHandler serves the shipments of orders and receives carrier tracking callbacks
+type ShipmentHandler struct {
+	orders    service.OrderService
+	shipments service.ShipmentService
+}
+
+// NewShipmentHandler creates a new shipment handler
+func NewShipmentHandler(orders service.OrderService, shipments service.ShipmentService) *ShipmentHandler {
+	return &ShipmentHandler{orders: orders, shipments: shipments}
+}
+
+// ListShipments handles GET /api/v1/orders/:id/shipments
+func (h *ShipmentHandler) ListShipments(c *gin.Context) {
+	orderID := c.Param("id")
+	ctx := c.Request.Context()
+
+	order, err := h.orders.GetOrder(ctx, orderID)
+	if err == nil && !canAccessOrder(c, order) {
+		err = repository.ErrOrderNotFound
+	}
+	if err != nil {
+		if err == repository.ErrOrderNotFound {
+			respondOrderNotFound(c, orderID)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "Failed to get order",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	shipments, err := h.shipments.ListShipments(ctx, orderID)
+	if err != nil {
+		log.Printf("Failed to list shipments of order %s: %v", orderID, err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "Failed to list shipments",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	resp := dto.ShipmentListResponse{
+		OrderID:   orderID,
+		Total:     len(shipments),
+		Shipments: make([]dto.ShipmentResponse, len(shipments)),
+	}
+	for i, s := range shipments {
+		resp.Shipments[i] = toShipmentResponse(s)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// CarrierWebhook handles POST /api/v1/carriers/:carrier/webhook
+// Carriers authenticate with a signature instead of API credentials. Anything
+// but a 2xx makes them resend the callback later.
+func (h *ShipmentHandler) CarrierWebhook(c *gin.Context) {
+	carrierName := c.Param("carrier")
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxCarrierWebhookBody))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, dto.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	result, err := h.shipments.HandleCarrierWebhook(c.Request.Context(), carrierName, c.Request.Header, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUnknownCarrier):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Error:   "Unknown carrier",
+				Message: fmt.Sprintf("Carrier %s is not configured", carrierName),
+			})
+		case errors.Is(err, webhooksig.ErrInvalidTimestamp),
+			errors.Is(err, webhooksig.ErrExpired),
+			errors.Is(err, webhooksig.ErrInvalidSignature):
+			log.Printf("⚠️  [Carrier] Rejected %s callback: %v", carrierName, err)
+			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Error:   "Invalid signature",
+				Message: err.Error(),
+				Code:    "INVALID_SIGNATURE",
+			})
+		case errors.Is(err, carrier.ErrInvalidWebhook),
+			errors.Is(err, domain.ErrInvalidShipmentStatus):
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Error:   "Invalid request",
+				Message: err.Error(),
+			})
+		default:
+			log.Printf("Failed to apply %s callback: %v", carrierName, err)
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Error:   "Failed to apply tracking events",
+				Message: err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.CarrierWebhookResponse{
+		Received: result.Received,
+		Applied:  result.Applied,
+		Unknown:  result.Unknown,
+	})
+}
+
+// Helper function to convert domain.Shipment to dto.ShipmentResponse
+func toShipmentResponse(s *domain.Shipment) dto.ShipmentResponse {
+	events := make([]dto.ShipmentEventResponse, len(s.Events))
+	for i, e := range s.Events {
+		events[i] = dto.ShipmentEventResponse{
+			Status:      string(e.Status),
+			Location:    e.Location,
+			Description: e.Description,
+			OccurredAt:  e.OccurredAt.Format(time.RFC3339),
+		}
+	}
+
+	return dto.ShipmentResponse{
+		ID:                s.ID,
+		Parcel:            s.Parcel,
+		Carrier:           s.Carrier,
+		ServiceLevel:      s.ServiceLevel,
+		TrackingNumber:    s.TrackingNumber,
+		LabelURL:          s.LabelURL,
+		Status:            string(s.Status),
+		EstimatedDelivery: s.EstimatedDelivery.Format(time.RFC3339),
+		Items:             s.Items,
+		Events:            events,
+		CreatedAt:         s.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:         s.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lppduy/go-asynq-loadtest/internal/dto"
+	"github.com/lppduy/go-asynq-loadtest/internal/events"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+	"github.com/lppduy/go-asynq-loadtest/internal/service"
+)
+
+// StreamHandler serves live order updates as Server-Sent Events
+type StreamHandler struct {
+	service     service.OrderService
+	hub         *events.Hub
+	heartbeat   time.Duration
+	maxDuration time.Duration
+}
+
+// NewStreamHandler creates a new stream handler
+func NewStreamHandler(service service.OrderService, hub *events.Hub, heartbeat, maxDuration time.Duration) *StreamHandler {
+	return &StreamHandler{
+		service:     service,
+		hub:         hub,
+		heartbeat:   heartbeat,
+		maxDuration: maxDuration,
+	}
+}
+
+// StreamOrder handles GET /api/v1/orders/:id/stream
+//
+// Events:
+//   - status: order status, payment status, invoice URL or tracking number changed
+//   - end:    order reached a terminal state, stream closes
+//   - timeout: stream reached its maximum duration, client should reconnect
+func (h *StreamHandler) StreamOrder(c *gin.Context) {
+	orderID := c.Param("id")
+	ctx := c.Request.Context()
+
+	// Subscribe before reading the current state so no update is missed in between
+	updates, unsubscribe, err := h.hub.Subscribe(ctx, orderID)
+	if err != nil {
+		log.Printf("Failed to subscribe to order %s: %v", orderID, err)
+		c.JSON(http.StatusServiceUnavailable, dto.ErrorResponse{
+			Error:   "Failed to open stream",
+			Message: err.Error(),
+		})
+		return
+	}
+	defer unsubscribe()
+
+	order, err := h.service.GetOrderStatus(ctx, orderID)
+	if err != nil {
+		if err == repository.ErrOrderNotFound {
+			respondOrderNotFound(c, orderID)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "Failed to get order status",
+			Message: err.Error(),
+		})
+		return
+	}
+	if !canAccessOrder(c, order) {
+		respondOrderNotFound(c, orderID)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+	c.Status(http.StatusOK)
+
+	last := events.NewOrderUpdate(order)
+	writeEvent(c, "status", last)
+	if last.IsTerminal() {
+		writeEvent(c, "end", last)
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	deadline := time.NewTimer(h.maxDuration)
+	defer deadline.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Client went away
+			return
+		case <-deadline.C:
+			writeEvent(c, "timeout", last)
+			return
+		case <-heartbeat.C:
+			// SSE comment keeps proxies from closing an idle connection
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		case update := <-updates:
+			if update.SameState(last) {
+				continue
+			}
+			last = update
+			writeEvent(c, "status", last)
+			if last.IsTerminal() {
+				writeEvent(c, "end", last)
+				return
+			}
+		}
+	}
+}
+
+// writeEvent writes one SSE event and flushes it to the client
+func writeEvent(c *gin.Context, event string, update events.OrderUpdate) {
+	data, err := json.Marshal(dto.OrderStatusResponse{
+		OrderID:        update.OrderID,
+		Status:         update.Status,
+		PaymentStatus:  update.PaymentStatus,
+		InvoiceURL:     update.InvoiceURL,
+		TrackingNumber: update.TrackingNumber,
+		UpdatedAt:      update.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	})
+	if err != nil {
+		log.Printf("Failed to marshal stream event: %v", err)
+		return
+	}
+
+	fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", update.UpdatedAt.UnixMilli(), event, data)
+	c.Writer.Flush()
+}
@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/dto"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+	"github.com/lppduy/go-asynq-loadtest/internal/service"
+)
+
+// WebhookHandler handles webhook subscription HTTP requests
+type WebhookHandler struct {
+	service service.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(service service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// CreateWebhook handles POST /api/v1/webhooks
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req dto.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	sub, err := h.service.CreateSubscription(c.Request.Context(), req)
+	if err != nil {
+		var invalid *service.ErrInvalidWebhook
+		if errors.As(err, &invalid) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Error:   "Invalid request",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		log.Printf("Failed to create webhook: %v", err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "Failed to create webhook",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	log.Printf("🔔 Webhook created: %s -> %s %v", sub.ID, sub.URL, sub.EventTypes)
+
+	// The secret is only shown once
+	resp := toWebhookResponse(sub)
+	resp.Secret = sub.Secret
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ListWebhooks handles GET /api/v1/webhooks
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	subs, err := h.service.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		log.Printf("Failed to list webhooks: %v", err)
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "Failed to list webhooks",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	resp := dto.WebhookListResponse{Total: len(subs), Webhooks: make([]dto.WebhookResponse, len(subs))}
+	for i, sub := range subs {
+		resp.Webhooks[i] = toWebhookResponse(sub)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetWebhook handles GET /api/v1/webhooks/:id
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	id := c.Param("id")
+
+	sub, err := h.service.GetSubscription(c.Request.Context(), id)
+	if err != nil {
+		respondWebhookError(c, id, "Failed to get webhook", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toWebhookResponse(sub))
+}
+
+// DeleteWebhook handles DELETE /api/v1/webhooks/:id
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.service.DeleteSubscription(c.Request.Context(), id); err != nil {
+		respondWebhookError(c, id, "Failed to delete webhook", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "Webhook deleted successfully"})
+}
+
+// ListDeliveries handles GET /api/v1/webhooks/:id/deliveries?limit=
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	id := c.Param("id")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "Invalid request",
+			Message: "limit must be between 1 and 500",
+		})
+		return
+	}
+
+	deliveries, err := h.service.ListDeliveries(c.Request.Context(), id, limit)
+	if err != nil {
+		respondWebhookError(c, id, "Failed to list deliveries", err)
+		return
+	}
+
+	resp := dto.WebhookDeliveryListResponse{
+		Total:      len(deliveries),
+		Deliveries: make([]dto.WebhookDeliveryResponse, len(deliveries)),
+	}
+	for i, d := range deliveries {
+		resp.Deliveries[i] = dto.WebhookDeliveryResponse{
+			EventID:    d.EventID,
+			EventType:  d.EventType,
+			Attempt:    d.Attempt,
+			StatusCode: d.StatusCode,
+			Success:    d.Success,
+			Error:      d.Error,
+			DurationMs: d.DurationMs,
+			CreatedAt:  d.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// TestWebhook handles POST /api/v1/webhooks/:id/test
+func (h *WebhookHandler) TestWebhook(c *gin.Context) {
+	id := c.Param("id")
+
+	event, err := h.service.SendTestEvent(c.Request.Context(), id)
+	if err != nil {
+		respondWebhookError(c, id, "Failed to send test event", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.WebhookTestResponse{
+		EventID:   event.ID,
+		EventType: event.Type,
+	})
+}
+
+// respondWebhookError maps service errors to HTTP responses
+func respondWebhookError(c *gin.Context, id, message string, err error) {
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "Webhook not found",
+			Message: fmt.Sprintf("Webhook %s does not exist", id),
+		})
+		return
+	}
+
+	log.Printf("%s: %v", message, err)
+	c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+		Error:   message,
+		Message: err.Error(),
+	})
+}
+
+// Helper function to convert domain.WebhookSubscription to dto.WebhookResponse
+func toWebhookResponse(sub *domain.WebhookSubscription) dto.WebhookResponse {
+	return dto.WebhookResponse{
+		ID:         sub.ID,
+		URL:        sub.URL,
+		EventTypes: sub.EventTypes,
+		Active:     sub.Active,
+		CreatedAt:  sub.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
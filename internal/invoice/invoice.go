@@ -0,0 +1,154 @@
+package invoice
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+)
+
+// ContentType of rendered invoices
+const ContentType = "application/pdf"
+
+// Key is where the invoice of an order is stored in the blob store
+func Key(orderID string) string {
+	return "invoices/" + orderID + ".pdf"
+}
+
+// URL is the API path that serves the invoice of an order, stored as its invoice_url
+func URL(orderID string) string {
+	return "/api/v1/orders/" + orderID + "/invoice"
+}
+
+// Layout in points: margins, and the right edges of the table columns
+const (
+	margin    = 50.0
+	colQty    = 360.0
+	colPrice  = 455.0
+	colAmount = pageWidth - margin
+	rowHeight = 18.0
+	bottom    = margin + 40 // Leaves room for the page number
+)
+
+// Render draws the invoice of an order: seller and customer, one line per item,
+// then the price breakdown. Long orders continue on further pages.
+func Render(order *domain.Order, issued time.Time) ([]byte, error) {
+	doc := &document{title: "Invoice " + order.ID, created: issued}
+	p := doc.newPage()
+
+	y := pageHeight - margin - 10
+	p.text(margin, y, 22, bold, "INVOICE")
+	p.textRight(colAmount, y, 10, bold, "Go Asynq Shop")
+	p.textRight(colAmount, y-14, 9, regular, "orders@example.com")
+
+	y -= 50
+	details := [][2]string{
+		{"Invoice number", "INV-" + strings.TrimPrefix(order.ID, "ORD-")},
+		{"Order", order.ID},
+		{"Order date", order.CreatedAt.Format("Jan 2, 2006")},
+		{"Issued", issued.Format("Jan 2, 2006")},
+		{"Payment", fmt.Sprintf("%s (%s)", order.PaymentMethod, order.PaymentStatus)},
+	}
+	for i, d := range details {
+		p.text(colQty-40, y-float64(i)*14, 9, bold, d[0])
+		p.textRight(colAmount, y-float64(i)*14, 9, regular, d[1])
+	}
+
+	p.text(margin, y, 9, bold, "BILL TO")
+	for i, l := range billTo(order) {
+		p.text(margin, y-14*float64(i+1), 10, regular, truncate(l, colQty-margin-60, 10, regular))
+	}
+
+	y -= 14*float64(len(details)) + 30
+	y = tableHeader(p, y)
+	for _, item := range order.Items {
+		if y < bottom {
+			p = doc.newPage()
+			y = tableHeader(p, pageHeight-margin-10)
+		}
+		p.text(margin, y, 10, regular, truncate(item.ProductName, colQty-margin-50, 10, regular))
+		p.textRight(colQty, y, 10, regular, fmt.Sprint(item.Quantity))
+		p.textRight(colPrice, y, 10, regular, item.UnitPrice.String())
+		p.textRight(colAmount, y, 10, regular, item.Subtotal.String())
+		p.line(margin, y-6, colAmount, y-6, 0.5, 0.85)
+		y -= rowHeight
+	}
+
+	totals := totalLines(order)
+	if y-rowHeight*float64(len(totals)+1) < bottom {
+		p = doc.newPage()
+		y = pageHeight - margin - 10
+	}
+	y -= 6
+	for i, t := range totals {
+		f := regular
+		if i == len(totals)-1 {
+			f = bold
+			p.line(colQty-40, y+12, colAmount, y+12, 1, 0)
+		}
+		p.text(colQty-40, y, 10, f, t[0])
+		p.textRight(colAmount, y, 10, f, t[1])
+		y -= rowHeight
+	}
+
+	p.text(margin, y-20, 10, regular, "Thank you for your order.")
+
+	for i, content := range doc.pages {
+		footer := page{buf: content}
+		footer.textRight(colAmount, margin, 8, regular, fmt.Sprintf("%s - page %d of %d", order.ID, i+1, len(doc.pages)))
+	}
+	return doc.bytes()
+}
+
+// tableHeader draws the column titles at y and returns the baseline of the first row
+func tableHeader(p page, y float64) float64 {
+	p.text(margin, y, 9, bold, "ITEM")
+	p.textRight(colQty, y, 9, bold, "QTY")
+	p.textRight(colPrice, y, 9, bold, "UNIT PRICE")
+	p.textRight(colAmount, y, 9, bold, "AMOUNT")
+	p.line(margin, y-6, colAmount, y-6, 1, 0)
+	return y - rowHeight - 2
+}
+
+// billTo returns the customer and shipping address lines
+func billTo(order *domain.Order) []string {
+	lines := []string{}
+	if order.CustomerName != "" {
+		lines = append(lines, order.CustomerName)
+	}
+	lines = append(lines, order.CustomerEmail)
+
+	a := order.ShippingAddress
+	city := strings.TrimSpace(a.State + " " + a.PostalCode)
+	if a.City != "" && city != "" {
+		city = a.City + ", " + city
+	} else if a.City != "" {
+		city = a.City
+	}
+	for _, l := range []string{a.Street, city, a.Country} {
+		if l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+// totalLines returns the price breakdown, total last
+func totalLines(order *domain.Order) [][2]string {
+	pr := order.Pricing
+	lines := [][2]string{{"Subtotal", pr.Subtotal.String()}}
+	if !pr.Discount.IsZero() {
+		label := "Discount"
+		if pr.CouponCode != "" {
+			label += " (" + pr.CouponCode + ")"
+		}
+		lines = append(lines, [2]string{label, "-" + pr.Discount.String()})
+	}
+	lines = append(lines,
+		[2]string{"Shipping (" + order.ShippingPriority + ")", pr.Shipping.String()},
+		[2]string{"Tax (" + pr.TaxRate.String() + "%)", pr.Tax.String()},
+		[2]string{"Total", order.TotalAmount.String()},
+	)
+	return lines
+}
@@ -0,0 +1,178 @@
+package invoice
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A4 in PDF points (1/72 inch), origin at the bottom left
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+)
+
+// font is one of the standard Type 1 fonts every PDF reader has, so nothing is embedded
+type font int
+
+const (
+	regular font = iota // Helvetica
+	bold                // Helvetica-Bold
+)
+
+// document is a minimal PDF 1.4 writer: pages of text and lines, no images
+type document struct {
+	title   string
+	created time.Time
+	pages   []*bytes.Buffer // Content streams
+}
+
+// page is the content stream pages are drawn into
+type page struct {
+	buf *bytes.Buffer
+}
+
+// newPage appends an empty page
+func (d *document) newPage() page {
+	buf := &bytes.Buffer{}
+	d.pages = append(d.pages, buf)
+	return page{buf: buf}
+}
+
+// text draws s with its baseline starting at x, y
+func (p page) text(x, y, size float64, f font, s string) {
+	fmt.Fprintf(p.buf, "BT /F%d %.1f Tf %.2f %.2f Td (%s) Tj ET\n", f+1, size, x, y, escape(encode(s)))
+}
+
+// textRight draws s so that it ends at x
+func (p page) textRight(x, y, size float64, f font, s string) {
+	p.text(x-textWidth(s, size, f), y, size, f, s)
+}
+
+// line draws a line of the given width in gray (0 = black, 1 = white)
+func (p page) line(x1, y1, x2, y2, width, gray float64) {
+	fmt.Fprintf(p.buf, "%.2f G %.2f w %.2f %.2f m %.2f %.2f l S\n", gray, width, x1, y1, x2, y2)
+}
+
+// bytes writes the document: catalog, page tree, fonts, then every page with
+// its content stream, the cross-reference table and the trailer
+func (d *document) bytes() ([]byte, error) {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) int {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+		return len(offsets)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Page objects come in pairs after the 4 fixed objects and the info dictionary
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	info := object(fmt.Sprintf("<< /Title (%s) /Producer (go-asynq-loadtest) /CreationDate (D:%s) >>",
+		escape(encode(d.title)), d.created.UTC().Format("20060102150405Z")))
+
+	for i, content := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, 7+2*i))
+
+		var stream bytes.Buffer
+		zw := zlib.NewWriter(&stream)
+		if _, err := zw.Write(content.Bytes()); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", stream.Len(), stream.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, info, xref)
+	return out.Bytes(), nil
+}
+
+// winAnsi maps the characters of WinAnsiEncoding outside Latin-1
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// encode converts s to WinAnsiEncoding; characters it lacks become '?'
+func encode(s string) string {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r < 0x80 || (r >= 0xa0 && r <= 0xff):
+			b = append(b, byte(r))
+		case winAnsi[r] != 0:
+			b = append(b, winAnsi[r])
+		default:
+			b = append(b, '?')
+		}
+	}
+	return string(b)
+}
+
+// escape makes an encoded string safe inside a PDF literal string
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`, "\r", `\r`, "\n", `\n`).Replace(s)
+}
+
+// Glyph widths of ' ' to '~' in 1/1000 of the font size, from the Helvetica AFM files
+var widths = [2][95]int{
+	regular: {
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	},
+	bold: {
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	},
+}
+
+// textWidth measures s in points; characters beyond ASCII count as an average glyph
+func textWidth(s string, size float64, f font) float64 {
+	total := 0
+	for _, c := range []byte(encode(s)) {
+		if c >= ' ' && c <= '~' {
+			total += widths[f][c-' ']
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// truncate shortens s with an ellipsis until it fits in max points
+func truncate(s string, max, size float64, f font) string {
+	if textWidth(s, size, f) <= max {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && textWidth(string(r)+"…", size, f) > max {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "…"
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lppduy/go-asynq-loadtest/internal/auth"
+	"github.com/lppduy/go-asynq-loadtest/internal/dto"
+)
+
+// Authenticate requires a valid API key or JWT and stores the principal
+// in the request context (see auth.FromContext).
+func Authenticate(authn *auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := authn.Authenticate(c.Request)
+		if err != nil {
+			message := "Provide X-API-Key or Authorization: Bearer <token>"
+			if !errors.Is(err, auth.ErrMissingCredentials) {
+				message = err.Error()
+			}
+			c.Header("WWW-Authenticate", `Bearer realm="api"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Error:   "Unauthorized",
+				Message: message,
+				Code:    "UNAUTHORIZED",
+			})
+			return
+		}
+
+		c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// RequireScope rejects authenticated callers lacking scope.
+// Must run after Authenticate.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := auth.FromContext(c.Request.Context())
+		if !ok || !principal.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, dto.ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Missing required scope: " + scope,
+				Code:    "FORBIDDEN",
+			})
+			return
+		}
+		c.Next()
+	}
+}
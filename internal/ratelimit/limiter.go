@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit describes a token bucket: Rate tokens are added per second up to Burst
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Enabled reports whether the limit should be enforced
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Result is the outcome of taking one token from a bucket
+type Result struct {
+	Allowed    bool
+	Limit      int           // Bucket capacity (burst)
+	Remaining  int           // Whole tokens left after this request
+	Reset      time.Duration // Time until the bucket is full again
+	RetryAfter time.Duration // Time until the next token is available (denied requests only)
+}
+
+// Limiter takes tokens from named buckets
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// take applies the token bucket algorithm shared by all backends.
+// It returns the new token count and the result for the caller.
+func take(tokens float64, elapsed time.Duration, limit Limit) (float64, Result) {
+	burst := float64(limit.Burst)
+	tokens = math.Min(burst, tokens+elapsed.Seconds()*limit.Rate)
+
+	res := Result{Limit: limit.Burst}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = secondsToDuration((1 - tokens) / limit.Rate)
+	}
+
+	res.Remaining = int(math.Floor(tokens))
+	res.Reset = secondsToDuration((burst - tokens) / limit.Rate)
+	return tokens, res
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
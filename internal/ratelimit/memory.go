@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+	fullAt   time.Time // When the bucket has refilled completely
+}
+
+// MemoryLimiter keeps buckets in process memory (single API replica)
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+// NewMemoryLimiter creates an in-memory limiter and starts evicting idle buckets
+func NewMemoryLimiter(ctx context.Context) *MemoryLimiter {
+	l := &MemoryLimiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+	go l.evictLoop(ctx, time.Minute)
+	return l
+}
+
+// Allow takes one token from the bucket identified by key
+func (l *MemoryLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), lastSeen: now}
+		l.buckets[key] = b
+	}
+
+	tokens, res := take(b.tokens, now.Sub(b.lastSeen), limit)
+	b.tokens = tokens
+	b.lastSeen = now
+	b.fullAt = now.Add(res.Reset)
+	return res, nil
+}
+
+// evictLoop drops buckets that have refilled completely; a new bucket
+// starts full, so dropping them does not change behaviour.
+func (l *MemoryLimiter) evictLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := l.now()
+			l.mu.Lock()
+			for key, b := range l.buckets {
+				if b.fullAt.Before(now) {
+					delete(l.buckets, key)
+				}
+			}
+			l.mu.Unlock()
+		}
+	}
+}
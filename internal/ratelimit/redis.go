@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript implements the same algorithm as take() atomically in Redis.
+// It uses the Redis clock so all API replicas agree on elapsed time.
+//
+// KEYS[1] = bucket key
+// ARGV[1] = rate (tokens/sec), ARGV[2] = burst
+// Returns {allowed, tokens_after (string), retry_after_ms, reset_ms}
+var tokenBucketScript = redis.NewScript(`
+local key = KEYS[1]
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local data = redis.call("HMGET", key, "tokens", "ts")
+local tokens = tonumber(data[1]) or burst
+local ts = tonumber(data[2]) or now
+
+local elapsed = math.max(0, now - ts) / 1000
+tokens = math.min(burst, tokens + elapsed * rate)
+
+local allowed = 0
+local retry_after = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry_after = math.ceil((1 - tokens) / rate * 1000)
+end
+
+local reset = math.ceil((burst - tokens) / rate * 1000)
+redis.call("HSET", key, "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", key, reset + 1000)
+
+return {allowed, tostring(tokens), retry_after, reset}
+`)
+
+// RedisLimiter keeps buckets in Redis so several API replicas share limits
+type RedisLimiter struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisLimiter creates a Redis-backed limiter
+func NewRedisLimiter(client *redis.Client) *RedisLimiter {
+	return &RedisLimiter{client: client, prefix: "ratelimit:"}
+}
+
+// Allow takes one token from the bucket identified by key
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	vals, err := tokenBucketScript.Run(ctx, l.client, []string{l.prefix + key},
+		strconv.FormatFloat(limit.Rate, 'f', -1, 64), limit.Burst).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("rate limit script failed: %w", err)
+	}
+	if len(vals) != 4 {
+		return Result{}, fmt.Errorf("rate limit script returned %d values", len(vals))
+	}
+
+	tokens, err := strconv.ParseFloat(fmt.Sprint(vals[1]), 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("invalid token count %v: %w", vals[1], err)
+	}
+
+	res := Result{
+		Allowed:    toInt64(vals[0]) == 1,
+		Limit:      limit.Burst,
+		Remaining:  int(tokens),
+		RetryAfter: time.Duration(toInt64(vals[2])) * time.Millisecond,
+		Reset:      time.Duration(toInt64(vals[3])) * time.Millisecond,
+	}
+	return res, nil
+}
+
+// toInt64 converts an integer script reply to int64
+func toInt64(v interface{}) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	case string:
+		i, _ := strconv.ParseInt(n, 10, 64)
+		return i
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+)
+
+// AnalyticsRepository defines the interface for the local analytics store
+type AnalyticsRepository interface {
+	// RecordEvents writes a batch of events and returns how many were new.
+	// Events of orders already recorded are skipped.
+	RecordEvents(ctx context.Context, events []*domain.AnalyticsEvent) (int64, error)
+	// Buckets sums the events created in [from, to) per bucket of width, currency
+	// and payment method, oldest bucket first. Buckets start at multiples of width
+	// since the Unix epoch.
+	Buckets(ctx context.Context, from, to time.Time, width time.Duration) ([]domain.AnalyticsBucket, error)
+}
+
+// GormAnalyticsRepository implements AnalyticsRepository using GORM
+type GormAnalyticsRepository struct {
+	db *gorm.DB
+}
+
+// NewGormAnalyticsRepository creates a new GORM-based analytics repository
+func NewGormAnalyticsRepository(db *gorm.DB) AnalyticsRepository {
+	return &GormAnalyticsRepository{db: db}
+}
+
+// RecordEvents inserts the batch in one statement
+func (r *GormAnalyticsRepository) RecordEvents(ctx context.Context, events []*domain.AnalyticsEvent) (int64, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	// SQLite compares times as text, so they are stored and queried in UTC
+	models := make([]*domain.AnalyticsEventModel, 0, len(events))
+	for _, e := range events {
+		model := domain.FromAnalyticsEvent(e)
+		model.CreatedAt, model.RecordedAt = model.CreatedAt.UTC(), model.RecordedAt.UTC()
+		models = append(models, model)
+	}
+
+	result := conn(ctx, r.db).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "order_id"}}, DoNothing: true}).
+		Create(&models)
+	return result.RowsAffected, result.Error
+}
+
+// analyticsBucketRow is one row of the Buckets query
+type analyticsBucketRow struct {
+	Bucket        int64
+	Currency      string
+	PaymentMethod string
+	Orders        int64
+	RevenueMinor  int64
+	Items         int64
+}
+
+// Buckets groups the events in SQL, so only one row per bucket, currency and
+// payment method leaves the database
+func (r *GormAnalyticsRepository) Buckets(ctx context.Context, from, to time.Time, width time.Duration) ([]domain.AnalyticsBucket, error) {
+	// Number of the bucket of created_at, counted in widths since the epoch
+	bucket := "CAST(FLOOR(EXTRACT(EPOCH FROM created_at) / ?) AS bigint)"
+	if r.db.Dialector.Name() == "sqlite" {
+		bucket = "CAST(strftime('%s', created_at) AS integer) / ?"
+	}
+	seconds := int64(width / time.Second)
+
+	var rows []analyticsBucketRow
+	err := conn(ctx, r.db).
+		Model(&domain.AnalyticsEventModel{}).
+		Select(bucket+` AS bucket, currency, payment_method, COUNT(*) AS orders,
+			SUM(total_amount_minor) AS revenue_minor, SUM(item_count) AS items`, seconds).
+		Where("created_at >= ? AND created_at < ?", from.UTC(), to.UTC()).
+		Group("bucket, currency, payment_method").
+		Order("bucket, currency, payment_method").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([]domain.AnalyticsBucket, 0, len(rows))
+	for _, row := range rows {
+		buckets = append(buckets, domain.AnalyticsBucket{
+			Start:         time.Unix(row.Bucket*seconds, 0).UTC(),
+			Currency:      row.Currency,
+			PaymentMethod: row.PaymentMethod,
+			Orders:        row.Orders,
+			RevenueMinor:  row.RevenueMinor,
+			Items:         row.Items,
+		})
+	}
+	return buckets, nil
+}
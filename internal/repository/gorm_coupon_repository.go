@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+)
+
+// CouponRepository defines the interface for coupons and their redemptions
+type CouponRepository interface {
+	Create(ctx context.Context, coupon *domain.Coupon) error
+	FindByCode(ctx context.Context, code string) (*domain.Coupon, error)
+	FindAll(ctx context.Context) ([]*domain.Coupon, error)
+	SetActive(ctx context.Context, code string, active bool) error
+
+	// Redeem records one use of a coupon by an order. The usage limit is checked
+	// and incremented in a single statement, so it holds under concurrent orders.
+	// Redeeming again for the same order is a no-op.
+	Redeem(ctx context.Context, code, orderID string, discount domain.Money) error
+	// Release gives back the use taken by an order (cancelled or unsaved order)
+	Release(ctx context.Context, orderID string) error
+	// UpdateDiscount records the new discount of an edited order; the use stays taken
+	UpdateDiscount(ctx context.Context, orderID string, discount domain.Money) error
+}
+
+// Coupon errors
+var (
+	ErrCouponNotFound  = errors.New("coupon not found")
+	ErrCouponExists    = errors.New("coupon already exists")
+	ErrCouponExhausted = errors.New("coupon usage limit reached")
+)
+
+// GormCouponRepository implements CouponRepository using GORM
+type GormCouponRepository struct {
+	db *gorm.DB
+}
+
+// NewGormCouponRepository creates a new GORM-based coupon repository
+func NewGormCouponRepository(db *gorm.DB) CouponRepository {
+	return &GormCouponRepository{db: db}
+}
+
+// Create adds a coupon
+func (r *GormCouponRepository) Create(ctx context.Context, coupon *domain.Coupon) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&domain.CouponModel{}).
+			Where("code = ?", coupon.Code).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrCouponExists
+		}
+		return tx.Create(domain.FromCoupon(coupon)).Error
+	})
+}
+
+// FindByCode retrieves a coupon by code
+func (r *GormCouponRepository) FindByCode(ctx context.Context, code string) (*domain.Coupon, error) {
+	var model domain.CouponModel
+	err := conn(ctx, r.db).First(&model, "code = ?", code).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCouponNotFound
+		}
+		return nil, err
+	}
+
+	return model.ToCoupon(), nil
+}
+
+// FindAll retrieves all coupons
+func (r *GormCouponRepository) FindAll(ctx context.Context) ([]*domain.Coupon, error) {
+	var models []domain.CouponModel
+	if err := conn(ctx, r.db).Order("created_at DESC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	coupons := make([]*domain.Coupon, 0, len(models))
+	for i := range models {
+		coupons = append(coupons, models[i].ToCoupon())
+	}
+	return coupons, nil
+}
+
+// SetActive enables or disables a coupon
+func (r *GormCouponRepository) SetActive(ctx context.Context, code string, active bool) error {
+	result := conn(ctx, r.db).
+		Model(&domain.CouponModel{}).
+		Where("code = ?", code).
+		Updates(map[string]interface{}{
+			"active":     active,
+			"updated_at": time.Now(),
+		})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrCouponNotFound
+	}
+
+	return nil
+}
+
+// Redeem takes one use of a coupon for an order
+func (r *GormCouponRepository) Redeem(ctx context.Context, code, orderID string, discount domain.Money) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&domain.CouponRedemptionModel{}).
+			Where("order_id = ?", orderID).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return nil
+		}
+
+		result := tx.Model(&domain.CouponModel{}).
+			Where("code = ? AND active AND (max_uses = 0 OR used_count < max_uses)", code).
+			Updates(map[string]interface{}{
+				"used_count": gorm.Expr("used_count + 1"),
+				"updated_at": time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// Tell a missing or disabled coupon apart from a used-up one
+			var model domain.CouponModel
+			if err := tx.First(&model, "code = ?", code).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrCouponNotFound
+				}
+				return err
+			}
+			if !model.Active {
+				return ErrCouponNotFound
+			}
+			return ErrCouponExhausted
+		}
+
+		return tx.Create(&domain.CouponRedemptionModel{
+			CouponCode: code,
+			OrderID:    orderID,
+			Discount:   discount.Amount,
+			CreatedAt:  time.Now(),
+		}).Error
+	})
+}
+
+// UpdateDiscount changes the discount recorded for an order's redemption
+func (r *GormCouponRepository) UpdateDiscount(ctx context.Context, orderID string, discount domain.Money) error {
+	return conn(ctx, r.db).
+		Model(&domain.CouponRedemptionModel{}).
+		Where("order_id = ?", orderID).
+		Update("discount_minor", discount.Amount).Error
+}
+
+// Release deletes the redemption of an order and gives its use back
+func (r *GormCouponRepository) Release(ctx context.Context, orderID string) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var redemption domain.CouponRedemptionModel
+		err := tx.Where("order_id = ?", orderID).First(&redemption).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil // Order used no coupon, or it was already released
+		}
+		if err != nil {
+			return err
+		}
+
+		result := tx.Delete(&domain.CouponRedemptionModel{}, redemption.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil // Released concurrently
+		}
+
+		return tx.Model(&domain.CouponModel{}).
+			Where("code = ? AND used_count > 0", redemption.CouponCode).
+			Update("used_count", gorm.Expr("used_count - 1")).Error
+	})
+}
@@ -0,0 +1,442 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+)
+
+// InventoryRepository defines the interface for products, stock levels and reservations
+type InventoryRepository interface {
+	CreateProduct(ctx context.Context, product *domain.Product, initialStock int) error
+	FindProductByID(ctx context.Context, id string) (*domain.Product, error)
+	FindAllProducts(ctx context.Context) ([]*domain.Product, error)
+	FindProductsByIDs(ctx context.Context, ids []string) (map[string]*domain.Product, error)
+	UpdateProduct(ctx context.Context, product *domain.Product) error
+	DeleteProduct(ctx context.Context, id string) error
+
+	FindStock(ctx context.Context, productID string) (*domain.StockLevel, error)
+	FindAllStock(ctx context.Context) ([]*domain.StockLevel, error)
+	FindStockByProductIDs(ctx context.Context, productIDs []string) (map[string]*domain.StockLevel, error)
+	SetStock(ctx context.Context, productID string, onHand int) (*domain.StockLevel, error)
+	AdjustStock(ctx context.Context, productID string, delta int) (*domain.StockLevel, error)
+
+	// LockStock locks the stock rows of products, in product order, until the
+	// transaction in ctx ends. Reservations made afterwards in the same
+	// transaction cannot deadlock with other orders.
+	LockStock(ctx context.Context, productIDs []string) error
+	// Reserve holds stock for every item of an order, all or nothing.
+	// Calling it again for the same order is a no-op.
+	Reserve(ctx context.Context, orderID string, items []domain.StockRequest) error
+	// ReplaceReservation swaps the held stock of an edited order for new items,
+	// all or nothing: when the new items do not fit, the old hold stays
+	ReplaceReservation(ctx context.Context, orderID string, items []domain.StockRequest) error
+	// Release returns held stock of an order (cancel, payment failure)
+	Release(ctx context.Context, orderID string) error
+	// Commit removes held stock of an order from on-hand (shipped)
+	Commit(ctx context.Context, orderID string) error
+}
+
+// Inventory errors
+var (
+	ErrProductNotFound   = errors.New("product not found")
+	ErrProductExists     = errors.New("product already exists")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrInvalidStockLevel = errors.New("stock level cannot be below reserved or zero")
+)
+
+// InsufficientStockError reports which product could not be reserved
+type InsufficientStockError struct {
+	ProductID string
+	Requested int
+	Available int
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock for product %s: requested %d, available %d",
+		e.ProductID, e.Requested, e.Available)
+}
+
+// Is makes errors.Is(err, ErrInsufficientStock) work
+func (e *InsufficientStockError) Is(target error) bool {
+	return target == ErrInsufficientStock
+}
+
+// GormInventoryRepository implements InventoryRepository using GORM
+type GormInventoryRepository struct {
+	db *gorm.DB
+}
+
+// NewGormInventoryRepository creates a new GORM-based inventory repository
+func NewGormInventoryRepository(db *gorm.DB) InventoryRepository {
+	return &GormInventoryRepository{db: db}
+}
+
+// CreateProduct adds a product together with its inventory row
+func (r *GormInventoryRepository) CreateProduct(ctx context.Context, product *domain.Product, initialStock int) error {
+	if initialStock < 0 {
+		return ErrInvalidStockLevel
+	}
+
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Deleted products keep their ID (and stock row), so include them
+		var existing int64
+		if err := tx.Unscoped().Model(&domain.ProductModel{}).
+			Where("id = ?", product.ID).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrProductExists
+		}
+
+		if err := tx.Create(domain.FromProduct(product)).Error; err != nil {
+			return err
+		}
+		return tx.Create(&domain.InventoryModel{
+			ProductID: product.ID,
+			OnHand:    initialStock,
+			UpdatedAt: product.CreatedAt,
+		}).Error
+	})
+}
+
+// FindProductByID retrieves a product by ID
+func (r *GormInventoryRepository) FindProductByID(ctx context.Context, id string) (*domain.Product, error) {
+	var model domain.ProductModel
+	err := conn(ctx, r.db).First(&model, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+
+	return model.ToProduct(), nil
+}
+
+// FindAllProducts retrieves all products
+func (r *GormInventoryRepository) FindAllProducts(ctx context.Context) ([]*domain.Product, error) {
+	var models []domain.ProductModel
+	if err := conn(ctx, r.db).Order("id").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	products := make([]*domain.Product, 0, len(models))
+	for i := range models {
+		products = append(products, models[i].ToProduct())
+	}
+	return products, nil
+}
+
+// FindProductsByIDs retrieves the given products keyed by ID; unknown IDs are absent
+func (r *GormInventoryRepository) FindProductsByIDs(ctx context.Context, ids []string) (map[string]*domain.Product, error) {
+	var models []domain.ProductModel
+	if err := conn(ctx, r.db).Where("id IN ?", ids).Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	products := make(map[string]*domain.Product, len(models))
+	for i := range models {
+		products[models[i].ID] = models[i].ToProduct()
+	}
+	return products, nil
+}
+
+// UpdateProduct updates name, price and active flag
+func (r *GormInventoryRepository) UpdateProduct(ctx context.Context, product *domain.Product) error {
+	result := conn(ctx, r.db).
+		Model(&domain.ProductModel{}).
+		Where("id = ?", product.ID).
+		Updates(map[string]interface{}{
+			"name":        product.Name,
+			"price_minor": product.Price.Amount,
+			"currency":    product.Price.Currency,
+			"active":      product.Active,
+			"updated_at":  product.UpdatedAt,
+		})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrProductNotFound
+	}
+
+	return nil
+}
+
+// DeleteProduct removes a product (soft delete); its stock row is kept for history
+func (r *GormInventoryRepository) DeleteProduct(ctx context.Context, id string) error {
+	result := conn(ctx, r.db).Delete(&domain.ProductModel{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrProductNotFound
+	}
+
+	return nil
+}
+
+// FindStock retrieves the stock level of a product
+func (r *GormInventoryRepository) FindStock(ctx context.Context, productID string) (*domain.StockLevel, error) {
+	return findStock(conn(ctx, r.db), productID)
+}
+
+func findStock(db *gorm.DB, productID string) (*domain.StockLevel, error) {
+	var model domain.InventoryModel
+	err := db.First(&model, "product_id = ?", productID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+
+	return model.ToStockLevel(), nil
+}
+
+// FindAllStock retrieves stock levels of all products
+func (r *GormInventoryRepository) FindAllStock(ctx context.Context) ([]*domain.StockLevel, error) {
+	var models []domain.InventoryModel
+	if err := conn(ctx, r.db).Order("product_id").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	levels := make([]*domain.StockLevel, 0, len(models))
+	for i := range models {
+		levels = append(levels, models[i].ToStockLevel())
+	}
+	return levels, nil
+}
+
+// FindStockByProductIDs retrieves stock levels keyed by product ID (no locks taken)
+func (r *GormInventoryRepository) FindStockByProductIDs(ctx context.Context, productIDs []string) (map[string]*domain.StockLevel, error) {
+	var models []domain.InventoryModel
+	if err := conn(ctx, r.db).Where("product_id IN ?", productIDs).Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	levels := make(map[string]*domain.StockLevel, len(models))
+	for i := range models {
+		levels[models[i].ProductID] = models[i].ToStockLevel()
+	}
+	return levels, nil
+}
+
+// SetStock sets on-hand units; it cannot go below what is already reserved
+func (r *GormInventoryRepository) SetStock(ctx context.Context, productID string, onHand int) (*domain.StockLevel, error) {
+	return r.updateOnHand(ctx, productID, onHand, "?", onHand)
+}
+
+// AdjustStock adds delta (may be negative) to on-hand units
+func (r *GormInventoryRepository) AdjustStock(ctx context.Context, productID string, delta int) (*domain.StockLevel, error) {
+	return r.updateOnHand(ctx, productID, delta, "on_hand + ?", delta)
+}
+
+// updateOnHand applies "on_hand = <expr>" only if the result stays >= reserved
+func (r *GormInventoryRepository) updateOnHand(ctx context.Context, productID string, arg int, expr string, value int) (*domain.StockLevel, error) {
+	var level *domain.StockLevel
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.InventoryModel{}).
+			Where("product_id = ? AND "+expr+" >= reserved AND "+expr+" >= 0", productID, value, value).
+			Updates(map[string]interface{}{
+				"on_hand":    gorm.Expr(expr, arg),
+				"updated_at": time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			if _, err := findStock(tx, productID); err != nil {
+				return err
+			}
+			return ErrInvalidStockLevel
+		}
+
+		var err error
+		level, err = findStock(tx, productID)
+		return err
+	})
+	return level, err
+}
+
+// LockStock locks inventory rows with SELECT ... FOR UPDATE in product order.
+// SQLite has no row locks; its write transactions already run one at a time.
+func (r *GormInventoryRepository) LockStock(ctx context.Context, productIDs []string) error {
+	var locked []string
+	return conn(ctx, r.db).Model(&domain.InventoryModel{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id IN ?", productIDs).
+		Order("product_id").
+		Pluck("product_id", &locked).Error
+}
+
+// Reserve holds stock for every item of an order in one transaction.
+// Each item is a conditional update (available >= quantity) that locks only
+// its own row, so orders for different products never wait on each other.
+func (r *GormInventoryRepository) Reserve(ctx context.Context, orderID string, items []domain.StockRequest) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Idempotent: a retried task must not reserve twice
+		var existing int64
+		if err := tx.Model(&domain.InventoryReservationModel{}).
+			Where("order_id = ?", orderID).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return nil
+		}
+		return reserveItems(tx, orderID, items)
+	})
+}
+
+// ReplaceReservation releases the active hold of an order and reserves items
+// instead, in one transaction
+func (r *GormInventoryRepository) ReplaceReservation(ctx context.Context, orderID string, items []domain.StockRequest) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var reservations []domain.InventoryReservationModel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_id = ? AND status = ?", orderID, domain.ReservationStatusReserved).
+			Order("product_id").
+			Find(&reservations).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, res := range reservations {
+			if err := tx.Model(&domain.InventoryModel{}).
+				Where("product_id = ?", res.ProductID).
+				Updates(map[string]interface{}{
+					"reserved":   gorm.Expr("reserved - ?", res.Quantity),
+					"updated_at": now,
+				}).Error; err != nil {
+				return err
+			}
+		}
+
+		// Rows are keyed by (order, product), so the old ones make room for the new
+		if err := tx.Where("order_id = ?", orderID).
+			Delete(&domain.InventoryReservationModel{}).Error; err != nil {
+			return err
+		}
+		return reserveItems(tx, orderID, items)
+	})
+}
+
+// reserveItems takes stock for the items of an order inside tx
+func reserveItems(tx *gorm.DB, orderID string, items []domain.StockRequest) error {
+	now := time.Now()
+	for _, item := range mergeStockRequests(items) {
+		result := tx.Model(&domain.InventoryModel{}).
+			Where("product_id = ? AND on_hand - reserved >= ?", item.ProductID, item.Quantity).
+			Updates(map[string]interface{}{
+				"reserved":   gorm.Expr("reserved + ?", item.Quantity),
+				"updated_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			level, err := findStock(tx, item.ProductID)
+			if err != nil {
+				return fmt.Errorf("product %s: %w", item.ProductID, err)
+			}
+			return &InsufficientStockError{
+				ProductID: item.ProductID,
+				Requested: item.Quantity,
+				Available: level.Available(),
+			}
+		}
+
+		if err := tx.Create(&domain.InventoryReservationModel{
+			OrderID:   orderID,
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Status:    string(domain.ReservationStatusReserved),
+			CreatedAt: now,
+			UpdatedAt: now,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Release returns held stock of an order; already released/committed units are untouched
+func (r *GormInventoryRepository) Release(ctx context.Context, orderID string) error {
+	return r.settle(ctx, orderID, domain.ReservationStatusReleased, map[string]string{
+		"reserved": "reserved - ?",
+	})
+}
+
+// Commit removes held stock of an order from on-hand
+func (r *GormInventoryRepository) Commit(ctx context.Context, orderID string) error {
+	return r.settle(ctx, orderID, domain.ReservationStatusCommitted, map[string]string{
+		"reserved": "reserved - ?",
+		"on_hand":  "on_hand - ?",
+	})
+}
+
+// settle moves an order's active reservations to a final status
+func (r *GormInventoryRepository) settle(ctx context.Context, orderID string, status domain.ReservationStatus, columns map[string]string) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var reservations []domain.InventoryReservationModel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_id = ? AND status = ?", orderID, domain.ReservationStatusReserved).
+			Order("product_id").
+			Find(&reservations).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, res := range reservations {
+			updates := map[string]interface{}{"updated_at": now}
+			for column, expr := range columns {
+				updates[column] = gorm.Expr(expr, res.Quantity)
+			}
+			if err := tx.Model(&domain.InventoryModel{}).
+				Where("product_id = ?", res.ProductID).
+				Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(reservations) == 0 {
+			return nil
+		}
+		return tx.Model(&domain.InventoryReservationModel{}).
+			Where("order_id = ? AND status = ?", orderID, domain.ReservationStatusReserved).
+			Updates(map[string]interface{}{"status": string(status), "updated_at": now}).Error
+	})
+}
+
+// mergeStockRequests sums quantities per product and sorts by product ID,
+// so concurrent transactions always lock rows in the same order (no deadlocks)
+func mergeStockRequests(items []domain.StockRequest) []domain.StockRequest {
+	totals := make(map[string]int, len(items))
+	for _, item := range items {
+		totals[item.ProductID] += item.Quantity
+	}
+
+	merged := make([]domain.StockRequest, 0, len(totals))
+	for productID, quantity := range totals {
+		merged = append(merged, domain.StockRequest{ProductID: productID, Quantity: quantity})
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].ProductID < merged[j].ProductID
+	})
+	return merged
+}
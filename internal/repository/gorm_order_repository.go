@@ -3,8 +3,10 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/lppduy/go-asynq-loadtest/internal/domain"
 )
@@ -13,10 +15,16 @@ import (
 type OrderRepository interface {
 	Create(ctx context.Context, order *domain.Order) error
 	FindByID(ctx context.Context, id string) (*domain.Order, error)
+	// FindByIDForUpdate is FindByID for read-modify-write. Inside a transaction
+	// the order row stays locked until it ends.
+	FindByIDForUpdate(ctx context.Context, id string) (*domain.Order, error)
 	FindByCustomerID(ctx context.Context, customerID string) ([]*domain.Order, error)
 	Update(ctx context.Context, order *domain.Order) error
 	Delete(ctx context.Context, id string) error
 	FindAll(ctx context.Context) ([]*domain.Order, error)
+	// FindStale returns up to limit orders in status not updated since
+	// updatedBefore, least recently updated first
+	FindStale(ctx context.Context, status domain.OrderStatus, updatedBefore time.Time, limit int) ([]*domain.Order, error)
 }
 
 // Common errors
@@ -34,21 +42,45 @@ func NewGormOrderRepository(db *gorm.DB) OrderRepository {
 	return &GormOrderRepository{db: db}
 }
 
-// Create adds a new order
+// withDetails loads the items (in line order) and address of orders
+func withDetails(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("line_no") }).
+		Preload("Address")
+}
+
+// Create adds a new order with its items and address in one transaction
 func (r *GormOrderRepository) Create(ctx context.Context, order *domain.Order) error {
-	model, err := domain.FromOrder(order)
-	if err != nil {
-		return err
-	}
+	model := domain.FromOrder(order)
 
-	return r.db.WithContext(ctx).Create(model).Error
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		return tx.Create(model).Error
+	})
 }
 
 // FindByID retrieves an order by ID
 func (r *GormOrderRepository) FindByID(ctx context.Context, id string) (*domain.Order, error) {
 	var model domain.OrderModel
-	err := r.db.WithContext(ctx).First(&model, "id = ?", id).Error
-	
+	err := withDetails(conn(ctx, r.db)).First(&model, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	return model.ToOrder(), nil
+}
+
+// FindByIDForUpdate retrieves an order by ID with its row locked.
+// SQLite has no row locks; its write transactions already run one at a time.
+func (r *GormOrderRepository) FindByIDForUpdate(ctx context.Context, id string) (*domain.Order, error) {
+	var model domain.OrderModel
+	err := withDetails(conn(ctx, r.db)).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&model, "id = ?", id).Error
+
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrOrderNotFound
@@ -56,60 +88,74 @@ func (r *GormOrderRepository) FindByID(ctx context.Context, id string) (*domain.
 		return nil, err
 	}
 
-	return model.ToOrder()
+	return model.ToOrder(), nil
 }
 
 // FindByCustomerID retrieves all orders for a customer
 func (r *GormOrderRepository) FindByCustomerID(ctx context.Context, customerID string) ([]*domain.Order, error) {
 	var models []domain.OrderModel
-	err := r.db.WithContext(ctx).
+	err := withDetails(conn(ctx, r.db)).
 		Where("customer_id = ?", customerID).
 		Order("created_at DESC").
 		Find(&models).Error
-	
+
 	if err != nil {
 		return nil, err
 	}
 
 	orders := make([]*domain.Order, 0, len(models))
-	for _, model := range models {
-		order, err := model.ToOrder()
-		if err != nil {
-			return nil, err
-		}
-		orders = append(orders, order)
+	for i := range models {
+		orders = append(orders, models[i].ToOrder())
 	}
 
 	return orders, nil
 }
 
-// Update updates an existing order
+// Update updates an existing order together with its items and address.
+// Lines are upserted by position and extra lines removed, so status-only
+// updates rewrite rows in place instead of re-creating them.
 func (r *GormOrderRepository) Update(ctx context.Context, order *domain.Order) error {
-	model, err := domain.FromOrder(order)
-	if err != nil {
-		return err
-	}
+	model := domain.FromOrder(order)
 
-	result := r.db.WithContext(ctx).
-		Model(&domain.OrderModel{}).
-		Where("id = ?", order.ID).
-		Updates(model)
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Model(&domain.OrderModel{}).
+			Where("id = ?", order.ID).
+			Omit(clause.Associations).
+			Updates(model)
 
-	if result.Error != nil {
-		return result.Error
-	}
+		if result.Error != nil {
+			return result.Error
+		}
 
-	if result.RowsAffected == 0 {
-		return ErrOrderNotFound
-	}
+		if result.RowsAffected == 0 {
+			return ErrOrderNotFound
+		}
 
-	return nil
+		if len(model.Items) > 0 {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "order_id"}, {Name: "line_no"}},
+				UpdateAll: true,
+			}).Create(&model.Items).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("order_id = ? AND line_no >= ?", order.ID, len(model.Items)).
+			Delete(&domain.OrderItemModel{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "order_id"}},
+			UpdateAll: true,
+		}).Create(model.Address).Error
+	})
 }
 
 // Delete removes an order by ID (soft delete)
 func (r *GormOrderRepository) Delete(ctx context.Context, id string) error {
-	result := r.db.WithContext(ctx).Delete(&domain.OrderModel{}, "id = ?", id)
-	
+	result := conn(ctx, r.db).Delete(&domain.OrderModel{}, "id = ?", id)
+
 	if result.Error != nil {
 		return result.Error
 	}
@@ -124,21 +170,38 @@ func (r *GormOrderRepository) Delete(ctx context.Context, id string) error {
 // FindAll retrieves all orders
 func (r *GormOrderRepository) FindAll(ctx context.Context) ([]*domain.Order, error) {
 	var models []domain.OrderModel
-	err := r.db.WithContext(ctx).
+	err := withDetails(conn(ctx, r.db)).
 		Order("created_at DESC").
 		Find(&models).Error
-	
+
 	if err != nil {
 		return nil, err
 	}
 
 	orders := make([]*domain.Order, 0, len(models))
-	for _, model := range models {
-		order, err := model.ToOrder()
-		if err != nil {
-			return nil, err
-		}
-		orders = append(orders, order)
+	for i := range models {
+		orders = append(orders, models[i].ToOrder())
+	}
+
+	return orders, nil
+}
+
+// FindStale retrieves orders stuck in status since before updatedBefore
+func (r *GormOrderRepository) FindStale(ctx context.Context, status domain.OrderStatus, updatedBefore time.Time, limit int) ([]*domain.Order, error) {
+	var models []domain.OrderModel
+	err := withDetails(conn(ctx, r.db)).
+		Where("status = ? AND updated_at < ?", string(status), updatedBefore).
+		Order("updated_at, id").
+		Limit(limit).
+		Find(&models).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]*domain.Order, 0, len(models))
+	for i := range models {
+		orders = append(orders, models[i].ToOrder())
 	}
 
 	return orders, nil
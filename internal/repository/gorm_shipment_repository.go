@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+)
+
+// ShipmentRepository defines the interface for shipments and their status history
+type ShipmentRepository interface {
+	Create(ctx context.Context, shipment *domain.Shipment) error
+	// FindByTracking looks a shipment up by its carrier's tracking number.
+	// Inside a transaction the row stays locked until it ends.
+	FindByTracking(ctx context.Context, carrier, trackingNumber string) (*domain.Shipment, error)
+	// ListByOrder returns the shipments of an order by parcel number
+	ListByOrder(ctx context.Context, orderID string) ([]*domain.Shipment, error)
+	// Update saves the status and appends the events not stored yet
+	Update(ctx context.Context, shipment *domain.Shipment) error
+}
+
+// Shipment errors
+var (
+	ErrShipmentNotFound = errors.New("shipment not found")
+)
+
+// GormShipmentRepository implements ShipmentRepository using GORM
+type GormShipmentRepository struct {
+	db *gorm.DB
+}
+
+// NewGormShipmentRepository creates a new GORM-based shipment repository
+func NewGormShipmentRepository(db *gorm.DB) ShipmentRepository {
+	return &GormShipmentRepository{db: db}
+}
+
+// withShipmentDetails loads the items and events of shipments in order
+func withShipmentDetails(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("line_no") }).
+		Preload("Events", func(db *gorm.DB) *gorm.DB { return db.Order("seq") })
+}
+
+// Create adds a shipment with its items and events in one transaction
+func (r *GormShipmentRepository) Create(ctx context.Context, shipment *domain.Shipment) error {
+	model := domain.FromShipment(shipment)
+
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		return tx.Create(model).Error
+	})
+}
+
+// FindByTracking retrieves a shipment by carrier and tracking number.
+// SQLite has no row locks; its write transactions already run one at a time.
+func (r *GormShipmentRepository) FindByTracking(ctx context.Context, carrier, trackingNumber string) (*domain.Shipment, error) {
+	var model domain.ShipmentModel
+	err := withShipmentDetails(conn(ctx, r.db)).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&model, "carrier = ? AND tracking_number = ?", carrier, trackingNumber).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShipmentNotFound
+		}
+		return nil, err
+	}
+
+	return model.ToShipment(), nil
+}
+
+// ListByOrder retrieves the shipments of an order
+func (r *GormShipmentRepository) ListByOrder(ctx context.Context, orderID string) ([]*domain.Shipment, error) {
+	var models []domain.ShipmentModel
+	err := withShipmentDetails(conn(ctx, r.db)).
+		Where("order_id = ?", orderID).
+		Order("parcel").
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	shipments := make([]*domain.Shipment, 0, len(models))
+	for i := range models {
+		shipments = append(shipments, models[i].ToShipment())
+	}
+	return shipments, nil
+}
+
+// Update writes the shipment row; events are append-only, so stored ones are left as they are
+func (r *GormShipmentRepository) Update(ctx context.Context, shipment *domain.Shipment) error {
+	model := domain.FromShipment(shipment)
+
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Model(&domain.ShipmentModel{}).
+			Where("id = ?", shipment.ID).
+			Omit(clause.Associations).
+			Updates(model)
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrShipmentNotFound
+		}
+
+		if len(model.Events) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "shipment_id"}, {Name: "seq"}},
+			DoNothing: true,
+		}).Create(&model.Events).Error
+	})
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+)
+
+// WebhookRepository defines the interface for webhook subscriptions and delivery logs
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error
+	FindSubscriptionByID(ctx context.Context, id string) (*domain.WebhookSubscription, error)
+	FindAllSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error)
+	FindActiveSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id string) error
+	RecordDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+	FindDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*domain.WebhookDelivery, error)
+}
+
+// Webhook errors
+var (
+	ErrWebhookNotFound = errors.New("webhook subscription not found")
+)
+
+// GormWebhookRepository implements WebhookRepository using GORM
+type GormWebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewGormWebhookRepository creates a new GORM-based webhook repository
+func NewGormWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &GormWebhookRepository{db: db}
+}
+
+// CreateSubscription adds a new subscription
+func (r *GormWebhookRepository) CreateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Create(domain.FromSubscription(sub)).Error
+}
+
+// FindSubscriptionByID retrieves a subscription by ID
+func (r *GormWebhookRepository) FindSubscriptionByID(ctx context.Context, id string) (*domain.WebhookSubscription, error) {
+	var model domain.WebhookSubscriptionModel
+	err := r.db.WithContext(ctx).First(&model, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+
+	return model.ToSubscription(), nil
+}
+
+// FindAllSubscriptions retrieves all subscriptions
+func (r *GormWebhookRepository) FindAllSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	return r.findSubscriptions(r.db.WithContext(ctx))
+}
+
+// FindActiveSubscriptions retrieves subscriptions that receive events
+func (r *GormWebhookRepository) FindActiveSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	return r.findSubscriptions(r.db.WithContext(ctx).Where("active = ?", true))
+}
+
+func (r *GormWebhookRepository) findSubscriptions(query *gorm.DB) ([]*domain.WebhookSubscription, error) {
+	var models []domain.WebhookSubscriptionModel
+	if err := query.Order("created_at DESC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	subs := make([]*domain.WebhookSubscription, 0, len(models))
+	for i := range models {
+		subs = append(subs, models[i].ToSubscription())
+	}
+	return subs, nil
+}
+
+// DeleteSubscription removes a subscription (soft delete)
+func (r *GormWebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Delete(&domain.WebhookSubscriptionModel{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// RecordDelivery appends an attempt to the delivery log
+func (r *GormWebhookRepository) RecordDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	model := domain.FromDelivery(delivery)
+	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+		return err
+	}
+	delivery.ID = model.ID
+	return nil
+}
+
+// FindDeliveries retrieves the most recent delivery attempts of a subscription
+func (r *GormWebhookRepository) FindDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*domain.WebhookDelivery, error) {
+	var models []domain.WebhookDeliveryModel
+	err := r.db.WithContext(ctx).
+		Where("subscription_id = ?", subscriptionID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]*domain.WebhookDelivery, 0, len(models))
+	for i := range models {
+		deliveries = append(deliveries, models[i].ToDelivery())
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+)
+
+// MemoryOrderRepository implements OrderRepository in process memory.
+// It is safe for concurrent use and keeps the semantics of GormOrderRepository
+// (ErrOrderNotFound, soft delete, newest first, row locks held until the
+// transaction ends), but nothing is shared between processes or survives a
+// restart. Orders are copied in and out, so callers never share state with the
+// store.
+type MemoryOrderRepository struct {
+	mu     sync.RWMutex
+	orders map[string]*memoryOrder
+}
+
+type memoryOrder struct {
+	order     domain.Order
+	deletedAt *time.Time // Soft delete
+
+	// Row lock: a token in the channel means it is taken. owner is the
+	// transaction keeping it until it ends (nil for a single write); it is
+	// guarded by the repository mutex.
+	lock  chan struct{}
+	owner *txState
+}
+
+// NewMemoryOrderRepository creates an empty in-memory repository
+func NewMemoryOrderRepository() OrderRepository {
+	return &MemoryOrderRepository{orders: make(map[string]*memoryOrder)}
+}
+
+// Create adds a new order
+func (r *MemoryOrderRepository) Create(ctx context.Context, order *domain.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Soft-deleted orders keep their ID, like the primary key of the orders table
+	if _, exists := r.orders[order.ID]; exists {
+		return fmt.Errorf("order %s already exists", order.ID)
+	}
+
+	r.orders[order.ID] = &memoryOrder{order: copyOrder(order), lock: make(chan struct{}, 1)}
+
+	// Not part of the database transaction, so undo by hand
+	id := order.ID
+	OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.orders, id)
+	})
+	return nil
+}
+
+// FindByID retrieves an order by ID
+func (r *MemoryOrderRepository) FindByID(ctx context.Context, id string) (*domain.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.orders[id]
+	if !ok || stored.deletedAt != nil {
+		return nil, ErrOrderNotFound
+	}
+
+	order := copyOrder(&stored.order)
+	return &order, nil
+}
+
+// FindByIDForUpdate retrieves an order by ID with its lock taken. Inside a
+// transaction the lock is kept until it ends, so other writers of the order wait.
+func (r *MemoryOrderRepository) FindByIDForUpdate(ctx context.Context, id string) (*domain.Order, error) {
+	unlock, err := r.lockOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return r.FindByID(ctx, id)
+}
+
+// FindByCustomerID retrieves all orders for a customer
+func (r *MemoryOrderRepository) FindByCustomerID(ctx context.Context, customerID string) ([]*domain.Order, error) {
+	return r.find(func(o *domain.Order) bool { return o.CustomerID == customerID }), nil
+}
+
+// Update replaces an existing order.
+// Unlike GORM's Updates, zero-value fields are written too; callers never rely on either.
+func (r *MemoryOrderRepository) Update(ctx context.Context, order *domain.Order) error {
+	unlock, err := r.lockOrder(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.orders[order.ID]
+	if !ok || stored.deletedAt != nil {
+		return ErrOrderNotFound
+	}
+
+	previous := stored.order
+	stored.order = copyOrder(order)
+
+	OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		stored.order = previous
+	})
+	return nil
+}
+
+// Delete removes an order by ID (soft delete)
+func (r *MemoryOrderRepository) Delete(ctx context.Context, id string) error {
+	unlock, err := r.lockOrder(ctx, id)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.orders[id]
+	if !ok || stored.deletedAt != nil {
+		return ErrOrderNotFound
+	}
+
+	now := time.Now()
+	stored.deletedAt = &now
+
+	OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		stored.deletedAt = nil
+	})
+	return nil
+}
+
+// FindAll retrieves all orders
+func (r *MemoryOrderRepository) FindAll(ctx context.Context) ([]*domain.Order, error) {
+	return r.find(func(*domain.Order) bool { return true }), nil
+}
+
+// FindStale retrieves orders stuck in status since before updatedBefore
+func (r *MemoryOrderRepository) FindStale(ctx context.Context, status domain.OrderStatus, updatedBefore time.Time, limit int) ([]*domain.Order, error) {
+	orders := r.find(func(o *domain.Order) bool {
+		return o.Status == status && o.UpdatedAt.Before(updatedBefore)
+	})
+
+	sort.SliceStable(orders, func(i, j int) bool {
+		if orders[i].UpdatedAt.Equal(orders[j].UpdatedAt) {
+			return orders[i].ID < orders[j].ID
+		}
+		return orders[i].UpdatedAt.Before(orders[j].UpdatedAt)
+	})
+	if len(orders) > limit {
+		orders = orders[:limit]
+	}
+	return orders, nil
+}
+
+// lockOrder takes the lock of order id, waiting while another transaction holds
+// it. Inside a transaction the lock is kept until the transaction ends and
+// unlock does nothing; outside one, unlock releases it. Unknown orders need no
+// lock: the caller reports ErrOrderNotFound.
+func (r *MemoryOrderRepository) lockOrder(ctx context.Context, id string) (unlock func(), err error) {
+	state, _ := ctx.Value(txKey{}).(*txState)
+
+	r.mu.RLock()
+	stored, ok := r.orders[id]
+	held := ok && state != nil && stored.owner == state
+	r.mu.RUnlock()
+	if !ok || held {
+		return func() {}, nil
+	}
+
+	select {
+	case stored.lock <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	release := func() { <-stored.lock }
+	if state == nil {
+		return release, nil
+	}
+
+	r.mu.Lock()
+	stored.owner = state
+	r.mu.Unlock()
+	onEnd(ctx, func() {
+		r.mu.Lock()
+		stored.owner = nil
+		r.mu.Unlock()
+		release()
+	})
+	return func() {}, nil
+}
+
+// find returns copies of the live orders matching keep, newest first
+func (r *MemoryOrderRepository) find(keep func(*domain.Order) bool) []*domain.Order {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	orders := make([]*domain.Order, 0)
+	for _, stored := range r.orders {
+		if stored.deletedAt != nil || !keep(&stored.order) {
+			continue
+		}
+		order := copyOrder(&stored.order)
+		orders = append(orders, &order)
+	}
+
+	sort.SliceStable(orders, func(i, j int) bool {
+		if orders[i].CreatedAt.Equal(orders[j].CreatedAt) {
+			return orders[i].ID < orders[j].ID
+		}
+		return orders[i].CreatedAt.After(orders[j].CreatedAt)
+	})
+	return orders
+}
+
+// copyOrder returns an order that shares no memory with o and carries no
+// unannounced status changes (a stored order has none, like a loaded one)
+func copyOrder(o *domain.Order) domain.Order {
+	order := *o
+	order.Items = append([]domain.OrderItem(nil), o.Items...)
+	order.TakeStatusChanges()
+	return order
+}
@@ -0,0 +1,138 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository/repotest"
+	"github.com/lppduy/go-asynq-loadtest/pkg/database"
+)
+
+func TestMemoryOrderRepository(t *testing.T) {
+	if err := repotest.TestOrderRepository(context.Background(), repository.NewMemoryOrderRepository); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// The memory store is not part of database transactions; it undoes its
+// writes when the transaction it was called in rolls back
+func TestMemoryOrderRepositoryRollback(t *testing.T) {
+	db, err := database.Connect(database.Config{
+		Driver:     database.DriverSQLite,
+		SQLitePath: filepath.Join(t.TempDir(), "tx.db"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactor := repository.NewGormTransactor(db)
+	repo := repository.NewMemoryOrderRepository()
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+
+	kept := &domain.Order{ID: "ORD-kept", CustomerID: "cust-1", Status: domain.OrderStatusPending, CreatedAt: time.Now()}
+	if err := repo.Create(ctx, kept); err != nil {
+		t.Fatal(err)
+	}
+
+	err = transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		added := &domain.Order{ID: "ORD-added", CustomerID: "cust-1", CreatedAt: time.Now()}
+		if err := repo.Create(ctx, added); err != nil {
+			return err
+		}
+		for _, status := range []domain.OrderStatus{domain.OrderStatusConfirmed, domain.OrderStatusShipped} {
+			changed := *kept
+			changed.Status = status
+			if err := repo.Update(ctx, &changed); err != nil {
+				return err
+			}
+		}
+		if err := repo.Delete(ctx, kept.ID); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithinTransaction = %v, want %v", err, errAbort)
+	}
+
+	if _, err := repo.FindByID(ctx, "ORD-added"); !errors.Is(err, repository.ErrOrderNotFound) {
+		t.Errorf("order created in the rolled back transaction: FindByID = %v, want ErrOrderNotFound", err)
+	}
+	got, err := repo.FindByID(ctx, kept.ID)
+	if err != nil {
+		t.Fatalf("order deleted in the rolled back transaction: FindByID = %v", err)
+	}
+	if got.Status != domain.OrderStatusPending {
+		t.Errorf("status = %s, want %s restored", got.Status, domain.OrderStatusPending)
+	}
+}
+
+// A write to an order locked by FindByIDForUpdate waits for the transaction to
+// end, like a row locked with SELECT ... FOR UPDATE
+func TestMemoryOrderRepositoryLock(t *testing.T) {
+	db, err := database.Connect(database.Config{
+		Driver:     database.DriverSQLite,
+		SQLitePath: filepath.Join(t.TempDir(), "tx.db"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactor := repository.NewGormTransactor(db)
+	repo := repository.NewMemoryOrderRepository()
+	ctx := context.Background()
+
+	order := &domain.Order{ID: "ORD-locked", CustomerID: "cust-1", Status: domain.OrderStatusPending, CreatedAt: time.Now()}
+	if err := repo.Create(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+
+	written := make(chan error, 1)
+	err = transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		locked, err := repo.FindByIDForUpdate(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		// Taking the lock again in the same transaction does not wait
+		if _, err := repo.FindByIDForUpdate(ctx, order.ID); err != nil {
+			return err
+		}
+
+		go func() {
+			cancelled := *order
+			cancelled.Status = domain.OrderStatusCancelled
+			written <- repo.Update(context.Background(), &cancelled)
+		}()
+		select {
+		case err := <-written:
+			t.Errorf("Update of a locked order returned %v before the transaction ended", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		locked.Status = domain.OrderStatusConfirmed
+		return repo.Update(ctx, locked)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-written:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Update still waits after the transaction committed")
+	}
+	got, err := repo.FindByID(ctx, order.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != domain.OrderStatusCancelled {
+		t.Errorf("status = %s, want %s written after the transaction", got.Status, domain.OrderStatusCancelled)
+	}
+}
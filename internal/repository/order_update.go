@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+)
+
+// UpdateOrder applies mutate to order id with its row locked and saves it, in
+// one transaction of tx, so concurrent writers of the order take turns. mutate
+// returns false to leave the order as it is. Status only moves forward: a change
+// that would take the order back is not saved and fails with
+// domain.ErrInvalidStatusTransition. Every saved change bumps updated_at.
+func UpdateOrder(ctx context.Context, tx Transactor, orders OrderRepository, id string, mutate func(o *domain.Order) bool) error {
+	return tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		order, err := orders.FindByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		from := order.Status
+		if !mutate(order) {
+			return nil
+		}
+		if !from.CanMoveTo(order.Status) {
+			return fmt.Errorf("order %s from %s to %s: %w", id, from, order.Status, domain.ErrInvalidStatusTransition)
+		}
+		order.UpdatedAt = time.Now()
+
+		if err := orders.Update(ctx, order); err != nil {
+			return fmt.Errorf("failed to update order %s: %w", id, err)
+		}
+		return nil
+	})
+}
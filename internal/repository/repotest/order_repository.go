@@ -0,0 +1,416 @@
+// Package repotest holds the conformance checks every OrderRepository
+// implementation must pass. Like testing/fstest it reports failures as an
+// error instead of taking a *testing.T:
+// `if err := repotest.TestOrderRepository(...); err != nil { t.Fatal(err) }`.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+)
+
+// Check is one named conformance check
+type Check struct {
+	Name string
+	Run  func(ctx context.Context, repo repository.OrderRepository) error
+}
+
+// OrderRepositoryChecks returns the suite. Checks only touch orders they
+// create (random IDs and customer IDs), so they can run against a database
+// that already holds data.
+func OrderRepositoryChecks() []Check {
+	return []Check{
+		{"create and find", checkCreateAndFind},
+		{"find missing order", checkFindMissing},
+		{"update", checkUpdate},
+		{"update missing order", checkUpdateMissing},
+		{"soft delete", checkSoftDelete},
+		{"find by customer newest first", checkFindByCustomer},
+		{"find all newest first", checkFindAll},
+		{"find stale oldest first", checkFindStale},
+		{"returned orders are copies", checkIsolation},
+		{"concurrent writers", checkConcurrency},
+	}
+}
+
+// TestOrderRepository runs every check, each against a repository returned by
+// newRepo, and returns one error listing all failures (nil when all pass)
+func TestOrderRepository(ctx context.Context, newRepo func() repository.OrderRepository) error {
+	var failures []string
+	for _, check := range OrderRepositoryChecks() {
+		if err := check.Run(ctx, newRepo()); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", check.Name, err))
+		}
+	}
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+// newOrder builds a fully populated order. Times are truncated to microseconds,
+// the precision of Postgres timestamps.
+func newOrder(customerID string, createdAt time.Time) *domain.Order {
+	createdAt = createdAt.Truncate(time.Microsecond)
+	return &domain.Order{
+		ID:            "ORD-" + uuid.New().String()[:8],
+		CustomerID:    customerID,
+		CustomerEmail: customerID + "@example.com",
+		CustomerName:  "Conformance Customer",
+		Items: []domain.OrderItem{
+			{ProductID: "prod-1", ProductName: "Product 1", Quantity: 2,
+				UnitPrice: domain.NewMoney(1000, "USD"), Subtotal: domain.NewMoney(2000, "USD")},
+			{ProductID: "prod-2", ProductName: "Product 2", Quantity: 1,
+				UnitPrice: domain.NewMoney(2500, "USD"), Subtotal: domain.NewMoney(2500, "USD")},
+		},
+		TotalAmount: domain.NewMoney(5126, "USD"),
+		Pricing: domain.PriceBreakdown{
+			Subtotal:   domain.NewMoney(4500, "USD"),
+			Discount:   domain.NewMoney(450, "USD"),
+			Shipping:   domain.NewMoney(500, "USD"),
+			Tax:        domain.NewMoney(576, "USD"),
+			TaxRate:    1422,
+			Total:      domain.NewMoney(5126, "USD"),
+			CouponCode: "TENOFF",
+		},
+		ShippingAddress: domain.Address{
+			Street: "123 Main St", City: "San Francisco", State: "CA", PostalCode: "94102", Country: "USA",
+		},
+		Status:           domain.OrderStatusPending,
+		PaymentStatus:    domain.PaymentStatusPending,
+		PaymentMethod:    "credit_card",
+		ShippingPriority: domain.ShippingExpress,
+		Notes:            "conformance",
+		CreatedAt:        createdAt,
+		UpdatedAt:        createdAt,
+	}
+}
+
+func newCustomerID() string {
+	return "repotest-" + uuid.New().String()[:8]
+}
+
+// sameOrder reports the first field that differs
+func sameOrder(want, got *domain.Order) error {
+	switch {
+	case got.ID != want.ID:
+		return fmt.Errorf("id = %q, want %q", got.ID, want.ID)
+	case got.CustomerID != want.CustomerID || got.CustomerEmail != want.CustomerEmail || got.CustomerName != want.CustomerName:
+		return fmt.Errorf("customer = %q/%q/%q, want %q/%q/%q", got.CustomerID, got.CustomerEmail, got.CustomerName,
+			want.CustomerID, want.CustomerEmail, want.CustomerName)
+	case len(got.Items) != len(want.Items):
+		return fmt.Errorf("%d items, want %d", len(got.Items), len(want.Items))
+	case got.TotalAmount != want.TotalAmount:
+		return fmt.Errorf("total = %s, want %s", got.TotalAmount, want.TotalAmount)
+	case got.Pricing != want.Pricing:
+		return fmt.Errorf("pricing = %+v, want %+v", got.Pricing, want.Pricing)
+	case got.ShippingAddress != want.ShippingAddress:
+		return fmt.Errorf("address = %+v, want %+v", got.ShippingAddress, want.ShippingAddress)
+	case got.Status != want.Status || got.PaymentStatus != want.PaymentStatus:
+		return fmt.Errorf("status = %s/%s, want %s/%s", got.Status, got.PaymentStatus, want.Status, want.PaymentStatus)
+	case got.PaymentMethod != want.PaymentMethod || got.ShippingPriority != want.ShippingPriority:
+		return fmt.Errorf("payment/shipping = %s/%s, want %s/%s", got.PaymentMethod, got.ShippingPriority, want.PaymentMethod, want.ShippingPriority)
+	case got.InvoiceURL != want.InvoiceURL || got.TrackingNumber != want.TrackingNumber || got.Notes != want.Notes:
+		return fmt.Errorf("invoice/tracking/notes = %q/%q/%q, want %q/%q/%q",
+			got.InvoiceURL, got.TrackingNumber, got.Notes, want.InvoiceURL, want.TrackingNumber, want.Notes)
+	case !got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt):
+		return fmt.Errorf("timestamps = %s/%s, want %s/%s", got.CreatedAt, got.UpdatedAt, want.CreatedAt, want.UpdatedAt)
+	}
+	for i := range want.Items {
+		if got.Items[i] != want.Items[i] {
+			return fmt.Errorf("item %d = %+v, want %+v", i, got.Items[i], want.Items[i])
+		}
+	}
+	return nil
+}
+
+func checkCreateAndFind(ctx context.Context, repo repository.OrderRepository) error {
+	order := newOrder(newCustomerID(), time.Now())
+	if err := repo.Create(ctx, order); err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+
+	got, err := repo.FindByID(ctx, order.ID)
+	if err != nil {
+		return fmt.Errorf("find: %w", err)
+	}
+	return sameOrder(order, got)
+}
+
+func checkFindMissing(ctx context.Context, repo repository.OrderRepository) error {
+	if _, err := repo.FindByID(ctx, "ORD-missing-"+uuid.New().String()[:8]); !errors.Is(err, repository.ErrOrderNotFound) {
+		return fmt.Errorf("find = %v, want ErrOrderNotFound", err)
+	}
+	return nil
+}
+
+func checkUpdate(ctx context.Context, repo repository.OrderRepository) error {
+	order := newOrder(newCustomerID(), time.Now())
+	if err := repo.Create(ctx, order); err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+
+	before := order.UpdatedAt
+	order.UpdatePaymentStatus(domain.PaymentStatusCompleted)
+	order.InvoiceURL = "https://invoices.example.com/" + order.ID + ".pdf"
+	order.TrackingNumber = "TRK-123"
+	order.Items = order.Items[:1]
+	order.ShippingAddress.City = "Oakland"
+	if err := repo.Update(ctx, order); err != nil {
+		return fmt.Errorf("update: %w", err)
+	}
+
+	got, err := repo.FindByID(ctx, order.ID)
+	if err != nil {
+		return fmt.Errorf("find: %w", err)
+	}
+	// Implementations may stamp their own update time
+	if got.UpdatedAt.Before(before) {
+		return fmt.Errorf("updated_at = %s, before the previous %s", got.UpdatedAt, before)
+	}
+	order.UpdatedAt = got.UpdatedAt
+	return sameOrder(order, got)
+}
+
+func checkUpdateMissing(ctx context.Context, repo repository.OrderRepository) error {
+	order := newOrder(newCustomerID(), time.Now())
+	if err := repo.Update(ctx, order); !errors.Is(err, repository.ErrOrderNotFound) {
+		return fmt.Errorf("update = %v, want ErrOrderNotFound", err)
+	}
+	return nil
+}
+
+func checkSoftDelete(ctx context.Context, repo repository.OrderRepository) error {
+	customerID := newCustomerID()
+	order := newOrder(customerID, time.Now())
+	if err := repo.Create(ctx, order); err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+	if err := repo.Delete(ctx, order.ID); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	if _, err := repo.FindByID(ctx, order.ID); !errors.Is(err, repository.ErrOrderNotFound) {
+		return fmt.Errorf("find after delete = %v, want ErrOrderNotFound", err)
+	}
+	if err := repo.Update(ctx, order); !errors.Is(err, repository.ErrOrderNotFound) {
+		return fmt.Errorf("update after delete = %v, want ErrOrderNotFound", err)
+	}
+	if err := repo.Delete(ctx, order.ID); !errors.Is(err, repository.ErrOrderNotFound) {
+		return fmt.Errorf("second delete = %v, want ErrOrderNotFound", err)
+	}
+
+	orders, err := repo.FindByCustomerID(ctx, customerID)
+	if err != nil {
+		return fmt.Errorf("find by customer: %w", err)
+	}
+	if len(orders) != 0 {
+		return fmt.Errorf("find by customer returned %d deleted orders", len(orders))
+	}
+
+	// The ID stays taken, as with a primary key
+	if err := repo.Create(ctx, newOrderWithID(order.ID, customerID)); err == nil {
+		return errors.New("create reused the ID of a deleted order")
+	}
+	return nil
+}
+
+func newOrderWithID(id, customerID string) *domain.Order {
+	order := newOrder(customerID, time.Now())
+	order.ID = id
+	return order
+}
+
+// createSpaced creates n orders of a customer, one minute apart, oldest first
+func createSpaced(ctx context.Context, repo repository.OrderRepository, customerID string, n int) ([]*domain.Order, error) {
+	base := time.Now().Add(-time.Hour)
+	orders := make([]*domain.Order, n)
+	// Insert out of order so the result order cannot come from insertion order
+	for _, i := range []int{1, 0, 2}[:n] {
+		orders[i] = newOrder(customerID, base.Add(time.Duration(i)*time.Minute))
+		if err := repo.Create(ctx, orders[i]); err != nil {
+			return nil, fmt.Errorf("create: %w", err)
+		}
+	}
+	return orders, nil
+}
+
+// newestFirst checks that got lists the IDs of want (oldest first) newest first,
+// ignoring orders not in want
+func newestFirst(want []*domain.Order, got []*domain.Order) error {
+	ours := make(map[string]bool, len(want))
+	for _, o := range want {
+		ours[o.ID] = true
+	}
+
+	var ids []string
+	for _, o := range got {
+		if ours[o.ID] {
+			ids = append(ids, o.ID)
+		}
+	}
+	if len(ids) != len(want) {
+		return fmt.Errorf("found %d of %d orders", len(ids), len(want))
+	}
+	for i, id := range ids {
+		if expected := want[len(want)-1-i].ID; id != expected {
+			return fmt.Errorf("position %d = %s, want %s", i, id, expected)
+		}
+	}
+	return nil
+}
+
+func checkFindByCustomer(ctx context.Context, repo repository.OrderRepository) error {
+	customerID := newCustomerID()
+	orders, err := createSpaced(ctx, repo, customerID, 3)
+	if err != nil {
+		return err
+	}
+	if err := repo.Create(ctx, newOrder(newCustomerID(), time.Now())); err != nil {
+		return fmt.Errorf("create other customer: %w", err)
+	}
+
+	got, err := repo.FindByCustomerID(ctx, customerID)
+	if err != nil {
+		return fmt.Errorf("find by customer: %w", err)
+	}
+	if len(got) != len(orders) {
+		return fmt.Errorf("find by customer returned %d orders, want %d", len(got), len(orders))
+	}
+	return newestFirst(orders, got)
+}
+
+func checkFindAll(ctx context.Context, repo repository.OrderRepository) error {
+	orders, err := createSpaced(ctx, repo, newCustomerID(), 3)
+	if err != nil {
+		return err
+	}
+
+	got, err := repo.FindAll(ctx)
+	if err != nil {
+		return fmt.Errorf("find all: %w", err)
+	}
+	return newestFirst(orders, got)
+}
+
+// checkFindStale dates its orders in 2000, older than any real order, so that
+// the limit only has to skip past orders of this check
+func checkFindStale(ctx context.Context, repo repository.OrderRepository) error {
+	base := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	customerID := newCustomerID()
+	orders := make([]*domain.Order, 3)
+	for _, i := range []int{1, 0, 2} {
+		orders[i] = newOrder(customerID, base.Add(time.Duration(i)*time.Minute))
+		if err := repo.Create(ctx, orders[i]); err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+	}
+	confirmed := newOrder(customerID, base)
+	confirmed.Status = domain.OrderStatusConfirmed
+	if err := repo.Create(ctx, confirmed); err != nil {
+		return fmt.Errorf("create confirmed: %w", err)
+	}
+
+	got, err := repo.FindStale(ctx, domain.OrderStatusPending, base.Add(time.Hour), 2)
+	if err != nil {
+		return fmt.Errorf("find stale: %w", err)
+	}
+	if len(got) != 2 {
+		return fmt.Errorf("find stale returned %d orders, want 2", len(got))
+	}
+	for i, o := range got {
+		if o.ID != orders[i].ID {
+			return fmt.Errorf("position %d = %s, want %s", i, o.ID, orders[i].ID)
+		}
+	}
+
+	if got, err = repo.FindStale(ctx, domain.OrderStatusPending, base, 10); err != nil {
+		return fmt.Errorf("find stale: %w", err)
+	}
+	for _, o := range got {
+		if o.CustomerID == customerID {
+			return fmt.Errorf("order %s updated at %s is not older than the cutoff", o.ID, o.UpdatedAt)
+		}
+	}
+	return nil
+}
+
+func checkIsolation(ctx context.Context, repo repository.OrderRepository) error {
+	order := newOrder(newCustomerID(), time.Now())
+	if err := repo.Create(ctx, order); err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+	want := *order
+	want.Items = append([]domain.OrderItem(nil), order.Items...)
+
+	// Changing the caller's copies must not change what is stored
+	order.Items[0].Quantity = 99
+	order.Notes = "changed after create"
+	found, err := repo.FindByID(ctx, order.ID)
+	if err != nil {
+		return fmt.Errorf("find: %w", err)
+	}
+	found.Items[0].Quantity = 42
+	found.Status = domain.OrderStatusCancelled
+
+	got, err := repo.FindByID(ctx, order.ID)
+	if err != nil {
+		return fmt.Errorf("find again: %w", err)
+	}
+	return sameOrder(&want, got)
+}
+
+func checkConcurrency(ctx context.Context, repo repository.OrderRepository) error {
+	const writers = 20
+	customerID := newCustomerID()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			order := newOrder(customerID, time.Now())
+			if err := repo.Create(ctx, order); err != nil {
+				errs <- fmt.Errorf("create: %w", err)
+				return
+			}
+			order.UpdateStatus(domain.OrderStatusProcessing)
+			if err := repo.Update(ctx, order); err != nil {
+				errs <- fmt.Errorf("update: %w", err)
+				return
+			}
+			if _, err := repo.FindByCustomerID(ctx, customerID); err != nil {
+				errs <- fmt.Errorf("find by customer: %w", err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		return err
+	}
+
+	got, err := repo.FindByCustomerID(ctx, customerID)
+	if err != nil {
+		return fmt.Errorf("find by customer: %w", err)
+	}
+	if len(got) != writers {
+		return fmt.Errorf("found %d orders, want %d", len(got), writers)
+	}
+	for _, o := range got {
+		if o.Status != domain.OrderStatusProcessing {
+			return fmt.Errorf("order %s status = %s, want processing", o.ID, o.Status)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Transactor runs work in one database transaction. Repository calls made with
+// the context handed to fn join that transaction.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txKey struct{}
+
+// txState is carried in the context of a running transaction
+type txState struct {
+	tx *gorm.DB
+
+	mu          sync.Mutex
+	afterCommit []func(ctx context.Context)
+	onRollback  []func()
+	onEnd       []func()
+}
+
+// GormTransactor implements Transactor using GORM
+type GormTransactor struct {
+	db *gorm.DB
+}
+
+// NewGormTransactor creates a new GORM-based transactor
+func NewGormTransactor(db *gorm.DB) Transactor {
+	return &GormTransactor{db: db}
+}
+
+// WithinTransaction commits when fn returns nil and rolls back otherwise.
+// Nested calls join the outer transaction.
+func (t *GormTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*txState); ok {
+		return fn(ctx)
+	}
+
+	state := &txState{}
+	var err error
+	func() {
+		// Locks of stores outside the database are released however the
+		// transaction ends, before after-commit hooks may need them
+		defer state.end()
+
+		err = t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			state.tx = tx
+			return fn(context.WithValue(ctx, txKey{}, state))
+		})
+		if err != nil {
+			// Newest first, so repeated writes to one record restore the oldest state
+			for i := len(state.onRollback) - 1; i >= 0; i-- {
+				state.onRollback[i]()
+			}
+		}
+	}()
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range state.afterCommit {
+		hook(ctx)
+	}
+	return nil
+}
+
+// AfterCommit runs fn once the transaction in ctx commits, or right away when
+// ctx carries none. fn gets a context without the transaction.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	state, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		fn(ctx)
+		return
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.afterCommit = append(state.afterCommit, fn)
+}
+
+// OnRollback runs undo if the transaction in ctx rolls back. Stores outside the
+// database use it to drop their writes.
+func OnRollback(ctx context.Context, undo func()) {
+	state, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		return
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.onRollback = append(state.onRollback, undo)
+}
+
+// onEnd runs fn when the transaction in ctx ends, committed or rolled back.
+// It reports false, without running fn, when ctx carries no transaction.
+func onEnd(ctx context.Context, fn func()) bool {
+	state, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		return false
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.onEnd = append(state.onEnd, fn)
+	return true
+}
+
+// end runs the onEnd hooks
+func (s *txState) end() {
+	s.mu.Lock()
+	hooks := s.onEnd
+	s.onEnd = nil
+	s.mu.Unlock()
+	for _, fn := range hooks {
+		fn()
+	}
+}
+
+// conn returns the transaction carried by ctx, or db outside one
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+)
+
+// MaxAnalyticsBuckets bounds the range of one summary: a day of minutes
+const MaxAnalyticsBuckets = 1440
+
+// ErrInvalidAnalyticsRange is returned for empty, reversed or too long summary ranges
+var ErrInvalidAnalyticsRange = errors.New("invalid analytics range")
+
+// AnalyticsSummary rolls up the orders of [From, To)
+type AnalyticsSummary struct {
+	From        time.Time
+	To          time.Time
+	Granularity domain.AnalyticsGranularity
+	Total       *domain.AnalyticsRollup
+	Rollups     []*domain.AnalyticsRollup // Buckets with orders, oldest first
+}
+
+// AnalyticsService defines business logic for analytics reporting
+type AnalyticsService interface {
+	Summary(ctx context.Context, from, to time.Time, granularity domain.AnalyticsGranularity) (*AnalyticsSummary, error)
+}
+
+type analyticsService struct {
+	repo repository.AnalyticsRepository
+}
+
+// NewAnalyticsService creates a new analytics service
+func NewAnalyticsService(repo repository.AnalyticsRepository) AnalyticsService {
+	return &analyticsService{repo: repo}
+}
+
+// Summary rolls up the recorded events per bucket and over the whole range
+func (s *analyticsService) Summary(ctx context.Context, from, to time.Time, granularity domain.AnalyticsGranularity) (*AnalyticsSummary, error) {
+	width := granularity.Duration()
+	switch {
+	case width == 0:
+		return nil, fmt.Errorf("%w: granularity must be minute or hour, got %q", ErrInvalidAnalyticsRange, granularity)
+	case !from.Before(to):
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidAnalyticsRange)
+	case to.Sub(from) > MaxAnalyticsBuckets*width:
+		return nil, fmt.Errorf("%w: at most %d %ss per summary", ErrInvalidAnalyticsRange, MaxAnalyticsBuckets, granularity)
+	}
+
+	buckets, err := s.repo.Buckets(ctx, from, to, width)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &AnalyticsSummary{
+		From:        from,
+		To:          to,
+		Granularity: granularity,
+		Total:       domain.NewAnalyticsRollup(from),
+		Rollups:     []*domain.AnalyticsRollup{},
+	}
+	for _, b := range buckets {
+		// Buckets come sorted by start, each spread over several rows
+		if n := len(summary.Rollups); n == 0 || !summary.Rollups[n-1].Start.Equal(b.Start) {
+			summary.Rollups = append(summary.Rollups, domain.NewAnalyticsRollup(b.Start))
+		}
+		summary.Rollups[len(summary.Rollups)-1].Add(b)
+		summary.Total.Add(b)
+	}
+	return summary, nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/dto"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+)
+
+// CouponService defines business logic for discount codes
+type CouponService interface {
+	CreateCoupon(ctx context.Context, req dto.CreateCouponRequest) (*domain.Coupon, error)
+	GetCoupon(ctx context.Context, code string) (*domain.Coupon, error)
+	ListCoupons(ctx context.Context) ([]*domain.Coupon, error)
+	SetActive(ctx context.Context, code string, active bool) (*domain.Coupon, error)
+}
+
+type couponService struct {
+	repo repository.CouponRepository
+}
+
+// NewCouponService creates a new coupon service
+func NewCouponService(repo repository.CouponRepository) CouponService {
+	return &couponService{repo: repo}
+}
+
+// CreateCoupon validates and stores a coupon. Codes are case-insensitive.
+func (s *couponService) CreateCoupon(ctx context.Context, req dto.CreateCouponRequest) (*domain.Coupon, error) {
+	currency := req.Currency
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+
+	now := time.Now()
+	coupon := &domain.Coupon{
+		Code:      strings.ToUpper(req.Code),
+		Type:      domain.CouponType(req.Type),
+		Amount:    domain.NewMoney(0, currency),
+		MinSpend:  domain.NewMoney(0, currency),
+		MaxUses:   req.MaxUses,
+		Active:    true,
+		ExpiresAt: req.ExpiresAt,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	switch coupon.Type {
+	case domain.CouponTypePercentage:
+		percent, err := domain.ParsePercent(req.Percent.String())
+		if err != nil || percent <= 0 || percent > 10000 {
+			return nil, fmt.Errorf("%w: percent must be between 0 and 100", domain.ErrInvalidAmount)
+		}
+		coupon.Percent = percent
+	case domain.CouponTypeFixed:
+		amount, err := parsePrice(req.Amount.String(), currency)
+		if err != nil {
+			return nil, err
+		}
+		coupon.Amount = amount
+	}
+
+	if req.MinSpend != "" {
+		minSpend, err := domain.ParseMoney(req.MinSpend.String(), currency)
+		if err != nil {
+			return nil, err
+		}
+		if minSpend.Amount < 0 {
+			return nil, fmt.Errorf("%w: min_spend cannot be negative", domain.ErrInvalidAmount)
+		}
+		coupon.MinSpend = minSpend
+	}
+
+	if err := s.repo.Create(ctx, coupon); err != nil {
+		return nil, fmt.Errorf("failed to create coupon: %w", err)
+	}
+	return coupon, nil
+}
+
+// GetCoupon retrieves a coupon by code
+func (s *couponService) GetCoupon(ctx context.Context, code string) (*domain.Coupon, error) {
+	return s.repo.FindByCode(ctx, strings.ToUpper(code))
+}
+
+// ListCoupons retrieves all coupons
+func (s *couponService) ListCoupons(ctx context.Context) ([]*domain.Coupon, error) {
+	return s.repo.FindAll(ctx)
+}
+
+// SetActive enables or disables a coupon; uses already taken are kept
+func (s *couponService) SetActive(ctx context.Context, code string, active bool) (*domain.Coupon, error) {
+	code = strings.ToUpper(code)
+	if err := s.repo.SetActive(ctx, code, active); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByCode(ctx, code)
+}
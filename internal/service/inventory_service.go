@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/dto"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+)
+
+// InventoryService defines business logic for the product catalog and stock levels
+type InventoryService interface {
+	CreateProduct(ctx context.Context, req dto.CreateProductRequest) (*domain.Product, error)
+	GetProduct(ctx context.Context, id string) (*domain.Product, error)
+	ListProducts(ctx context.Context) ([]*domain.Product, error)
+	UpdateProduct(ctx context.Context, id string, req dto.UpdateProductRequest) (*domain.Product, error)
+	DeleteProduct(ctx context.Context, id string) error
+
+	GetStock(ctx context.Context, productID string) (*domain.StockLevel, error)
+	ListStock(ctx context.Context) ([]*domain.StockLevel, error)
+	SetStock(ctx context.Context, productID string, onHand int) (*domain.StockLevel, error)
+	AdjustStock(ctx context.Context, productID string, delta int) (*domain.StockLevel, error)
+}
+
+type inventoryService struct {
+	repo repository.InventoryRepository
+}
+
+// NewInventoryService creates a new inventory service
+func NewInventoryService(repo repository.InventoryRepository) InventoryService {
+	return &inventoryService{repo: repo}
+}
+
+// CreateProduct adds a product with its initial stock
+func (s *inventoryService) CreateProduct(ctx context.Context, req dto.CreateProductRequest) (*domain.Product, error) {
+	currency := req.Currency
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+	price, err := parsePrice(req.Price.String(), currency)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	product := &domain.Product{
+		ID:        req.ID,
+		Name:      req.Name,
+		Price:     price,
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.repo.CreateProduct(ctx, product, req.InitialStock); err != nil {
+		return nil, fmt.Errorf("failed to create product: %w", err)
+	}
+	return product, nil
+}
+
+// GetProduct retrieves a product by ID
+func (s *inventoryService) GetProduct(ctx context.Context, id string) (*domain.Product, error) {
+	return s.repo.FindProductByID(ctx, id)
+}
+
+// ListProducts retrieves all products
+func (s *inventoryService) ListProducts(ctx context.Context) ([]*domain.Product, error) {
+	return s.repo.FindAllProducts(ctx)
+}
+
+// UpdateProduct applies the fields present in the request
+func (s *inventoryService) UpdateProduct(ctx context.Context, id string, req dto.UpdateProductRequest) (*domain.Product, error) {
+	product, err := s.repo.FindProductByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		product.Name = *req.Name
+	}
+	if req.Price != nil {
+		price, err := parsePrice(req.Price.String(), product.Price.Currency)
+		if err != nil {
+			return nil, err
+		}
+		product.Price = price
+	}
+	if req.Active != nil {
+		product.Active = *req.Active
+	}
+	product.UpdatedAt = time.Now()
+
+	if err := s.repo.UpdateProduct(ctx, product); err != nil {
+		return nil, fmt.Errorf("failed to update product: %w", err)
+	}
+	return product, nil
+}
+
+// DeleteProduct removes a product from the catalog
+func (s *inventoryService) DeleteProduct(ctx context.Context, id string) error {
+	return s.repo.DeleteProduct(ctx, id)
+}
+
+// GetStock retrieves the stock level of a product
+func (s *inventoryService) GetStock(ctx context.Context, productID string) (*domain.StockLevel, error) {
+	return s.repo.FindStock(ctx, productID)
+}
+
+// ListStock retrieves stock levels of all products
+func (s *inventoryService) ListStock(ctx context.Context) ([]*domain.StockLevel, error) {
+	return s.repo.FindAllStock(ctx)
+}
+
+// SetStock sets on-hand units of a product
+func (s *inventoryService) SetStock(ctx context.Context, productID string, onHand int) (*domain.StockLevel, error) {
+	return s.repo.SetStock(ctx, productID, onHand)
+}
+
+// AdjustStock adds (restock) or removes (damage, loss) on-hand units
+func (s *inventoryService) AdjustStock(ctx context.Context, productID string, delta int) (*domain.StockLevel, error) {
+	return s.repo.AdjustStock(ctx, productID, delta)
+}
+
+// parsePrice parses a catalog price, which must be positive
+func parsePrice(s, currency string) (domain.Money, error) {
+	price, err := domain.ParseMoney(s, currency)
+	if err != nil {
+		return domain.Money{}, err
+	}
+	if price.Amount <= 0 {
+		return domain.Money{}, fmt.Errorf("%w: price must be positive", domain.ErrInvalidAmount)
+	}
+	return price, nil
+}
+
+// SeedCatalog creates the catalog used by the k6 scripts, products prod-0 to
+// prod-<count-1> priced 10.00, 20.00, ... with stock on-hand units each.
+// Existing products only get their stock set to stock.
+func SeedCatalog(ctx context.Context, repo repository.InventoryRepository, count, stock int) (created, restocked int, err error) {
+	for i := 0; i < count; i++ {
+		now := time.Now()
+		product := &domain.Product{
+			ID:        fmt.Sprintf("prod-%d", i),
+			Name:      fmt.Sprintf("Product %d", i),
+			Price:     domain.NewMoney(int64(1000+i*1000), domain.DefaultCurrency),
+			Active:    true,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		err := repo.CreateProduct(ctx, product, stock)
+		if errors.Is(err, repository.ErrProductExists) {
+			if _, err := repo.SetStock(ctx, product.ID, stock); err != nil {
+				return created, restocked, fmt.Errorf("failed to set stock for %s: %w", product.ID, err)
+			}
+			restocked++
+			continue
+		}
+		if err != nil {
+			return created, restocked, fmt.Errorf("failed to create %s: %w", product.ID, err)
+		}
+		created++
+	}
+	return created, restocked, nil
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/invoice"
+	"github.com/lppduy/go-asynq-loadtest/internal/storage"
+)
+
+// ErrInvoiceNotReady is returned until invoice:generate has stored the invoice of an order
+var ErrInvoiceNotReady = errors.New("invoice not generated yet")
+
+// InvoiceDownload is either a link to the invoice or the file itself
+type InvoiceDownload struct {
+	URL  string        // Signed link to redirect to, when the store has them
+	Body io.ReadCloser // Otherwise the PDF, closed by the caller
+	Info storage.BlobInfo
+}
+
+// InvoiceService defines business logic for invoice downloads
+type InvoiceService interface {
+	Download(ctx context.Context, order *domain.Order) (*InvoiceDownload, error)
+}
+
+type invoiceService struct {
+	blobs           storage.BlobStore
+	signedURLExpiry time.Duration
+}
+
+// NewInvoiceService creates a new invoice service
+func NewInvoiceService(blobs storage.BlobStore, signedURLExpiry time.Duration) InvoiceService {
+	return &invoiceService{blobs: blobs, signedURLExpiry: signedURLExpiry}
+}
+
+// Download prefers a signed link, so the API does not proxy the file
+func (s *invoiceService) Download(ctx context.Context, order *domain.Order) (*InvoiceDownload, error) {
+	if order.InvoiceURL == "" {
+		return nil, ErrInvoiceNotReady
+	}
+	key := invoice.Key(order.ID)
+
+	url, err := s.blobs.SignedURL(ctx, key, s.signedURLExpiry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign invoice URL: %w", err)
+	}
+	if url != "" {
+		return &InvoiceDownload{URL: url}, nil
+	}
+
+	body, info, err := s.blobs.Get(ctx, key)
+	if errors.Is(err, storage.ErrBlobNotFound) {
+		return nil, fmt.Errorf("%w: %s is not in the store", ErrInvoiceNotReady, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open invoice: %w", err)
+	}
+	return &InvoiceDownload{Body: body, Info: info}, nil
+}
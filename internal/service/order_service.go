@@ -2,7 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,67 +18,175 @@ import (
 // OrderService defines business logic for orders
 type OrderService interface {
 	CreateOrder(ctx context.Context, req dto.CreateOrderRequest) (*domain.Order, error)
+	CreateOrders(ctx context.Context, reqs []dto.CreateOrderRequest, mode string) ([]BatchOrderResult, error)
 	GetOrder(ctx context.Context, id string) (*domain.Order, error)
 	ListOrders(ctx context.Context, customerID string) ([]*domain.Order, error)
+	UpdateOrder(ctx context.Context, id string, req dto.UpdateOrderRequest, replaceTasks TaskReplacer) (*domain.Order, error)
 	CancelOrder(ctx context.Context, id string, reason string) (*domain.Order, error)
 	GetOrderStatus(ctx context.Context, id string) (*domain.Order, error)
 }
 
 type orderService struct {
-	repo repository.OrderRepository
+	repo          repository.OrderRepository
+	inventoryRepo repository.InventoryRepository
+	couponRepo    repository.CouponRepository
+	tx            repository.Transactor
+	pricer        *pricer
 }
 
 // NewOrderService creates a new order service
-func NewOrderService(repo repository.OrderRepository) OrderService {
-	return &orderService{repo: repo}
+func NewOrderService(repo repository.OrderRepository, inventoryRepo repository.InventoryRepository, couponRepo repository.CouponRepository, tx repository.Transactor, pricing PricingRules) OrderService {
+	return &orderService{
+		repo:          repo,
+		inventoryRepo: inventoryRepo,
+		couponRepo:    couponRepo,
+		tx:            tx,
+		pricer:        newPricer(pricing, couponRepo),
+	}
+}
+
+// ErrOrderNotEditable is returned by UpdateOrder once payment has started
+var ErrOrderNotEditable = errors.New("order can no longer be edited")
+
+// TaskReplacer swaps the scheduled tasks of an edited order, which carry its
+// old items and totals, for ones carrying the new state. UpdateOrder calls it
+// with the order row locked, so edits of one order take turns, and rolls the
+// edit back when it fails.
+type TaskReplacer func(ctx context.Context, before, after *domain.Order) error
+
+// Batch modes of CreateOrders
+const (
+	BatchModeAtomic  = "atomic"  // All orders in one transaction, or none
+	BatchModePartial = "partial" // Every order is created or refused on its own
+)
+
+// ErrBatchRolledBack is the result of orders of an atomic batch that were not
+// kept because another order of the batch failed
+var ErrBatchRolledBack = errors.New("not created: another order of the batch failed")
+
+// errBatchItemFailed aborts the transaction of an atomic batch
+var errBatchItemFailed = errors.New("batch order failed")
+
+// BatchOrderResult is the outcome of one order of a batch
+type BatchOrderResult struct {
+	Order *domain.Order // Set when the order was created
+	Err   error
+}
+
+// Item error codes returned by CreateOrder and UpdateOrder
+const (
+	ItemErrorProductNotFound   = "PRODUCT_NOT_FOUND"
+	ItemErrorProductInactive   = "PRODUCT_INACTIVE"
+	ItemErrorPriceMismatch     = "PRICE_MISMATCH"
+	ItemErrorCurrencyMismatch  = "CURRENCY_MISMATCH"
+	ItemErrorInsufficientStock = "INSUFFICIENT_STOCK"
+)
+
+// ItemError describes why one order item was refused
+type ItemError struct {
+	Index     int
+	ProductID string
+	Code      string
+	Message   string
+}
+
+// ErrOrderValidation is returned when one or more items cannot be ordered
+type ErrOrderValidation struct {
+	Items []ItemError
+}
+
+func (e *ErrOrderValidation) Error() string {
+	return fmt.Sprintf("order has %d invalid item(s)", len(e.Items))
 }
 
-// CreateOrder creates a new order
+// CreateOrder validates items against the catalog, prices the order, reserves its stock,
+// redeems its coupon and saves it. Stock and coupon uses are taken with conditional
+// updates, so concurrent orders only contend when they share a product or coupon, and
+// two orders can never take the same last unit or the same last coupon use.
 func (s *orderService) CreateOrder(ctx context.Context, req dto.CreateOrderRequest) (*domain.Order, error) {
 	// Generate order ID
 	orderID := generateOrderID()
 
-	// Convert items and calculate totals
-	items := make([]domain.OrderItem, len(req.Items))
-	totalAmount := 0.0
+	currency := req.Currency
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+	if !domain.IsSupportedCurrency(currency) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrUnsupportedCurrency, currency)
+	}
 
-	for i, item := range req.Items {
-		subtotal := item.UnitPrice * float64(item.Quantity)
-		items[i] = domain.OrderItem{
-			ProductID:   item.ProductID,
-			ProductName: item.ProductName,
-			Quantity:    item.Quantity,
-			UnitPrice:   item.UnitPrice,
-			Subtotal:    subtotal,
+	items, err := s.priceItems(ctx, req.Items, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	priority := req.ShippingPriority
+	if priority == "" {
+		priority = domain.ShippingStandard
+	}
+	couponCode := strings.ToUpper(strings.TrimSpace(req.CouponCode))
+
+	// Subtotal, coupon discount, shipping and tax
+	quote, err := s.pricer.Quote(ctx, items, currency, req.ShippingAddress, priority, couponCode)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cheap unlocked check first so the client gets every short item at once
+	requests := stockRequests(items)
+	if err := s.checkAvailability(ctx, requests); err != nil {
+		return nil, err
+	}
+
+	// The reservation is what actually prevents overselling
+	if err := s.inventoryRepo.Reserve(ctx, orderID, requests); err != nil {
+		return nil, reservationError(requests, err)
+	}
+
+	// The usage limit is enforced here, not by the quote
+	if quote.coupon != nil {
+		if err := s.couponRepo.Redeem(ctx, quote.coupon.Code, orderID, quote.breakdown.Discount); err != nil {
+			s.releaseStock(ctx, orderID, "order with unusable coupon")
+			switch {
+			case errors.Is(err, repository.ErrCouponExhausted):
+				return nil, &ErrInvalidCoupon{Code: CouponErrorExhausted, CouponCode: quote.coupon.Code, Message: "coupon has been used up"}
+			case errors.Is(err, repository.ErrCouponNotFound):
+				return nil, &ErrInvalidCoupon{Code: CouponErrorNotFound, CouponCode: quote.coupon.Code, Message: "coupon does not exist"}
+			}
+			return nil, fmt.Errorf("failed to redeem coupon: %w", err)
 		}
-		totalAmount += subtotal
 	}
 
 	// Create order
 	now := time.Now()
 	order := &domain.Order{
-		ID:              orderID,
-		CustomerID:      req.CustomerID,
-		CustomerEmail:   req.CustomerEmail,
-		Items:           items,
-		TotalAmount:     totalAmount,
-		ShippingAddress: req.ShippingAddress,
-		Status:          domain.OrderStatusPending,
-		PaymentStatus:   domain.PaymentStatusPending,
-		PaymentMethod:   req.PaymentMethod,
-		Notes:           req.Notes,
-		CreatedAt:       now,
-		UpdatedAt:       now,
+		ID:               orderID,
+		CustomerID:       req.CustomerID,
+		CustomerEmail:    req.CustomerEmail,
+		CustomerName:     req.CustomerName,
+		Items:            items,
+		TotalAmount:      quote.breakdown.Total,
+		Pricing:          quote.breakdown,
+		ShippingAddress:  req.ShippingAddress,
+		Status:           domain.OrderStatusPending,
+		PaymentStatus:    domain.PaymentStatusPending,
+		PaymentMethod:    req.PaymentMethod,
+		ShippingPriority: priority,
+		Notes:            req.Notes,
+		CreatedAt:        now,
+		UpdatedAt:        now,
 	}
 
 	// Save to repository
 	if err := s.repo.Create(ctx, order); err != nil {
+		s.releaseStock(ctx, orderID, "unsaved order")
+		s.releaseCoupon(ctx, orderID, "unsaved order")
 		return nil, fmt.Errorf("failed to create order: %w", err)
 	}
 
 	// Note: Background jobs will be enqueued by the handler
 	// - payment:process
-	// - inventory:update
+	// - inventory:update (stock is already reserved; confirms the reservation)
 	// - email:confirmation
 	// - invoice:generate
 	// - analytics:track
@@ -83,6 +195,259 @@ func (s *orderService) CreateOrder(ctx context.Context, req dto.CreateOrderReque
 	return order, nil
 }
 
+// CreateOrders creates a batch of orders and returns one result per request, in
+// order. In partial mode each order goes through CreateOrder on its own. In atomic
+// mode all of them are written in one transaction: the first failure rolls the
+// batch back and every other order gets ErrBatchRolledBack. The error is only set
+// when the transaction itself fails.
+func (s *orderService) CreateOrders(ctx context.Context, reqs []dto.CreateOrderRequest, mode string) ([]BatchOrderResult, error) {
+	results := make([]BatchOrderResult, len(reqs))
+	if mode == BatchModePartial {
+		for i, req := range reqs {
+			results[i].Order, results[i].Err = s.CreateOrder(ctx, req)
+		}
+		return results, nil
+	}
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Lock all stock rows up front and in product order; orders of the
+		// batch would otherwise lock them in request order and could deadlock
+		// with another batch
+		if err := s.inventoryRepo.LockStock(ctx, batchProductIDs(reqs)); err != nil {
+			return err
+		}
+
+		for i, req := range reqs {
+			order, err := s.CreateOrder(ctx, req)
+			if err != nil {
+				results[i].Err = err
+				return errBatchItemFailed
+			}
+			results[i].Order = order
+		}
+		return nil
+	})
+	if err == nil {
+		return results, nil
+	}
+
+	for i := range results {
+		results[i].Order = nil
+		if results[i].Err == nil {
+			results[i].Err = ErrBatchRolledBack
+		}
+	}
+	if errors.Is(err, errBatchItemFailed) {
+		return results, nil
+	}
+	return nil, fmt.Errorf("failed to create orders: %w", err)
+}
+
+// batchProductIDs lists the distinct products of a batch
+func batchProductIDs(reqs []dto.CreateOrderRequest) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, req := range reqs {
+		for _, item := range req.Items {
+			if !seen[item.ProductID] {
+				seen[item.ProductID] = true
+				ids = append(ids, item.ProductID)
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// UpdateOrder replaces the items, shipping address and/or notes of a pending order
+// and recalculates its totals. The stock hold, the order and its scheduled tasks
+// change in one transaction, so a failed edit leaves the order as it was.
+func (s *orderService) UpdateOrder(ctx context.Context, id string, req dto.UpdateOrderRequest, replaceTasks TaskReplacer) (*domain.Order, error) {
+	var order *domain.Order
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		order, err = s.repo.FindByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !order.CanEdit() {
+			return fmt.Errorf("%w (current status: %s)", ErrOrderNotEditable, order.Status)
+		}
+		before := *order
+
+		currency := order.TotalAmount.Currency
+		items := order.Items
+		if req.Items != nil {
+			if items, err = s.priceItems(ctx, req.Items, currency); err != nil {
+				return err
+			}
+		}
+		address := order.ShippingAddress
+		if req.ShippingAddress != nil {
+			address = *req.ShippingAddress
+		}
+
+		quote, err := s.pricer.Requote(ctx, items, currency, address, order.ShippingPriority, order.Pricing.CouponCode)
+		if err != nil {
+			return err
+		}
+
+		if req.Items != nil {
+			if err := s.inventoryRepo.ReplaceReservation(ctx, id, stockRequests(items)); err != nil {
+				return reservationError(stockRequests(items), err)
+			}
+		}
+
+		order.Items = items
+		order.ShippingAddress = address
+		if req.Notes != nil {
+			order.Notes = *req.Notes
+		}
+		order.TotalAmount = quote.breakdown.Total
+		order.Pricing = quote.breakdown
+		order.UpdatedAt = time.Now()
+
+		if err := s.repo.Update(ctx, order); err != nil {
+			return fmt.Errorf("failed to update order: %w", err)
+		}
+
+		// Informational only, the use itself does not change
+		if quote.coupon != nil {
+			if err := s.couponRepo.UpdateDiscount(ctx, id, quote.breakdown.Discount); err != nil {
+				return fmt.Errorf("failed to update coupon discount: %w", err)
+			}
+		}
+
+		return replaceTasks(ctx, &before, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// stockRequests lists the stock needed by order items
+func stockRequests(items []domain.OrderItem) []domain.StockRequest {
+	requests := make([]domain.StockRequest, len(items))
+	for i, item := range items {
+		requests[i] = domain.StockRequest{ProductID: item.ProductID, Quantity: item.Quantity}
+	}
+	return requests
+}
+
+// reservationError turns a refused reservation into per-item errors
+func reservationError(requests []domain.StockRequest, err error) error {
+	var short *repository.InsufficientStockError
+	if errors.As(err, &short) {
+		return &ErrOrderValidation{Items: itemErrorsFor(requests, short.ProductID, ItemErrorInsufficientStock,
+			fmt.Sprintf("requested %d, available %d", short.Requested, short.Available))}
+	}
+	return fmt.Errorf("failed to reserve stock: %w", err)
+}
+
+// priceItems resolves every item against the catalog. Name and price come from
+// the catalog; a unit_price sent by the client must match it.
+func (s *orderService) priceItems(ctx context.Context, reqItems []dto.CreateOrderItemRequest, currency string) ([]domain.OrderItem, error) {
+	ids := make([]string, 0, len(reqItems))
+	for _, item := range reqItems {
+		ids = append(ids, item.ProductID)
+	}
+
+	products, err := s.inventoryRepo.FindProductsByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load products: %w", err)
+	}
+
+	var invalid []ItemError
+	items := make([]domain.OrderItem, len(reqItems))
+	for i, item := range reqItems {
+		product, ok := products[item.ProductID]
+		switch {
+		case !ok:
+			invalid = append(invalid, ItemError{Index: i, ProductID: item.ProductID,
+				Code: ItemErrorProductNotFound, Message: "product does not exist"})
+			continue
+		case !product.Active:
+			invalid = append(invalid, ItemError{Index: i, ProductID: item.ProductID,
+				Code: ItemErrorProductInactive, Message: "product is no longer sold"})
+			continue
+		case product.Price.Currency != currency:
+			invalid = append(invalid, ItemError{Index: i, ProductID: item.ProductID,
+				Code: ItemErrorCurrencyMismatch, Message: "product is priced in " + product.Price.Currency})
+			continue
+		case item.UnitPrice != "" && !priceMatches(item.UnitPrice.String(), product.Price):
+			invalid = append(invalid, ItemError{Index: i, ProductID: item.ProductID,
+				Code: ItemErrorPriceMismatch, Message: "unit price is " + product.Price.Decimal()})
+			continue
+		}
+
+		items[i] = domain.OrderItem{
+			ProductID:   product.ID,
+			ProductName: product.Name,
+			Quantity:    item.Quantity,
+			UnitPrice:   product.Price,
+			Subtotal:    product.Price.Mul(int64(item.Quantity)),
+		}
+	}
+
+	if len(invalid) > 0 {
+		return nil, &ErrOrderValidation{Items: invalid}
+	}
+	return items, nil
+}
+
+// priceMatches compares a client-sent decimal price with the catalog price exactly
+func priceMatches(sent string, price domain.Money) bool {
+	parsed, err := domain.ParseMoney(sent, price.Currency)
+	return err == nil && parsed == price
+}
+
+// checkAvailability reports every product whose available stock is below the ordered total
+func (s *orderService) checkAvailability(ctx context.Context, requests []domain.StockRequest) error {
+	totals := make(map[string]int, len(requests))
+	ids := make([]string, 0, len(requests))
+	for _, r := range requests {
+		if _, seen := totals[r.ProductID]; !seen {
+			ids = append(ids, r.ProductID)
+		}
+		totals[r.ProductID] += r.Quantity
+	}
+
+	levels, err := s.inventoryRepo.FindStockByProductIDs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to load stock: %w", err)
+	}
+
+	var invalid []ItemError
+	for _, id := range ids {
+		available := 0
+		if level, ok := levels[id]; ok {
+			available = level.Available()
+		}
+		if available < totals[id] {
+			invalid = append(invalid, itemErrorsFor(requests, id, ItemErrorInsufficientStock,
+				fmt.Sprintf("requested %d, available %d", totals[id], available))...)
+		}
+	}
+
+	if len(invalid) > 0 {
+		sort.Slice(invalid, func(i, j int) bool { return invalid[i].Index < invalid[j].Index })
+		return &ErrOrderValidation{Items: invalid}
+	}
+	return nil
+}
+
+// itemErrorsFor builds one error per order line of the given product
+func itemErrorsFor(requests []domain.StockRequest, productID, code, message string) []ItemError {
+	var errs []ItemError
+	for i, r := range requests {
+		if r.ProductID == productID {
+			errs = append(errs, ItemError{Index: i, ProductID: productID, Code: code, Message: message})
+		}
+	}
+	return errs
+}
+
 // GetOrder retrieves an order by ID
 func (s *orderService) GetOrder(ctx context.Context, id string) (*domain.Order, error) {
 	return s.repo.FindByID(ctx, id)
@@ -97,32 +462,60 @@ func (s *orderService) ListOrders(ctx context.Context, customerID string) ([]*do
 	return s.repo.FindByCustomerID(ctx, customerID)
 }
 
-// CancelOrder cancels an order
+// CancelOrder cancels an order. The order row is locked from the status check to
+// the write, so a payment task committing in between cannot be overwritten;
+// stock and the coupon use are given back once the cancellation commits.
 func (s *orderService) CancelOrder(ctx context.Context, id string, reason string) (*domain.Order, error) {
-	// Get order
-	order, err := s.repo.FindByID(ctx, id)
+	var order *domain.Order
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		order, err = s.repo.FindByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		// Check if can cancel
+		if !order.CanCancel() || !order.Status.CanMoveTo(domain.OrderStatusCancelled) {
+			return fmt.Errorf("order cannot be cancelled (current status: %s)", order.Status)
+		}
+
+		// Cancel order
+		order.Cancel()
+		order.Notes = fmt.Sprintf("Cancelled: %s", reason)
+
+		if err := s.repo.Update(ctx, order); err != nil {
+			return fmt.Errorf("failed to cancel order: %w", err)
+		}
+
+		// Return reserved stock and the coupon use; the order is already cancelled, so a
+		// failure here only leaves them held until an operator adjusts them
+		repository.AfterCommit(ctx, func(ctx context.Context) {
+			s.releaseStock(ctx, id, "cancelled order")
+			s.releaseCoupon(ctx, id, "cancelled order")
+		})
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Check if can cancel
-	if !order.CanCancel() {
-		return nil, fmt.Errorf("order cannot be cancelled (current status: %s)", order.Status)
-	}
+	// Paid orders are refunded by payment:refund, enqueued by the handler
 
-	// Cancel order
-	order.Cancel()
-	order.Notes = fmt.Sprintf("Cancelled: %s", reason)
+	return order, nil
+}
 
-	// Update repository
-	if err := s.repo.Update(ctx, order); err != nil {
-		return nil, fmt.Errorf("failed to cancel order: %w", err)
+// releaseStock returns the stock held for an order, logging failures
+func (s *orderService) releaseStock(ctx context.Context, orderID, what string) {
+	if err := s.inventoryRepo.Release(ctx, orderID); err != nil {
+		log.Printf("⚠️  [Inventory] Failed to release stock for %s %s: %v", what, orderID, err)
 	}
+}
 
-	// Note: Background job to process refund will be enqueued by handler
-	// - payment:refund
-
-	return order, nil
+// releaseCoupon gives back the coupon use of an order, logging failures
+func (s *orderService) releaseCoupon(ctx context.Context, orderID, what string) {
+	if err := s.couponRepo.Release(ctx, orderID); err != nil {
+		log.Printf("⚠️  [Coupon] Failed to release coupon for %s %s: %v", what, orderID, err)
+	}
 }
 
 // GetOrderStatus retrieves order status
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+)
+
+// Coupon error codes returned by CreateOrder
+const (
+	CouponErrorNotFound         = "COUPON_NOT_FOUND"
+	CouponErrorExpired          = "COUPON_EXPIRED"
+	CouponErrorExhausted        = "COUPON_EXHAUSTED"
+	CouponErrorMinSpendNotMet   = "COUPON_MIN_SPEND_NOT_MET"
+	CouponErrorCurrencyMismatch = "COUPON_CURRENCY_MISMATCH"
+)
+
+// ErrInvalidCoupon is returned when the coupon of an order cannot be applied
+type ErrInvalidCoupon struct {
+	Code       string
+	CouponCode string
+	Message    string
+}
+
+func (e *ErrInvalidCoupon) Error() string {
+	return fmt.Sprintf("coupon %s cannot be applied: %s", e.CouponCode, e.Message)
+}
+
+// PricingRules holds the tax and shipping settings applied to every order
+type PricingRules struct {
+	TaxRates       map[string]domain.BasisPoints // Keyed by upper-case shipping country
+	DefaultTaxRate domain.BasisPoints            // Countries not in TaxRates
+	ShippingFees   map[string]string             // Priority -> decimal fee in the order currency
+	// Standard shipping is free from this discounted subtotal ("" = never)
+	FreeShippingOver string
+}
+
+// NewPricingRules parses pricing settings:
+// taxRates "US:7.25,DE:19" (percent), shippingFees "standard:5.00,express:15.00"
+func NewPricingRules(taxRates, defaultTaxRate, shippingFees, freeShippingOver string) (PricingRules, error) {
+	rules := PricingRules{
+		TaxRates:         map[string]domain.BasisPoints{},
+		ShippingFees:     map[string]string{},
+		FreeShippingOver: strings.TrimSpace(freeShippingOver),
+	}
+
+	for country, rate := range splitPairs(taxRates) {
+		bp, err := domain.ParsePercent(rate)
+		if err != nil || bp < 0 {
+			return PricingRules{}, fmt.Errorf("invalid tax rate for %s: %q", country, rate)
+		}
+		rules.TaxRates[strings.ToUpper(country)] = bp
+	}
+
+	if defaultTaxRate != "" {
+		bp, err := domain.ParsePercent(defaultTaxRate)
+		if err != nil || bp < 0 {
+			return PricingRules{}, fmt.Errorf("invalid default tax rate: %q", defaultTaxRate)
+		}
+		rules.DefaultTaxRate = bp
+	}
+
+	for priority, fee := range splitPairs(shippingFees) {
+		if _, err := domain.ParseMoney(fee, domain.DefaultCurrency); err != nil {
+			return PricingRules{}, fmt.Errorf("invalid shipping fee for %s: %q", priority, fee)
+		}
+		rules.ShippingFees[strings.ToLower(priority)] = fee
+	}
+
+	if rules.FreeShippingOver != "" {
+		if _, err := domain.ParseMoney(rules.FreeShippingOver, domain.DefaultCurrency); err != nil {
+			return PricingRules{}, fmt.Errorf("invalid free shipping threshold: %q", rules.FreeShippingOver)
+		}
+	}
+
+	return rules, nil
+}
+
+// splitPairs parses "a:1,b:2"; malformed entries are skipped
+func splitPairs(s string) map[string]string {
+	pairs := map[string]string{}
+	for _, entry := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || key == "" {
+			continue
+		}
+		pairs[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return pairs
+}
+
+// TaxRate returns the rate applied to orders shipped to a country
+func (r PricingRules) TaxRate(country string) domain.BasisPoints {
+	if rate, ok := r.TaxRates[strings.ToUpper(strings.TrimSpace(country))]; ok {
+		return rate
+	}
+	return r.DefaultTaxRate
+}
+
+// quote is the input and running result of the pricing pipeline
+type quote struct {
+	items      []domain.OrderItem
+	currency   string
+	country    string
+	priority   string
+	couponCode string
+	redeemed   bool // The order already holds a use of the coupon
+
+	coupon    *domain.Coupon
+	breakdown domain.PriceBreakdown
+}
+
+// pricingStep fills in one part of the price breakdown
+type pricingStep func(ctx context.Context, q *quote) error
+
+// pricer computes order totals: subtotal, coupon discount, shipping, tax, total.
+// Steps run in order; each may use what the previous ones computed.
+type pricer struct {
+	rules   PricingRules
+	coupons repository.CouponRepository
+	steps   []pricingStep
+}
+
+func newPricer(rules PricingRules, coupons repository.CouponRepository) *pricer {
+	p := &pricer{rules: rules, coupons: coupons}
+	p.steps = []pricingStep{
+		p.subtotal,
+		p.discount,
+		p.shipping,
+		p.tax,
+		p.total,
+	}
+	return p
+}
+
+// Quote prices items shipped to address. The coupon is only checked here;
+// its use is taken when the order is saved.
+func (p *pricer) Quote(ctx context.Context, items []domain.OrderItem, currency string, address domain.Address, priority, couponCode string) (*quote, error) {
+	q := &quote{
+		items:      items,
+		currency:   currency,
+		country:    address.Country,
+		priority:   priority,
+		couponCode: couponCode,
+	}
+	return p.run(ctx, q)
+}
+
+// Requote prices an edited order. Its coupon use is already taken, so only the
+// conditions that depend on the new items (currency, minimum spend) are checked again.
+func (p *pricer) Requote(ctx context.Context, items []domain.OrderItem, currency string, address domain.Address, priority, couponCode string) (*quote, error) {
+	q := &quote{
+		items:      items,
+		currency:   currency,
+		country:    address.Country,
+		priority:   priority,
+		couponCode: couponCode,
+		redeemed:   true,
+	}
+	return p.run(ctx, q)
+}
+
+func (p *pricer) run(ctx context.Context, q *quote) (*quote, error) {
+	for _, step := range p.steps {
+		if err := step(ctx, q); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+// subtotal sums the item subtotals
+func (p *pricer) subtotal(_ context.Context, q *quote) error {
+	subtotal := domain.NewMoney(0, q.currency)
+	for _, item := range q.items {
+		subtotal = subtotal.Add(item.Subtotal)
+	}
+	q.breakdown.Subtotal = subtotal
+	return nil
+}
+
+// discount applies the coupon, if any
+func (p *pricer) discount(ctx context.Context, q *quote) error {
+	q.breakdown.Discount = domain.NewMoney(0, q.currency)
+	if q.couponCode == "" {
+		return nil
+	}
+
+	coupon, err := p.coupons.FindByCode(ctx, q.couponCode)
+	if errors.Is(err, repository.ErrCouponNotFound) || (err == nil && !coupon.Active && !q.redeemed) {
+		return &ErrInvalidCoupon{Code: CouponErrorNotFound, CouponCode: q.couponCode, Message: "coupon does not exist"}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load coupon: %w", err)
+	}
+
+	invalid := func(code, message string) error {
+		return &ErrInvalidCoupon{Code: code, CouponCode: coupon.Code, Message: message}
+	}
+	// Expiry and usage limit were checked when a redeemed use was taken
+	switch {
+	case !q.redeemed && coupon.IsExpired(time.Now()):
+		return invalid(CouponErrorExpired, "coupon has expired")
+	case !q.redeemed && coupon.MaxUses > 0 && coupon.UsedCount >= coupon.MaxUses:
+		return invalid(CouponErrorExhausted, "coupon has been used up")
+	case (coupon.Type == domain.CouponTypeFixed || !coupon.MinSpend.IsZero()) && coupon.Currency() != q.currency:
+		return invalid(CouponErrorCurrencyMismatch, "coupon is in "+coupon.Currency())
+	case q.breakdown.Subtotal.Amount < coupon.MinSpend.Amount:
+		return invalid(CouponErrorMinSpendNotMet, "minimum spend is "+coupon.MinSpend.String())
+	}
+
+	q.coupon = coupon
+	q.breakdown.CouponCode = coupon.Code
+	q.breakdown.Discount = coupon.Discount(q.breakdown.Subtotal).OrDefaultCurrency(q.currency)
+	return nil
+}
+
+// shipping charges the fee of the shipping priority
+func (p *pricer) shipping(_ context.Context, q *quote) error {
+	q.breakdown.Shipping = domain.NewMoney(0, q.currency)
+
+	fee, ok := p.rules.ShippingFees[q.priority]
+	if !ok {
+		return nil
+	}
+	amount, err := domain.ParseMoney(fee, q.currency)
+	if err != nil {
+		return fmt.Errorf("shipping fee %q for %s: %w", fee, q.currency, err)
+	}
+
+	if q.priority == domain.ShippingStandard && p.rules.FreeShippingOver != "" {
+		threshold, err := domain.ParseMoney(p.rules.FreeShippingOver, q.currency)
+		if err != nil {
+			return fmt.Errorf("free shipping threshold %q for %s: %w", p.rules.FreeShippingOver, q.currency, err)
+		}
+		if q.breakdown.Subtotal.Sub(q.breakdown.Discount).Amount >= threshold.Amount {
+			return nil
+		}
+	}
+
+	q.breakdown.Shipping = amount
+	return nil
+}
+
+// tax applies the shipping country's rate to the discounted subtotal (shipping is not taxed)
+func (p *pricer) tax(_ context.Context, q *quote) error {
+	rate := p.rules.TaxRate(q.country)
+	q.breakdown.TaxRate = rate
+	q.breakdown.Tax = q.breakdown.Subtotal.Sub(q.breakdown.Discount).Percent(rate)
+	return nil
+}
+
+// total adds everything up
+func (p *pricer) total(_ context.Context, q *quote) error {
+	b := &q.breakdown
+	b.Total = b.Subtotal.Sub(b.Discount).Add(b.Shipping).Add(b.Tax)
+	return nil
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+)
+
+// couponTable serves coupons from a map
+type couponTable struct {
+	repository.CouponRepository
+	coupons map[string]*domain.Coupon
+}
+
+func (c couponTable) FindByCode(_ context.Context, code string) (*domain.Coupon, error) {
+	coupon, ok := c.coupons[code]
+	if !ok {
+		return nil, repository.ErrCouponNotFound
+	}
+	return coupon, nil
+}
+
+func TestPricerQuote(t *testing.T) {
+	rules, err := NewPricingRules("US:7.25,XX:0.5,JP:10", "0", "standard:5.00,express:15.00", "50.00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	coupons := couponTable{coupons: map[string]*domain.Coupon{
+		"TEN":     {Code: "TEN", Type: domain.CouponTypePercentage, Percent: 1000, Active: true},
+		"FIFTEEN": {Code: "FIFTEEN", Type: domain.CouponTypePercentage, Percent: 1500, Active: true},
+		"BIG":     {Code: "BIG", Type: domain.CouponTypeFixed, Amount: domain.NewMoney(5000, "USD"), Active: true},
+		"OFF15":   {Code: "OFF15", Type: domain.CouponTypeFixed, Amount: domain.NewMoney(1500, "USD"), Active: true},
+		"MIN100":  {Code: "MIN100", Type: domain.CouponTypeFixed, Amount: domain.NewMoney(500, "USD"), MinSpend: domain.NewMoney(10000, "USD"), Active: true},
+	}}
+	p := newPricer(rules, coupons)
+
+	line := func(price int64, qty int, currency string) domain.OrderItem {
+		unit := domain.NewMoney(price, currency)
+		return domain.OrderItem{Quantity: qty, UnitPrice: unit, Subtotal: unit.Mul(int64(qty))}
+	}
+	tests := []struct {
+		name     string
+		items    []domain.OrderItem
+		currency string
+		country  string
+		priority string
+		coupon   string
+
+		// Expected breakdown in minor units
+		subtotal, discount, shipping, tax, total int64
+		errCode                                  string
+	}{
+		{
+			name:  "lines add up before tax is rounded once",
+			items: []domain.OrderItem{line(499, 2, "USD"), line(15, 1, "USD")}, currency: "USD", country: "US", priority: "standard",
+			// 10.13 at 7.25% = 0.734425
+			subtotal: 1013, shipping: 500, tax: 73, total: 1586,
+		},
+		{
+			name:  "tax of exactly half a cent rounds up",
+			items: []domain.OrderItem{line(100, 1, "USD")}, currency: "USD", country: "XX", priority: "express",
+			// 1.00 at 0.5% = 0.005
+			subtotal: 100, shipping: 1500, tax: 1, total: 1601,
+		},
+		{
+			name:  "discount rounds half up and tax applies after it",
+			items: []domain.OrderItem{line(3333, 1, "USD")}, currency: "USD", country: "US", priority: "standard", coupon: "FIFTEEN",
+			// 15% of 33.33 = 4.9995; 28.33 at 7.25% = 2.053925
+			subtotal: 3333, discount: 500, shipping: 500, tax: 205, total: 3538,
+		},
+		{
+			name:  "zero-decimal currency",
+			items: []domain.OrderItem{line(1005, 1, "JPY")}, currency: "JPY", country: "JP", coupon: "TEN",
+			// 10% of 1005 = 100.5; 905 at 10% = 90.5
+			subtotal: 1005, discount: 101, tax: 90, total: 994,
+		},
+		{
+			name:  "zero-decimal currency rounds tax half up",
+			items: []domain.OrderItem{line(335, 3, "JPY")}, currency: "JPY", country: "JP",
+			// 1005 at 10% = 100.5
+			subtotal: 1005, tax: 101, total: 1106,
+		},
+		{
+			name:  "discount larger than the subtotal is capped",
+			items: []domain.OrderItem{line(1999, 1, "USD")}, currency: "USD", country: "US", priority: "standard", coupon: "BIG",
+			subtotal: 1999, discount: 1999, shipping: 500, tax: 0, total: 500,
+		},
+		{
+			name:  "free shipping counts the discounted subtotal",
+			items: []domain.OrderItem{line(3000, 2, "USD")}, currency: "USD", country: "US", priority: "standard", coupon: "OFF15",
+			// 45.00 at 7.25% = 3.2625
+			subtotal: 6000, discount: 1500, shipping: 500, tax: 326, total: 5326,
+		},
+		{
+			name:  "free shipping from the threshold",
+			items: []domain.OrderItem{line(5000, 1, "USD")}, currency: "USD", country: "DE", priority: "standard",
+			subtotal: 5000, total: 5000,
+		},
+		{
+			name:  "fixed coupon in another currency",
+			items: []domain.OrderItem{line(5000, 1, "JPY")}, currency: "JPY", country: "JP", coupon: "BIG",
+			errCode: CouponErrorCurrencyMismatch,
+		},
+		{
+			name:  "minimum spend not met",
+			items: []domain.OrderItem{line(9999, 1, "USD")}, currency: "USD", country: "US", coupon: "MIN100",
+			errCode: CouponErrorMinSpendNotMet,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address := domain.Address{Country: tt.country}
+			q, err := p.Quote(context.Background(), tt.items, tt.currency, address, tt.priority, tt.coupon)
+			if tt.errCode != "" {
+				var invalid *ErrInvalidCoupon
+				if !errors.As(err, &invalid) || invalid.Code != tt.errCode {
+					t.Fatalf("Quote error = %v, want %s", err, tt.errCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b := q.breakdown
+			got := []domain.Money{b.Subtotal, b.Discount, b.Shipping, b.Tax, b.Total}
+			want := []int64{tt.subtotal, tt.discount, tt.shipping, tt.tax, tt.total}
+			for i, name := range []string{"subtotal", "discount", "shipping", "tax", "total"} {
+				if got[i] != domain.NewMoney(want[i], tt.currency) {
+					t.Errorf("%s = %s, want %s", name, got[i], domain.NewMoney(want[i], tt.currency))
+				}
+			}
+		})
+	}
+}
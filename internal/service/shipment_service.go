@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/carrier"
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+)
+
+// ErrUnknownCarrier is returned for callbacks of carriers that are not configured
+var ErrUnknownCarrier = errors.New("unknown carrier")
+
+// TrackingResult counts what a carrier callback changed
+type TrackingResult struct {
+	Received int
+	Applied  int
+	Unknown  int // Events for tracking numbers without a shipment
+}
+
+// ShipmentService defines business logic for shipments and carrier tracking
+type ShipmentService interface {
+	ListShipments(ctx context.Context, orderID string) ([]*domain.Shipment, error)
+	// HandleCarrierWebhook authenticates a tracking callback and applies its
+	// events. Orders are delivered once all their parcels are.
+	HandleCarrierWebhook(ctx context.Context, carrierName string, header http.Header, body []byte) (*TrackingResult, error)
+}
+
+type shipmentService struct {
+	shipments  repository.ShipmentRepository
+	orders     repository.OrderRepository
+	transactor repository.Transactor
+	carriers   map[string]carrier.Carrier
+}
+
+// NewShipmentService creates a new shipment service accepting callbacks of carriers
+func NewShipmentService(
+	shipments repository.ShipmentRepository,
+	orders repository.OrderRepository,
+	transactor repository.Transactor,
+	carriers ...carrier.Carrier,
+) ShipmentService {
+	byName := make(map[string]carrier.Carrier, len(carriers))
+	for _, c := range carriers {
+		byName[c.Name()] = c
+	}
+	return &shipmentService{shipments: shipments, orders: orders, transactor: transactor, carriers: byName}
+}
+
+// ListShipments retrieves the parcels of an order
+func (s *shipmentService) ListShipments(ctx context.Context, orderID string) ([]*domain.Shipment, error) {
+	return s.shipments.ListByOrder(ctx, orderID)
+}
+
+// HandleCarrierWebhook applies each event in its own transaction. Carriers
+// resend failed callbacks whole, and events already applied are ignored then.
+func (s *shipmentService) HandleCarrierWebhook(ctx context.Context, carrierName string, header http.Header, body []byte) (*TrackingResult, error) {
+	c, ok := s.carriers[carrierName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCarrier, carrierName)
+	}
+
+	events, err := c.ParseWebhook(header, body)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TrackingResult{Received: len(events)}
+	for _, e := range events {
+		shipment, applied, err := s.applyEvent(ctx, carrierName, e)
+		switch {
+		case errors.Is(err, repository.ErrShipmentNotFound):
+			log.Printf("⚠️  [Carrier] %s event for unknown tracking number %s", carrierName, e.TrackingNumber)
+			result.Unknown++
+			continue
+		case err != nil:
+			return nil, err
+		case applied:
+			result.Applied++
+		}
+
+		// Checked after the commit: when the last two parcels arrive at once, the
+		// check that runs second sees both delivered. Repeated callbacks check
+		// again, in case the first attempt failed here.
+		if shipment.IsDelivered() {
+			if err := s.deliverOrder(ctx, shipment.OrderID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// applyEvent advances the shipment with the row locked, so concurrent callbacks
+// for one parcel cannot overwrite each other's history
+func (s *shipmentService) applyEvent(ctx context.Context, carrierName string, e carrier.TrackingEvent) (*domain.Shipment, bool, error) {
+	var shipment *domain.Shipment
+	var applied bool
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		shipment, err = s.shipments.FindByTracking(ctx, carrierName, e.TrackingNumber)
+		if err != nil {
+			return err
+		}
+
+		applied, err = shipment.Advance(e.Event)
+		if err != nil || !applied {
+			return err
+		}
+		if err := s.shipments.Update(ctx, shipment); err != nil {
+			return fmt.Errorf("failed to update shipment %s: %w", shipment.ID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if applied {
+		log.Printf("🚚 [Carrier] %s %s: %s", carrierName, e.TrackingNumber, shipment.Status)
+	}
+	return shipment, applied, nil
+}
+
+// deliverOrder marks a shipped order delivered once every parcel has arrived
+func (s *shipmentService) deliverOrder(ctx context.Context, orderID string) error {
+	shipments, err := s.shipments.ListByOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	for _, shipment := range shipments {
+		if !shipment.IsDelivered() {
+			return nil
+		}
+	}
+
+	// Locked like task updates, so a concurrent invoice or task write is not lost
+	var delivered bool
+	err = repository.UpdateOrder(ctx, s.transactor, s.orders, orderID, func(o *domain.Order) bool {
+		if o.Status != domain.OrderStatusShipped {
+			return false
+		}
+		o.UpdateStatus(domain.OrderStatusDelivered)
+		delivered = true
+		return true
+	})
+	if err != nil || !delivered {
+		return err
+	}
+	log.Printf("📬 [Carrier] Order %s delivered (%d parcel(s))", orderID, len(shipments))
+	return nil
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/dto"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+	"github.com/lppduy/go-asynq-loadtest/internal/webhook"
+)
+
+// WebhookService defines business logic for webhook subscriptions
+type WebhookService interface {
+	CreateSubscription(ctx context.Context, req dto.CreateWebhookRequest) (*domain.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, id string) (*domain.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id string) error
+	ListDeliveries(ctx context.Context, id string, limit int) ([]*domain.WebhookDelivery, error)
+	SendTestEvent(ctx context.Context, id string) (*domain.WebhookEvent, error)
+}
+
+// ErrInvalidWebhook is returned for subscriptions that fail validation
+type ErrInvalidWebhook struct {
+	Reason string
+}
+
+func (e *ErrInvalidWebhook) Error() string {
+	return "invalid webhook: " + e.Reason
+}
+
+type webhookService struct {
+	repo       repository.WebhookRepository
+	dispatcher *webhook.Dispatcher
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(repo repository.WebhookRepository, dispatcher *webhook.Dispatcher) WebhookService {
+	return &webhookService{repo: repo, dispatcher: dispatcher}
+}
+
+// CreateSubscription validates and stores a new subscription
+func (s *webhookService) CreateSubscription(ctx context.Context, req dto.CreateWebhookRequest) (*domain.WebhookSubscription, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, &ErrInvalidWebhook{Reason: "url must be an absolute http(s) URL"}
+	}
+
+	for _, t := range req.EventTypes {
+		if !isKnownEventType(t) {
+			return nil, &ErrInvalidWebhook{Reason: fmt.Sprintf("unknown event type %q", t)}
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = generateSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	sub := &domain.WebhookSubscription{
+		ID:         fmt.Sprintf("WH-%s", uuid.New().String()[:8]),
+		URL:        req.URL,
+		Secret:     secret,
+		EventTypes: req.EventTypes,
+		Active:     true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := s.repo.CreateSubscription(ctx, sub); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return sub, nil
+}
+
+// GetSubscription retrieves a subscription by ID
+func (s *webhookService) GetSubscription(ctx context.Context, id string) (*domain.WebhookSubscription, error) {
+	return s.repo.FindSubscriptionByID(ctx, id)
+}
+
+// ListSubscriptions retrieves all subscriptions
+func (s *webhookService) ListSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	return s.repo.FindAllSubscriptions(ctx)
+}
+
+// DeleteSubscription removes a subscription; queued deliveries are dropped by the worker
+func (s *webhookService) DeleteSubscription(ctx context.Context, id string) error {
+	return s.repo.DeleteSubscription(ctx, id)
+}
+
+// ListDeliveries retrieves the delivery log of a subscription
+func (s *webhookService) ListDeliveries(ctx context.Context, id string, limit int) ([]*domain.WebhookDelivery, error) {
+	if _, err := s.repo.FindSubscriptionByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.FindDeliveries(ctx, id, limit)
+}
+
+// SendTestEvent enqueues a sample order event to one subscription
+func (s *webhookService) SendTestEvent(ctx context.Context, id string) (*domain.WebhookEvent, error) {
+	sub, err := s.repo.FindSubscriptionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	sample := domain.OrderEventData{
+		OrderID:        "ORD-test0000",
+		CustomerID:     "cust-test",
+		Status:         string(domain.OrderStatusConfirmed),
+		PreviousStatus: string(domain.OrderStatusPaymentProcessing),
+		PaymentStatus:  string(domain.PaymentStatusCompleted),
+		TotalAmount:    domain.NewMoney(9999, domain.DefaultCurrency),
+	}
+	return s.dispatcher.DispatchTo(ctx, sub, domain.WebhookEventTest, sample)
+}
+
+// isKnownEventType accepts wildcards and every order lifecycle event
+func isKnownEventType(t string) bool {
+	switch t {
+	case domain.WebhookEventAll, "order.*", domain.WebhookEventOrderCreated, domain.WebhookEventTest:
+		return true
+	}
+	for _, status := range []domain.OrderStatus{
+		domain.OrderStatusPending,
+		domain.OrderStatusPaymentProcessing,
+		domain.OrderStatusPaymentFailed,
+		domain.OrderStatusConfirmed,
+		domain.OrderStatusProcessing,
+		domain.OrderStatusShipped,
+		domain.OrderStatusDelivered,
+		domain.OrderStatusCancelled,
+	} {
+		if t == domain.OrderStatusEventType(status) {
+			return true
+		}
+	}
+	return false
+}
+
+// generateSecret returns a random signing secret
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrBlobNotFound is returned when no blob is stored under a key
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobInfo describes a stored blob
+type BlobInfo struct {
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// BlobStore keeps generated files (invoices) under slash-separated keys
+type BlobStore interface {
+	// Put stores data under key, replacing any previous blob
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get opens the blob under key; the caller closes it
+	Get(ctx context.Context, key string) (io.ReadCloser, BlobInfo, error)
+	// SignedURL returns a link that downloads the blob without credentials until
+	// it expires, or "" when the store can only serve blobs through Get
+	SignedURL(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+// validKey rejects keys that could leave the store's root
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// New creates the store of backend: "file" under dir, or "s3"
+func New(ctx context.Context, backend, dir string, s3 S3Config) (BlobStore, error) {
+	if backend == "s3" {
+		return NewS3Store(ctx, s3)
+	}
+	return NewFileStore(dir)
+}
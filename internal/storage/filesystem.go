@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileStore keeps blobs as files under a local directory, for single-host runs.
+// Blobs are served through the API, there are no signed URLs.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates the directory if needed
+func NewFileStore(dir string) (BlobStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory %s: %w", dir, err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Put writes to a temporary file and renames it, so readers never see a partial blob
+func (s *FileStore) Put(ctx context.Context, key string, data []byte, _ string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get opens the file; its content type comes from the key's extension
+func (s *FileStore) Get(ctx context.Context, key string) (io.ReadCloser, BlobInfo, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, BlobInfo{}, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, BlobInfo{}, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, BlobInfo{}, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, BlobInfo{}, err
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return f, BlobInfo{Size: stat.Size(), ContentType: contentType, ModTime: stat.ModTime()}, nil
+}
+
+// SignedURL is not supported by local files
+func (s *FileStore) SignedURL(context.Context, string, time.Duration) (string, error) {
+	return "", nil
+}
+
+// path maps a key to a file under the store directory
+func (s *FileStore) path(key string) (string, error) {
+	if !validKey(key) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
@@ -2,86 +2,152 @@ package tasks
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/hibiken/asynq"
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+	"github.com/lppduy/go-asynq-loadtest/pkg/metrics"
 )
 
 const (
 	TypeAnalyticsTrack = "analytics:track"
+	TypeAnalyticsFlush = "analytics:flush"
 )
 
+// AnalyticsGroup is the Asynq group analytics:track tasks wait in until they are flushed
+const AnalyticsGroup = "analytics"
+
 // AnalyticsPayload represents the payload for analytics tracking
 type AnalyticsPayload struct {
-	OrderID      string  `json:"order_id"`
-	CustomerID   string  `json:"customer_id"`
-	TotalAmount  float64 `json:"total_amount"`
-	ItemCount    int     `json:"item_count"`
-	PaymentMethod string `json:"payment_method"`
-	CreatedAt    string  `json:"created_at"`
+	OrderID       string       `json:"order_id" pb:"1"`
+	CustomerID    string       `json:"customer_id" pb:"2"`
+	TotalAmount   domain.Money `json:"total_amount" pb:"3"`
+	ItemCount     int          `json:"item_count" pb:"4"`
+	PaymentMethod string       `json:"payment_method" pb:"5"`
+	CreatedAt     string       `json:"created_at" pb:"6"`
 }
 
-// NewAnalyticsTrackTask creates a new analytics tracking task
-func NewAnalyticsTrackTask(orderID, customerID string, totalAmount float64, itemCount int, paymentMethod string) (*asynq.Task, error) {
-	payload, err := json.Marshal(AnalyticsPayload{
-		OrderID:       orderID,
-		CustomerID:    customerID,
-		TotalAmount:   totalAmount,
-		ItemCount:     itemCount,
-		PaymentMethod: paymentMethod,
-		CreatedAt:     time.Now().Format(time.RFC3339),
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal analytics payload: %w", err)
+// Validate checks the fields every analytics event needs
+func (p AnalyticsPayload) Validate() error {
+	switch {
+	case p.OrderID == "":
+		return errors.New("order_id is required")
+	case p.CustomerID == "":
+		return errors.New("customer_id is required")
 	}
+	return nil
+}
+
+// AnalyticsTrack records an order in the analytics store. Events are not
+// handled one by one: they wait in the AnalyticsGroup group and the worker's
+// GroupAggregator (AggregateAnalytics) turns each batch into one analytics:flush.
+var AnalyticsTrack = Define(TaskDef[AnalyticsPayload]{
+	Type: TypeAnalyticsTrack,
+	Options: []asynq.Option{
+		asynq.MaxRetry(2), // Analytics can fail without blocking order
+		asynq.Timeout(10 * time.Second),
+		asynq.Queue("low"),          // Low priority
+		asynq.Group(AnalyticsGroup), // Flushed in batches
+	},
+	// Version 1 had float amounts without a currency
+	Upcasters: []Upcaster{upcastLegacyMoney("total_amount")},
+	// Only runs for events enqueued without a group, by API releases before batching
+	Handler: func(d Deps) func(context.Context, AnalyticsPayload) error {
+		return func(ctx context.Context, payload AnalyticsPayload) error {
+			return recordAnalytics(ctx, d.AnalyticsRepo, []AnalyticsPayload{payload})
+		}
+	},
+})
 
-	return asynq.NewTask(
-		TypeAnalyticsTrack,
-		payload,
-		asynq.MaxRetry(2),             // Analytics can fail without blocking order
-		asynq.Timeout(10*time.Second),
-		asynq.Queue("low"),            // Low priority
-		asynq.ProcessIn(10*time.Second), // Track after 10 seconds
-	), nil
+// AnalyticsBatchPayload is the payload of analytics:flush, the events of one group
+// batch. Events are in the current AnalyticsPayload shape, so an upcaster added
+// for AnalyticsPayload needs a counterpart here.
+type AnalyticsBatchPayload struct {
+	Events []AnalyticsPayload `json:"events" pb:"1"`
 }
 
-// HandleAnalyticsTrackTask tracks order analytics
-func HandleAnalyticsTrackTask(ctx context.Context, t *asynq.Task) error {
-	var payload AnalyticsPayload
-	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
-		return fmt.Errorf("failed to unmarshal analytics payload: %w", err)
+// Validate checks every event of the batch
+func (p AnalyticsBatchPayload) Validate() error {
+	if len(p.Events) == 0 {
+		return errors.New("events are required")
 	}
+	for i, e := range p.Events {
+		if err := e.Validate(); err != nil {
+			return fmt.Errorf("events[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
 
-	log.Printf("📊 [Analytics] Tracking order: %s", payload.OrderID)
-	log.Printf("📊 [Analytics] Customer: %s | Amount: $%.2f | Items: %d", 
-		payload.CustomerID, payload.TotalAmount, payload.ItemCount)
-
-	// Simulate analytics tracking (200ms)
-	time.Sleep(200 * time.Millisecond)
+// AnalyticsFlush writes a batch of analytics events in one statement
+var AnalyticsFlush = Define(TaskDef[AnalyticsBatchPayload]{
+	Type: TypeAnalyticsFlush,
+	Options: []asynq.Option{
+		asynq.MaxRetry(5), // Events already written are skipped on retry
+		asynq.Timeout(30 * time.Second),
+		asynq.Queue("low"),
+	},
+	Handler: func(d Deps) func(context.Context, AnalyticsBatchPayload) error {
+		return func(ctx context.Context, payload AnalyticsBatchPayload) error {
+			return recordAnalytics(ctx, d.AnalyticsRepo, payload.Events)
+		}
+	},
+})
 
-	// Send to analytics service
-	if err := sendToAnalytics(payload); err != nil {
-		// Log but don't fail - analytics is not critical
-		log.Printf("⚠️  [Analytics] Failed to send data: %v", err)
-		return nil // Don't retry
+// AggregateAnalytics is the asynq.GroupAggregator of the worker. It decodes the
+// analytics:track tasks of a group batch, whatever their codec and version, into
+// one analytics:flush task. Events that do not decode are dropped and logged.
+func AggregateAnalytics(group string, batch []*asynq.Task) *asynq.Task {
+	payload := AnalyticsBatchPayload{Events: make([]AnalyticsPayload, 0, len(batch))}
+	for _, t := range batch {
+		event, err := AnalyticsTrack.Decode(t)
+		if err != nil {
+			log.Printf("⚠️  [Analytics] Dropping event of group %s: %v", group, err)
+			continue
+		}
+		payload.Events = append(payload.Events, event)
 	}
 
-	log.Printf("✅ [Analytics] Event tracked for order: %s", payload.OrderID)
-	return nil
+	task, err := AnalyticsFlush.NewTask(payload)
+	if err != nil {
+		// The flush task is archived by the worker and stays visible in Asynqmon
+		log.Printf("❌ [Analytics] Failed to encode batch of %d events: %v", len(payload.Events), err)
+		return asynq.NewTask(TypeAnalyticsFlush, nil)
+	}
+	return task
 }
 
-// sendToAnalytics sends data to analytics service
-func sendToAnalytics(payload AnalyticsPayload) error {
-	// In production: Send to Google Analytics, Mixpanel, Segment, etc.
-	// Example:
-	// analytics.Track("order_created", map[string]interface{}{
-	//     "order_id": payload.OrderID,
-	//     "revenue": payload.TotalAmount,
-	//     "item_count": payload.ItemCount,
-	// })
-	
+// recordAnalytics writes events to the analytics store
+func recordAnalytics(ctx context.Context, repo repository.AnalyticsRepository, payloads []AnalyticsPayload) error {
+	now := time.Now()
+	events := make([]*domain.AnalyticsEvent, 0, len(payloads))
+	for _, p := range payloads {
+		createdAt, err := time.Parse(time.RFC3339, p.CreatedAt)
+		if err != nil {
+			createdAt = now
+		}
+		metrics.AnalyticsEventLatency.Observe(now.Sub(createdAt).Seconds())
+		events = append(events, &domain.AnalyticsEvent{
+			OrderID:       p.OrderID,
+			CustomerID:    p.CustomerID,
+			TotalAmount:   p.TotalAmount,
+			ItemCount:     p.ItemCount,
+			PaymentMethod: p.PaymentMethod,
+			CreatedAt:     createdAt,
+			RecordedAt:    now,
+		})
+	}
+
+	recorded, err := repo.RecordEvents(ctx, events)
+	if err != nil {
+		return fmt.Errorf("failed to record %d analytics events: %w", len(events), err)
+	}
+	metrics.AnalyticsBatchSize.Observe(float64(len(events)))
+
+	log.Printf("📊 [Analytics] Recorded %d events (%d already recorded)", recorded, int64(len(events))-recorded)
 	return nil
 }
@@ -0,0 +1,131 @@
+package tasks
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Codec encodes task payloads. JSON is the default; msgpack and protobuf are
+// smaller and cheaper to encode at stress-test volume. The codec is named in
+// every task, so workers decode all of them whichever one the producer uses.
+type Codec interface {
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	// Unmarshal decodes data into v, failing on fields v does not have
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// Supported codecs
+var (
+	JSON     Codec = jsonCodec{}
+	Msgpack  Codec = msgpackCodec{}
+	Protobuf Codec = protobufCodec{}
+)
+
+// ErrUnknownCodec is returned for codec names or IDs this build does not know
+var ErrUnknownCodec = errors.New("unknown payload codec")
+
+// payloadCodec encodes the payloads of NewTask and Enqueue
+var payloadCodec = JSON
+
+// SetCodec sets the codec of new tasks. Call it once at startup.
+func SetCodec(c Codec) {
+	payloadCodec = c
+}
+
+// CodecByName returns the codec called json, msgpack or protobuf
+func CodecByName(name string) (Codec, error) {
+	for _, c := range []Codec{JSON, Msgpack, Protobuf} {
+		if c.Name() == name {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownCodec, name)
+}
+
+// Asynq v0.25 tasks carry no headers, so the codec is named at the start of
+// the payload. JSON payloads are the version envelope and start with '{'.
+// Binary payloads are framed as: frameMarker, codec ID, uvarint version, body.
+const frameMarker = 0x00
+
+// frameIDs identify the binary codecs in a frame
+var frameIDs = map[Codec]byte{
+	Msgpack:  'm',
+	Protobuf: 'p',
+}
+
+// encodePayload encodes v as version of its payload type with c
+func encodePayload(c Codec, version int, v interface{}) ([]byte, error) {
+	body, err := c.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if c == JSON {
+		return sealEnvelope(version, body)
+	}
+
+	id, ok := frameIDs[c]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownCodec, c.Name())
+	}
+	frame := append([]byte{frameMarker, id}, binary.AppendUvarint(nil, uint64(version))...)
+	return append(frame, body...), nil
+}
+
+// openPayload returns the codec, the version and the body of an encoded payload
+func openPayload(data []byte) (Codec, int, []byte, error) {
+	if len(data) == 0 || data[0] != frameMarker {
+		version, body, err := openEnvelope(data)
+		return JSON, version, body, err
+	}
+
+	if len(data) < 2 {
+		return nil, 0, nil, errors.New("truncated payload frame")
+	}
+	var c Codec
+	for candidate, id := range frameIDs {
+		if id == data[1] {
+			c = candidate
+		}
+	}
+	if c == nil {
+		return nil, 0, nil, fmt.Errorf("%w: id %q", ErrUnknownCodec, data[1])
+	}
+	version, n := binary.Uvarint(data[2:])
+	if n <= 0 {
+		return nil, 0, nil, errors.New("invalid version in payload frame")
+	}
+	return c, int(version), data[2+n:], nil
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                               { return "json" }
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return decodeStrict(data, v) }
+
+// msgpackHandle writes maps keyed by the json tags of the payload fields
+var msgpackHandle = func() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{}
+	h.WriteExt = true
+	h.ErrorIfNoField = true
+	return h
+}()
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return "msgpack" }
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var data []byte
+	err := codec.NewEncoderBytes(&data, msgpackHandle).Encode(v)
+	return data, err
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return codec.NewDecoderBytes(data, msgpackHandle).Decode(v)
+}
@@ -0,0 +1,60 @@
+package tasks_test
+
+import (
+	"testing"
+
+	"github.com/hibiken/asynq"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/tasks"
+)
+
+// BenchmarkCodecEncode encodes the sample of every task type with every codec,
+// as NewTask does. bytes/op is the payload size, including envelope or frame.
+func BenchmarkCodecEncode(b *testing.B) {
+	for _, s := range samples() {
+		for _, c := range codecs {
+			b.Run(s.taskType+"/"+c.Name(), func(b *testing.B) { s.encode(b, c) })
+		}
+	}
+}
+
+// BenchmarkCodecDecode decodes the sample of every task type with every codec,
+// as the worker does before the handler runs
+func BenchmarkCodecDecode(b *testing.B) {
+	for _, s := range samples() {
+		for _, c := range codecs {
+			b.Run(s.taskType+"/"+c.Name(), func(b *testing.B) { s.decode(b, c) })
+		}
+	}
+}
+
+func benchEncode[P any](b *testing.B, def *tasks.TaskDef[P], payload P, c tasks.Codec) {
+	data, err := def.Encode(c, payload)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		def.Encode(c, payload)
+	}
+	b.ReportMetric(float64(len(data)), "bytes/op")
+}
+
+func benchDecode[P any](b *testing.B, def *tasks.TaskDef[P], payload P, c tasks.Codec) {
+	data, err := def.Encode(c, payload)
+	if err != nil {
+		b.Fatal(err)
+	}
+	task := asynq.NewTask(def.Type, data)
+	if _, err := def.Decode(task); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		def.Decode(task)
+	}
+}
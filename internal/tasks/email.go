@@ -2,84 +2,129 @@ package tasks
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/mail"
 	"time"
 
 	"github.com/hibiken/asynq"
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/email"
 )
 
 const (
 	TypeEmailConfirmation = "email:confirmation"
+	TypeEmailCancellation = "email:cancellation"
+	TypeEmailRefund       = "email:refund"
+	TypeEmailShipping     = "email:shipping"
 )
 
 // EmailPayload represents the payload for email sending
 type EmailPayload struct {
-	OrderID       string `json:"order_id"`
-	CustomerEmail string `json:"customer_email"`
-	CustomerName  string `json:"customer_name"`
-	TotalAmount   float64 `json:"total_amount"`
+	OrderID       string       `json:"order_id" pb:"1"`
+	CustomerEmail string       `json:"customer_email" pb:"2"`
+	CustomerName  string       `json:"customer_name" pb:"3"`
+	TotalAmount   domain.Money `json:"total_amount" pb:"4"`
 }
 
-// NewEmailConfirmationTask creates a new email confirmation task
-func NewEmailConfirmationTask(orderID, customerEmail, customerName string, totalAmount float64) (*asynq.Task, error) {
-	payload, err := json.Marshal(EmailPayload{
-		OrderID:       orderID,
-		CustomerEmail: customerEmail,
-		CustomerName:  customerName,
-		TotalAmount:   totalAmount,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal email payload: %w", err)
+// Validate checks that there is someone to write to
+func (p EmailPayload) Validate() error {
+	switch {
+	case p.OrderID == "":
+		return errors.New("order_id is required")
+	case p.CustomerEmail == "":
+		return errors.New("customer_email is required")
 	}
-
-	return asynq.NewTask(
-		TypeEmailConfirmation,
-		payload,
-		asynq.MaxRetry(5),              // Email can retry more
-		asynq.Timeout(20*time.Second),
-		asynq.Queue("default"),         // Default queue
-		asynq.ProcessIn(3*time.Second), // Send after 3 seconds
-	), nil
+	return nil
 }
 
-// HandleEmailConfirmationTask sends order confirmation email
-func HandleEmailConfirmationTask(ctx context.Context, t *asynq.Task) error {
-	var payload EmailPayload
-	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
-		return fmt.Errorf("failed to unmarshal email payload: %w", err)
-	}
+// EmailConfirmation sends the order confirmation email
+var EmailConfirmation = Define(TaskDef[EmailPayload]{
+	Type: TypeEmailConfirmation,
+	Options: []asynq.Option{
+		asynq.MaxRetry(5), // Email can retry more
+		asynq.Timeout(20 * time.Second),
+		asynq.Queue("default"),           // Default queue
+		asynq.ProcessIn(3 * time.Second), // Send after 3 seconds
+	},
+	// Version 1 had float amounts without a currency
+	Upcasters:    []Upcaster{upcastLegacyMoney("total_amount")},
+	Handler:      newEmailHandler(email.KindConfirmation),
+	RequireOrder: true,
+})
 
-	log.Printf("📧 [Email] Sending confirmation to: %s", payload.CustomerEmail)
-	log.Printf("📧 [Email] Order: %s | Amount: $%.2f", payload.OrderID, payload.TotalAmount)
+// EmailCancellation tells the customer their order was cancelled
+var EmailCancellation = Define(TaskDef[EmailPayload]{
+	Type: TypeEmailCancellation,
+	Options: []asynq.Option{
+		asynq.MaxRetry(5),
+		asynq.Timeout(20 * time.Second),
+		asynq.Queue("default"),
+	},
+	// One per order, even when the cancel request is retried
+	TaskID:       func(p EmailPayload) string { return OrderTaskID(TypeEmailCancellation, p.OrderID) },
+	Handler:      newEmailHandler(email.KindCancellation),
+	RequireOrder: true,
+})
 
-	// Simulate email sending (1 second)
-	time.Sleep(1 * time.Second)
+// EmailRefund announces the refund of a cancelled, already paid order. It is
+// enqueued by payment:refund once the money is back. TotalAmount is the
+// refunded amount.
+var EmailRefund = Define(TaskDef[EmailPayload]{
+	Type: TypeEmailRefund,
+	Options: []asynq.Option{
+		asynq.MaxRetry(5),
+		asynq.Timeout(20 * time.Second),
+		asynq.Queue("default"),
+	},
+	TaskID:       func(p EmailPayload) string { return OrderTaskID(TypeEmailRefund, p.OrderID) },
+	Handler:      newEmailHandler(email.KindRefund),
+	RequireOrder: true,
+})
 
-	// Simulate email service API call
-	// In production: Use SendGrid, AWS SES, Mailgun, etc.
-	if err := sendEmail(payload); err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
-	}
+// EmailShipping sends the tracking number once the warehouse has shipped the order
+var EmailShipping = Define(TaskDef[EmailPayload]{
+	Type: TypeEmailShipping,
+	Options: []asynq.Option{
+		asynq.MaxRetry(5),
+		asynq.Timeout(20 * time.Second),
+		asynq.Queue("default"),
+	},
+	TaskID:       func(p EmailPayload) string { return OrderTaskID(TypeEmailShipping, p.OrderID) },
+	Handler:      newEmailHandler(email.KindShipping),
+	RequireOrder: true,
+})
 
-	log.Printf("✅ [Email] Confirmation sent successfully to: %s", payload.CustomerEmail)
-	return nil
-}
+// newEmailHandler returns the handler of one email kind. Items, address and tracking
+// come from the order as it is now; recipient, name and amount from the payload.
+func newEmailHandler(kind email.Kind) func(Deps) func(context.Context, EmailPayload) error {
+	return func(d Deps) func(context.Context, EmailPayload) error {
+		orderRepo, mailer := d.OrderRepo, d.Mailer
+		return func(ctx context.Context, payload EmailPayload) error {
+			order, err := orderRepo.FindByID(ctx, payload.OrderID)
+			if err != nil {
+				return err
+			}
 
-// sendEmail simulates sending email via email service
-func sendEmail(payload EmailPayload) error {
-	// In production: Call actual email service API
-	// Example: SendGrid, AWS SES, Mailgun
-	emailBody := fmt.Sprintf(`
-		Dear Customer,
-		
-		Your order %s has been confirmed!
-		Total Amount: $%.2f
-		
-		Thank you for your purchase!
-	`, payload.OrderID, payload.TotalAmount)
+			msg, err := email.Render(kind, email.Data{
+				CustomerName: payload.CustomerName,
+				Order:        order,
+				Amount:       payload.TotalAmount,
+			})
+			if err != nil {
+				// A broken template does not fix itself on retry
+				return fmt.Errorf("%v: %w", err, asynq.SkipRetry)
+			}
+			msg.To = mail.Address{Name: payload.CustomerName, Address: payload.CustomerEmail}
 
-	_ = emailBody // Use the email body
-	return nil
+			log.Printf("📧 [Email] Sending %s to: %s | Order: %s", kind, payload.CustomerEmail, payload.OrderID)
+			if err := mailer.Send(ctx, msg); err != nil {
+				return fmt.Errorf("failed to send %s email: %w", kind, err)
+			}
+
+			log.Printf("✅ [Email] %s email sent successfully to: %s", kind, payload.CustomerEmail)
+			return nil
+		}
+	}
 }
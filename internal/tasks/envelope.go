@@ -0,0 +1,84 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+)
+
+// ErrUnsupportedPayloadVersion is returned for payloads written by a newer
+// (or broken) producer than this build can read
+var ErrUnsupportedPayloadVersion = errors.New("unsupported payload version")
+
+// envelope wraps every task payload with the version of its schema:
+// {"version": 2, "payload": {...}}
+type envelope struct {
+	Version int             `json:"version"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Upcaster migrates a payload from one version to the next
+type Upcaster func(data json.RawMessage) (json.RawMessage, error)
+
+// sealEnvelope wraps an encoded payload of the given version
+func sealEnvelope(version int, payload []byte) ([]byte, error) {
+	return json.Marshal(envelope{Version: version, Payload: payload})
+}
+
+// openEnvelope returns the version and the payload of an encoded task.
+// Payloads enqueued before they were versioned have no envelope and are version 1.
+func openEnvelope(data []byte) (int, json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return 0, nil, err
+	}
+	rawVersion, hasVersion := fields["version"]
+	payload, hasPayload := fields["payload"]
+	if !hasVersion || !hasPayload || len(fields) != 2 {
+		return 1, data, nil
+	}
+
+	var version int
+	if err := json.Unmarshal(rawVersion, &version); err != nil {
+		return 0, nil, fmt.Errorf("invalid version %s", rawVersion)
+	}
+	return version, payload, nil
+}
+
+// decodeStrict decodes data into v, failing on fields v does not have
+func decodeStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// upcastLegacyMoney migrates amounts written as float dollars, before amounts
+// carried a currency, to Money in the default currency. Amounts that already
+// are Money (payloads from before the envelope) are kept as they are.
+func upcastLegacyMoney(fieldNames ...string) Upcaster {
+	return func(data json.RawMessage) (json.RawMessage, error) {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, err
+		}
+		for _, name := range fieldNames {
+			raw, ok := fields[name]
+			if !ok || bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+				continue
+			}
+			var amount domain.Money
+			if err := json.Unmarshal(raw, &amount); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			encoded, err := json.Marshal(amount.OrDefaultCurrency(domain.DefaultCurrency))
+			if err != nil {
+				return nil, err
+			}
+			fields[name] = encoded
+		}
+		return json.Marshal(fields)
+	}
+}
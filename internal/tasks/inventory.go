@@ -2,7 +2,7 @@ package tasks
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -18,100 +18,104 @@ const (
 
 // InventoryItem represents an item to update in inventory
 type InventoryItem struct {
-	ProductID string `json:"product_id"`
-	Quantity  int    `json:"quantity"`
+	ProductID string `json:"product_id" pb:"1"`
+	Quantity  int    `json:"quantity" pb:"2"`
 }
 
 // InventoryPayload represents the payload for inventory update
 type InventoryPayload struct {
-	OrderID string          `json:"order_id"`
-	Items   []InventoryItem `json:"items"`
+	OrderID string          `json:"order_id" pb:"1"`
+	Items   []InventoryItem `json:"items" pb:"2"`
 }
 
-// NewInventoryUpdateTask creates a new inventory update task
-func NewInventoryUpdateTask(orderID string, items []InventoryItem) (*asynq.Task, error) {
-	payload, err := json.Marshal(InventoryPayload{
-		OrderID: orderID,
-		Items:   items,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal inventory payload: %w", err)
+// Validate checks that there is stock to reserve
+func (p InventoryPayload) Validate() error {
+	if p.OrderID == "" {
+		return errors.New("order_id is required")
 	}
-
-	return asynq.NewTask(
-		TypeInventoryUpdate,
-		payload,
-		asynq.MaxRetry(3),
-		asynq.Timeout(15*time.Second),
-		asynq.Queue("high"),            // High priority
-		asynq.ProcessIn(1*time.Second), // Process quickly
-	), nil
+	if len(p.Items) == 0 {
+		return errors.New("items must not be empty")
+	}
+	for i, item := range p.Items {
+		if item.ProductID == "" || item.Quantity <= 0 {
+			return fmt.Errorf("item %d needs a product_id and a positive quantity", i)
+		}
+	}
+	return nil
 }
 
-// NewInventoryUpdateHandler returns a handler that also updates order status in PostgreSQL.
-func NewInventoryUpdateHandler(orderRepo repository.OrderRepository) func(context.Context, *asynq.Task) error {
-	return func(ctx context.Context, t *asynq.Task) error {
-		var payload InventoryPayload
-		if err := json.Unmarshal(t.Payload(), &payload); err != nil {
-			return fmt.Errorf("failed to unmarshal inventory payload: %w", err)
+// InventoryUpdate confirms (or takes) the stock reservation of an order
+var InventoryUpdate = Define(TaskDef[InventoryPayload]{
+	Type: TypeInventoryUpdate,
+	Options: []asynq.Option{
+		asynq.MaxRetry(3),
+		asynq.Timeout(15 * time.Second),
+		asynq.Queue("high"),              // High priority
+		asynq.ProcessIn(1 * time.Second), // Process quickly
+	},
+	// Deterministic ID so order edits can find and replace it
+	TaskID:       func(p InventoryPayload) string { return OrderTaskID(TypeInventoryUpdate, p.OrderID) },
+	Handler:      newInventoryUpdateHandler,
+	RequireOrder: true,
+})
+
+// newInventoryUpdateHandler returns a handler that reserves stock for the order items.
+// Orders created through the API already hold their reservation, so this only
+// confirms it; orders that cannot be fulfilled are cancelled instead of retried.
+func newInventoryUpdateHandler(d Deps) func(context.Context, InventoryPayload) error {
+	orderRepo, inventoryRepo := d.OrderRepo, d.InventoryRepo
+	return func(ctx context.Context, payload InventoryPayload) error {
+		log.Printf("📦 [Inventory] Reserving stock for order: %s", payload.OrderID)
+		log.Printf("📦 [Inventory] Items to reserve: %d", len(payload.Items))
+
+		order, err := orderRepo.FindByID(ctx, payload.OrderID)
+		if err != nil {
+			return err
+		}
+		if order.Status.IsTerminal() {
+			log.Printf("⏭️  [Inventory] Order %s is %s, nothing to reserve", payload.OrderID, order.Status)
+			return nil
 		}
 
-		log.Printf("📦 [Inventory] Updating inventory for order: %s", payload.OrderID)
-		log.Printf("📦 [Inventory] Items to update: %d", len(payload.Items))
-
-		// Simulate inventory update (500ms)
-		time.Sleep(500 * time.Millisecond)
+		requests := make([]domain.StockRequest, len(payload.Items))
+		for i, item := range payload.Items {
+			requests[i] = domain.StockRequest{ProductID: item.ProductID, Quantity: item.Quantity}
+		}
 
-		for _, item := range payload.Items {
-			if err := updateInventoryItem(item); err != nil {
-				return fmt.Errorf("failed to update inventory for product %s: %w", item.ProductID, err)
-			}
-			log.Printf("📦 [Inventory] Updated: %s (qty: %d)", item.ProductID, item.Quantity)
+		err = inventoryRepo.Reserve(ctx, payload.OrderID, requests)
+		if errors.Is(err, repository.ErrInsufficientStock) || errors.Is(err, repository.ErrProductNotFound) {
+			log.Printf("🚫 [Inventory] Rejecting order %s: %v", payload.OrderID, err)
+			reason := err.Error()
+			return updateOrder(ctx, d, payload.OrderID, func(o *domain.Order) {
+				if o.Status.IsTerminal() {
+					return
+				}
+				o.Cancel()
+				o.Notes = "Rejected: " + reason
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to reserve stock for order %s: %w", payload.OrderID, err)
 		}
 
-		// Persist "processing" status (if already confirmed by payment)
-		_ = updateOrder(ctx, orderRepo, payload.OrderID, func(o *domain.Order) {
+		// Persist "processing" status (if already confirmed by payment).
+		// The order may have been cancelled while we were reserving; give the stock back.
+		var cancelled bool
+		_ = updateOrder(ctx, d, payload.OrderID, func(o *domain.Order) {
+			cancelled = o.Status.IsTerminal()
 			if o.Status == domain.OrderStatusConfirmed {
 				o.UpdateStatus(domain.OrderStatusProcessing)
 			}
 		})
+		if cancelled {
+			if err := inventoryRepo.Release(ctx, payload.OrderID); err != nil {
+				return fmt.Errorf("failed to release stock for order %s: %w", payload.OrderID, err)
+			}
+			log.Printf("↩️  [Inventory] Order %s ended while reserving, stock released", payload.OrderID)
+			return nil
+		}
 
-		log.Printf("✅ [Inventory] All items updated for order: %s", payload.OrderID)
+		log.Printf("✅ [Inventory] All items reserved for order: %s", payload.OrderID)
 		return nil
 	}
 }
-
-// HandleInventoryUpdateTask updates inventory for order items
-func HandleInventoryUpdateTask(ctx context.Context, t *asynq.Task) error {
-	var payload InventoryPayload
-	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
-		return fmt.Errorf("failed to unmarshal inventory payload: %w", err)
-	}
-
-	log.Printf("📦 [Inventory] Updating inventory for order: %s", payload.OrderID)
-	log.Printf("📦 [Inventory] Items to update: %d", len(payload.Items))
-
-	// Simulate inventory update (500ms)
-	time.Sleep(500 * time.Millisecond)
-
-	// Process each item
-	for _, item := range payload.Items {
-		if err := updateInventoryItem(item); err != nil {
-			return fmt.Errorf("failed to update inventory for product %s: %w", item.ProductID, err)
-		}
-		log.Printf("📦 [Inventory] Updated: %s (qty: %d)", item.ProductID, item.Quantity)
-	}
-
-	log.Printf("✅ [Inventory] All items updated for order: %s", payload.OrderID)
-	return nil
-}
-
-// updateInventoryItem updates a single inventory item
-func updateInventoryItem(item InventoryItem) error {
-	// In production: Update database inventory table
-	// Example: inventoryRepo.DecrementStock(item.ProductID, item.Quantity)
-	
-	// Simulate database update
-	time.Sleep(100 * time.Millisecond)
-	return nil
-}
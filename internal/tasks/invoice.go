@@ -2,14 +2,13 @@ package tasks
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/hibiken/asynq"
 	"github.com/lppduy/go-asynq-loadtest/internal/domain"
-	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+	"github.com/lppduy/go-asynq-loadtest/internal/invoice"
 )
 
 const (
@@ -18,99 +17,59 @@ const (
 
 // InvoicePayload represents the payload for invoice generation
 type InvoicePayload struct {
-	OrderID       string  `json:"order_id"`
-	CustomerName  string  `json:"customer_name"`
-	CustomerEmail string  `json:"customer_email"`
-	TotalAmount   float64 `json:"total_amount"`
+	OrderID       string       `json:"order_id" pb:"1"`
+	CustomerName  string       `json:"customer_name" pb:"2"`
+	CustomerEmail string       `json:"customer_email" pb:"3"`
+	TotalAmount   domain.Money `json:"total_amount" pb:"4"`
 }
 
-// NewInvoiceGenerateTask creates a new invoice generation task
-func NewInvoiceGenerateTask(orderID, customerName, customerEmail string, totalAmount float64) (*asynq.Task, error) {
-	payload, err := json.Marshal(InvoicePayload{
-		OrderID:       orderID,
-		CustomerName:  customerName,
-		CustomerEmail: customerEmail,
-		TotalAmount:   totalAmount,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal invoice payload: %w", err)
-	}
-
-	return asynq.NewTask(
-		TypeInvoiceGenerate,
-		payload,
+// InvoiceGenerate renders the invoice of an order and stores its URL
+var InvoiceGenerate = Define(TaskDef[InvoicePayload]{
+	Type: TypeInvoiceGenerate,
+	Options: []asynq.Option{
 		asynq.MaxRetry(3),
-		asynq.Timeout(60*time.Second),  // PDF generation can take time
+		asynq.Timeout(60 * time.Second), // Rendering is fast, uploads may not be
 		asynq.Queue("default"),
-		asynq.ProcessIn(5*time.Second), // Generate after 5 seconds
-	), nil
-}
-
-// NewInvoiceGenerateHandler returns a handler that also updates invoice_url in PostgreSQL.
-func NewInvoiceGenerateHandler(orderRepo repository.OrderRepository) func(context.Context, *asynq.Task) error {
-	return func(ctx context.Context, t *asynq.Task) error {
-		var payload InvoicePayload
-		if err := json.Unmarshal(t.Payload(), &payload); err != nil {
-			return fmt.Errorf("failed to unmarshal invoice payload: %w", err)
-		}
-
+		asynq.ProcessIn(5 * time.Second), // Generate after 5 seconds
+	},
+	// Version 1 had float amounts without a currency
+	Upcasters: []Upcaster{upcastLegacyMoney("total_amount")},
+	// Deterministic ID so order edits can find and replace it
+	TaskID:       func(p InvoicePayload) string { return OrderTaskID(TypeInvoiceGenerate, p.OrderID) },
+	Handler:      newInvoiceGenerateHandler,
+	RequireOrder: true,
+})
+
+// newInvoiceGenerateHandler returns a handler that renders the PDF from the order as
+// it is now, stores it in the blob store and sets the order's invoice_url. Orders
+// edited before payment run it again and overwrite the file.
+func newInvoiceGenerateHandler(d Deps) func(context.Context, InvoicePayload) error {
+	orderRepo, blobs := d.OrderRepo, d.Blobs
+	return func(ctx context.Context, payload InvoicePayload) error {
 		log.Printf("🧾 [Invoice] Generating invoice for order: %s", payload.OrderID)
-		log.Printf("🧾 [Invoice] Customer: %s | Amount: $%.2f", payload.CustomerName, payload.TotalAmount)
 
-		// Simulate PDF generation (3 seconds)
-		time.Sleep(3 * time.Second)
+		order, err := orderRepo.FindByID(ctx, payload.OrderID)
+		if err != nil {
+			return err
+		}
 
-		invoiceURL, err := generateInvoicePDF(payload)
+		pdf, err := invoice.Render(order, time.Now())
 		if err != nil {
-			return fmt.Errorf("failed to generate invoice PDF: %w", err)
+			return fmt.Errorf("failed to render invoice PDF: %w", err)
+		}
+		if err := blobs.Put(ctx, invoice.Key(order.ID), pdf, invoice.ContentType); err != nil {
+			return fmt.Errorf("failed to store invoice PDF: %w", err)
 		}
 
-		// Persist invoice URL into PostgreSQL
-		if err := updateOrder(ctx, orderRepo, payload.OrderID, func(o *domain.Order) {
+		invoiceURL := invoice.URL(order.ID)
+		if err := updateOrder(ctx, d, payload.OrderID, func(o *domain.Order) {
 			o.InvoiceURL = invoiceURL
 		}); err != nil {
 			return err
 		}
 
-		log.Printf("✅ [Invoice] Invoice generated: %s", invoiceURL)
+		log.Printf("✅ [Invoice] Invoice generated: %s | %d items, %s, %d bytes",
+			invoiceURL, len(order.Items), order.TotalAmount, len(pdf))
 		return nil
 	}
 }
-
-// HandleInvoiceGenerateTask generates PDF invoice for order
-func HandleInvoiceGenerateTask(ctx context.Context, t *asynq.Task) error {
-	var payload InvoicePayload
-	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
-		return fmt.Errorf("failed to unmarshal invoice payload: %w", err)
-	}
-
-	log.Printf("🧾 [Invoice] Generating invoice for order: %s", payload.OrderID)
-	log.Printf("🧾 [Invoice] Customer: %s | Amount: $%.2f", payload.CustomerName, payload.TotalAmount)
-
-	// Simulate PDF generation (3 seconds)
-	time.Sleep(3 * time.Second)
-
-	// Generate invoice PDF
-	invoiceURL, err := generateInvoicePDF(payload)
-	if err != nil {
-		return fmt.Errorf("failed to generate invoice PDF: %w", err)
-	}
-
-	log.Printf("✅ [Invoice] Invoice generated: %s", invoiceURL)
-	// In production: Update order with invoice URL
-	// orderRepo.UpdateInvoiceURL(ctx, payload.OrderID, invoiceURL)
-
-	return nil
-}
-
-// generateInvoicePDF generates PDF invoice and uploads to storage
-func generateInvoicePDF(payload InvoicePayload) (string, error) {
-	// In production:
-	// 1. Generate PDF using library (e.g., go-pdf, wkhtmltopdf)
-	// 2. Upload to S3/GCS
-	// 3. Return public URL
-	
-	// Simulate PDF generation
-	invoiceURL := fmt.Sprintf("https://storage.example.com/invoices/%s.pdf", payload.OrderID)
-	return invoiceURL, nil
-}
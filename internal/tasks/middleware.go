@@ -0,0 +1,174 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+	"github.com/lppduy/go-asynq-loadtest/pkg/metrics"
+)
+
+// Router registers task handlers on an asynq.ServeMux behind a middleware chain:
+// the common middlewares of the router, then the ones given for the task type.
+// The first middleware is the outermost.
+type Router struct {
+	mux    *asynq.ServeMux
+	common []asynq.MiddlewareFunc
+}
+
+// NewRouter creates a router whose handlers all run behind common
+func NewRouter(mux *asynq.ServeMux, common ...asynq.MiddlewareFunc) *Router {
+	return &Router{mux: mux, common: common}
+}
+
+// Handle registers h for taskType behind the common and the given middlewares
+func (r *Router) Handle(taskType string, h asynq.Handler, mws ...asynq.MiddlewareFunc) {
+	chain := append(append([]asynq.MiddlewareFunc{}, r.common...), mws...)
+	for i := len(chain) - 1; i >= 0; i-- {
+		h = chain[i](h)
+	}
+	r.mux.Handle(taskType, h)
+}
+
+// HandleFunc registers a handler function for taskType, see Handle
+func (r *Router) HandleFunc(taskType string, fn func(context.Context, *asynq.Task) error, mws ...asynq.MiddlewareFunc) {
+	r.Handle(taskType, asynq.HandlerFunc(fn), mws...)
+}
+
+// Recover turns a panicking handler into a permanent failure: the same payload
+// would panic again, so the task is archived instead of retried
+func Recover() asynq.MiddlewareFunc {
+	return func(next asynq.Handler) asynq.Handler {
+		return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = panicError(t, r)
+				}
+			}()
+			return next.ProcessTask(ctx, t)
+		})
+	}
+}
+
+// panicError logs a recovered panic with its stack and wraps it in asynq.SkipRetry
+func panicError(t *asynq.Task, r interface{}) error {
+	log.Printf("💥 [Panic] Task %s: %v\n%s", t.Type(), r, debug.Stack())
+	return fmt.Errorf("panic in %s handler: %v: %w", t.Type(), r, asynq.SkipRetry)
+}
+
+// Logging writes one structured log line per processed task with its type, ID,
+// queue, attempt, duration and outcome
+func Logging() asynq.MiddlewareFunc {
+	return func(next asynq.Handler) asynq.Handler {
+		return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+			start := time.Now()
+			err := next.ProcessTask(ctx, t)
+
+			id, _ := asynq.GetTaskID(ctx)
+			queue, _ := asynq.GetQueueName(ctx)
+			retried, _ := asynq.GetRetryCount(ctx)
+			maxRetry, _ := asynq.GetMaxRetry(ctx)
+			attrs := []any{
+				slog.String("type", t.Type()),
+				slog.String("id", id),
+				slog.String("queue", queue),
+				slog.Int("attempt", retried+1),
+				slog.Int("max_retry", maxRetry),
+				slog.Int64("duration_ms", time.Since(start).Milliseconds()),
+				slog.String("result", taskResult(err)),
+			}
+			if err != nil {
+				slog.ErrorContext(ctx, "task failed", append(attrs, slog.String("error", err.Error()))...)
+				return err
+			}
+			slog.InfoContext(ctx, "task done", attrs...)
+			return nil
+		})
+	}
+}
+
+// Metrics records the duration of every task by type and result
+func Metrics() asynq.MiddlewareFunc {
+	return func(next asynq.Handler) asynq.Handler {
+		return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+			start := time.Now()
+			err := next.ProcessTask(ctx, t)
+			metrics.TaskDuration.WithLabelValues(t.Type(), taskResult(err)).Observe(time.Since(start).Seconds())
+			return err
+		})
+	}
+}
+
+// taskResult labels the outcome of a task: success, error (retried) or
+// permanent (archived without retry)
+func taskResult(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, asynq.SkipRetry):
+		return "permanent"
+	default:
+		return "error"
+	}
+}
+
+// Deadline gives the handler a context that ends after d, whatever timeout the
+// task was enqueued with. Handlers stop at their next context check; a run that
+// hits the deadline fails with context.DeadlineExceeded and is retried.
+func Deadline(d time.Duration) asynq.MiddlewareFunc {
+	return func(next asynq.Handler) asynq.Handler {
+		return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+
+			err := next.ProcessTask(ctx, t)
+			if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && !errors.Is(err, context.DeadlineExceeded) {
+				return fmt.Errorf("%s did not finish within %s: %w: %w", t.Type(), d, err, context.DeadlineExceeded)
+			}
+			return err
+		})
+	}
+}
+
+// Validator is implemented by payloads that can check their own fields.
+// TaskDef.Decode runs it before the handler.
+type Validator interface {
+	Validate() error
+}
+
+// RequireOrder skips tasks whose order_id does not exist (deleted, or never
+// saved). The payload is read with the TaskDef of its type, so it works for
+// task types declared with Define. Lookup errors are returned so the task is retried.
+func RequireOrder(orderRepo repository.OrderRepository) asynq.MiddlewareFunc {
+	return func(next asynq.Handler) asynq.Handler {
+		return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+			def, ok := lookup(t.Type())
+			if !ok {
+				return fmt.Errorf("%s is not a declared task type: %w", t.Type(), asynq.SkipRetry)
+			}
+			orderID, err := def.orderID(t)
+			if err != nil {
+				return err
+			}
+			if orderID == "" {
+				return fmt.Errorf("%s payload has no order_id: %w", t.Type(), asynq.SkipRetry)
+			}
+
+			_, err = orderRepo.FindByID(ctx, orderID)
+			if errors.Is(err, repository.ErrOrderNotFound) {
+				log.Printf("⏭️  [%s] Order %s does not exist, archiving task", t.Type(), orderID)
+				return fmt.Errorf("order %s: %w", orderID, asynq.SkipRetry)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to load order %s: %w", orderID, err)
+			}
+			return next.ProcessTask(ctx, t)
+		})
+	}
+}
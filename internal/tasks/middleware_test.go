@@ -0,0 +1,40 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+func TestDeadline(t *testing.T) {
+	task := asynq.NewTask("test:slow", nil)
+	slow := asynq.HandlerFunc(func(ctx context.Context, _ *asynq.Task) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return nil
+		}
+	})
+
+	// The enqueue timeout is longer, the per-type deadline still applies
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	start := time.Now()
+	err := Deadline(20*time.Millisecond)(slow).ProcessTask(ctx, task)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if took := time.Since(start); took > 500*time.Millisecond {
+		t.Errorf("handler ran %s past its deadline", took)
+	}
+
+	fast := asynq.HandlerFunc(func(context.Context, *asynq.Task) error { return nil })
+	if err := Deadline(time.Second)(fast).ProcessTask(ctx, task); err != nil {
+		t.Errorf("handler within its deadline: err = %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package tasks
+
+import "fmt"
+
+// OrderTask names a per-order task and the queue it is enqueued on
+type OrderTask struct {
+	Type       string
+	Queue      string
+	Rerunnable bool // Harmless to run again for an edited order once completed
+}
+
+// ReplaceableOrderTasks carry an order's items, total or address in their
+// payload. They are enqueued with OrderTaskID so an order edit can find them
+// with asynq.Inspector, delete them and enqueue replacements. Payment comes
+// first: once it has started the order can no longer be edited.
+var ReplaceableOrderTasks = []OrderTask{
+	{Type: TypePaymentProcess, Queue: PaymentProcess.Queue()},
+	{Type: TypeInventoryUpdate, Queue: InventoryUpdate.Queue(), Rerunnable: true},
+	{Type: TypeInvoiceGenerate, Queue: InvoiceGenerate.Queue(), Rerunnable: true},
+	{Type: TypeWarehouseNotify, Queue: WarehouseNotify.Queue()},
+}
+
+// OrderTaskID returns the deterministic task ID of a per-order task,
+// e.g. "payment:process:ORD-1a2b3c4d"
+func OrderTaskID(taskType, orderID string) string {
+	return fmt.Sprintf("%s:%s", taskType, orderID)
+}
@@ -2,27 +2,26 @@ package tasks
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"time"
+
+	"github.com/hibiken/asynq"
 
 	"github.com/lppduy/go-asynq-loadtest/internal/domain"
 	"github.com/lppduy/go-asynq-loadtest/internal/repository"
 )
 
-func updateOrder(ctx context.Context, repo repository.OrderRepository, orderID string, mutate func(o *domain.Order)) error {
-	order, err := repo.FindByID(ctx, orderID)
-	if err != nil {
-		return err
-	}
-
-	mutate(order)
-	if order.UpdatedAt.IsZero() {
-		order.UpdatedAt = time.Now()
+// updateOrder applies mutate to the order with its row locked, so tasks of one
+// order take turns (see repository.UpdateOrder). Status only moves forward: a
+// change that would take the order back, like a late payment turning shipped
+// into confirmed, is not saved and fails the task without retry.
+func updateOrder(ctx context.Context, d Deps, orderID string, mutate func(o *domain.Order)) error {
+	err := repository.UpdateOrder(ctx, d.Transactor, d.OrderRepo, orderID, func(o *domain.Order) bool {
+		mutate(o)
+		return true
+	})
+	if errors.Is(err, domain.ErrInvalidStatusTransition) {
+		return fmt.Errorf("%w: %w", err, asynq.SkipRetry)
 	}
-
-	if err := repo.Update(ctx, order); err != nil {
-		return fmt.Errorf("failed to update order %s: %w", orderID, err)
-	}
-	return nil
+	return err
 }
-
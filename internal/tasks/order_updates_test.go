@@ -0,0 +1,103 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+	"github.com/lppduy/go-asynq-loadtest/pkg/database"
+	"gorm.io/gorm"
+)
+
+// newTestDB returns a migrated SQLite database in a temporary directory
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.Connect(database.Config{
+		Driver:     database.DriverSQLite,
+		SQLitePath: filepath.Join(t.TempDir(), "orders.db"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.MigrateUp(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestUpdateOrderIsForwardOnly(t *testing.T) {
+	db := newTestDB(t)
+	transactor := repository.NewGormTransactor(db)
+
+	// GORM sets updated_at on every write by itself, the memory store does not
+	t.Run("gorm", func(t *testing.T) {
+		testUpdateOrderIsForwardOnly(t, Deps{OrderRepo: repository.NewGormOrderRepository(db), Transactor: transactor})
+	})
+	t.Run("memory", func(t *testing.T) {
+		testUpdateOrderIsForwardOnly(t, Deps{OrderRepo: repository.NewMemoryOrderRepository(), Transactor: transactor})
+	})
+}
+
+func testUpdateOrderIsForwardOnly(t *testing.T, d Deps) {
+	ctx := context.Background()
+	order := &domain.Order{
+		ID:            "ORD-1a2b3c4d",
+		CustomerID:    "cust-42",
+		CustomerEmail: "cust-42@example.com",
+		Items: []domain.OrderItem{{ProductID: "prod-1", ProductName: "Product 1", Quantity: 1,
+			UnitPrice: domain.NewMoney(1000, "USD"), Subtotal: domain.NewMoney(1000, "USD")}},
+		TotalAmount:   domain.NewMoney(1000, "USD"),
+		Status:        domain.OrderStatusShipped,
+		PaymentStatus: domain.PaymentStatusCompleted,
+		CreatedAt:     time.Now().Add(-time.Hour),
+		UpdatedAt:     time.Now().Add(-time.Hour),
+	}
+	if err := d.OrderRepo.Create(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+
+	// Updates that leave the status alone still count as activity for reconciliation
+	if err := updateOrder(ctx, d, order.ID, func(o *domain.Order) { o.InvoiceURL = "invoices/ORD-1a2b3c4d.pdf" }); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := d.OrderRepo.FindByID(ctx, order.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(stored.UpdatedAt) > time.Minute {
+		t.Errorf("updated_at = %s, want bumped by the invoice update", stored.UpdatedAt)
+	}
+
+	// A late payment must not take a shipped order back to confirmed
+	err = updateOrder(ctx, d, order.ID, func(o *domain.Order) {
+		o.UpdatePaymentStatus(domain.PaymentStatusCompleted)
+		o.Notes = "late payment"
+	})
+	if !errors.Is(err, domain.ErrInvalidStatusTransition) || !errors.Is(err, asynq.SkipRetry) {
+		t.Fatalf("moving back = %v, want ErrInvalidStatusTransition and SkipRetry", err)
+	}
+	stored, err = d.OrderRepo.FindByID(ctx, order.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != domain.OrderStatusShipped || stored.Notes != "" {
+		t.Fatalf("rejected update was saved: status %s, notes %q", stored.Status, stored.Notes)
+	}
+
+	// Moving forward, or not changing the status, is saved
+	for _, status := range []domain.OrderStatus{domain.OrderStatusShipped, domain.OrderStatusDelivered} {
+		if err := updateOrder(ctx, d, order.ID, func(o *domain.Order) { o.UpdateStatus(status) }); err != nil {
+			t.Fatalf("update to %s: %v", status, err)
+		}
+	}
+	// Delivered is final, even for cancellation
+	err = updateOrder(ctx, d, order.ID, func(o *domain.Order) { o.Cancel() })
+	if !errors.Is(err, domain.ErrInvalidStatusTransition) {
+		t.Fatalf("cancelling a delivered order = %v, want ErrInvalidStatusTransition", err)
+	}
+}
@@ -0,0 +1,372 @@
+package tasks_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hibiken/asynq"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/tasks"
+)
+
+// The golden files pin the wire format of every task payload version and
+// codec, so that old payloads still decode after a payload type changes.
+//
+// Each task type has a directory under goldenRoot (payment:process in
+// payment_process/) holding one sample payload as every producer version
+// wrote it. v<N>.json, v<N>.msgpack and v<N>.protobuf are the current
+// version: they must be exactly what NewTask writes today with each codec and
+// are regenerated with -update. Older files are frozen: they are the shapes
+// still sitting in queues during a rolling deploy, and every one must decode
+// to the sample.
+
+var update = flag.Bool("update", false, "rewrite the golden files of the current payload versions")
+
+// goldenRoot is the golden file root, relative to the package
+const goldenRoot = "testdata/payloads"
+
+// codecs are the codecs with golden files
+var codecs = []tasks.Codec{tasks.JSON, tasks.Msgpack, tasks.Protobuf}
+
+// sample is one task type with a sample payload
+type sample struct {
+	taskType string
+	test     func(t *testing.T)
+	encode   func(b *testing.B, c tasks.Codec)
+	decode   func(b *testing.B, c tasks.Codec)
+}
+
+// samples returns a sample of every task type
+func samples() []sample {
+	return []sample{
+		newSample(tasks.PaymentProcess, tasks.PaymentPayload{
+			OrderID:       "ORD-1a2b3c4d",
+			Amount:        domain.NewMoney(12999, "USD"),
+			PaymentMethod: "credit_card",
+		}),
+		newSample(tasks.PaymentRefund, tasks.PaymentPayload{
+			OrderID:       "ORD-1a2b3c4d",
+			Amount:        domain.NewMoney(12999, "USD"),
+			PaymentMethod: "credit_card",
+		}),
+		newSample(tasks.InventoryUpdate, tasks.InventoryPayload{
+			OrderID: "ORD-1a2b3c4d",
+			Items: []tasks.InventoryItem{
+				{ProductID: "prod-1", Quantity: 2},
+				{ProductID: "prod-2", Quantity: 1},
+			},
+		}),
+		newSample(tasks.InvoiceGenerate, tasks.InvoicePayload{
+			OrderID:       "ORD-1a2b3c4d",
+			CustomerName:  "cust-42",
+			CustomerEmail: "cust-42@example.com",
+			TotalAmount:   domain.NewMoney(12999, "USD"),
+		}),
+		newSample(tasks.WarehouseNotify, tasks.WarehousePayload{
+			OrderID:         "ORD-1a2b3c4d",
+			CustomerName:    "cust-42",
+			ShippingAddress: "123 Main St, San Francisco, CA 94102, USA",
+			ItemCount:       3,
+			Priority:        "express",
+		}),
+		newSample(tasks.EmailConfirmation, tasks.EmailPayload{
+			OrderID:       "ORD-1a2b3c4d",
+			CustomerEmail: "cust-42@example.com",
+			CustomerName:  "cust-42",
+			TotalAmount:   domain.NewMoney(12999, "USD"),
+		}),
+		newSample(tasks.EmailCancellation, tasks.EmailPayload{
+			OrderID:       "ORD-1a2b3c4d",
+			CustomerEmail: "cust-42@example.com",
+			CustomerName:  "Ada Lovelace",
+			TotalAmount:   domain.NewMoney(12999, "USD"),
+		}),
+		newSample(tasks.EmailRefund, tasks.EmailPayload{
+			OrderID:       "ORD-1a2b3c4d",
+			CustomerEmail: "cust-42@example.com",
+			CustomerName:  "Ada Lovelace",
+			TotalAmount:   domain.NewMoney(12999, "USD"),
+		}),
+		newSample(tasks.EmailShipping, tasks.EmailPayload{
+			OrderID:       "ORD-1a2b3c4d",
+			CustomerEmail: "cust-42@example.com",
+			CustomerName:  "Ada Lovelace",
+			TotalAmount:   domain.NewMoney(12999, "USD"),
+		}),
+		newSample(tasks.AnalyticsTrack, tasks.AnalyticsPayload{
+			OrderID:       "ORD-1a2b3c4d",
+			CustomerID:    "cust-42",
+			TotalAmount:   domain.NewMoney(12999, "USD"),
+			ItemCount:     3,
+			PaymentMethod: "credit_card",
+			CreatedAt:     "2026-10-18T16:00:00Z",
+		}),
+		newSample(tasks.AnalyticsFlush, tasks.AnalyticsBatchPayload{
+			Events: []tasks.AnalyticsPayload{
+				{OrderID: "ORD-1a2b3c4d", CustomerID: "cust-42", TotalAmount: domain.NewMoney(12999, "USD"),
+					ItemCount: 3, PaymentMethod: "credit_card", CreatedAt: "2026-10-18T16:00:00Z"},
+				{OrderID: "ORD-5e6f7a8b", CustomerID: "cust-7", TotalAmount: domain.NewMoney(4500, "EUR"),
+					ItemCount: 1, PaymentMethod: "paypal", CreatedAt: "2026-10-18T16:00:02Z"},
+			},
+		}),
+		newSample(tasks.WebhookDeliver, tasks.WebhookPayload{
+			SubscriptionID: "whs_1a2b3c4d",
+			EventID:        "evt_1a2b3c4d",
+			EventType:      "order.created",
+			Body:           json.RawMessage(`{"id":"evt_1a2b3c4d","type":"order.created","data":{"order_id":"ORD-1a2b3c4d"}}`),
+		}),
+		newSample(tasks.OrdersReconcile, tasks.ReconcilePayload{}),
+	}
+}
+
+// newSample builds the golden checks and the benchmarks of one task type
+func newSample[P any](def *tasks.TaskDef[P], payload P) sample {
+	return sample{
+		taskType: def.Type,
+		test: func(t *testing.T) {
+			for _, c := range codecs {
+				t.Run(fmt.Sprintf("encodes as v%d.%s", def.Version(), c.Name()), func(t *testing.T) {
+					checkEncoding(t, def, payload, c)
+				})
+			}
+			t.Run("decodes every golden file", func(t *testing.T) { checkDecoding(t, def, payload) })
+			t.Run("matches payloads.proto", func(t *testing.T) { checkProtoSchema(t, def, payload) })
+			t.Run("rejects unknown fields", func(t *testing.T) { checkUnknownField(t, def) })
+			t.Run(fmt.Sprintf("rejects version %d", def.Version()+1), func(t *testing.T) { checkNewerVersion(t, def) })
+		},
+		encode: func(b *testing.B, c tasks.Codec) { benchEncode(b, def, payload, c) },
+		decode: func(b *testing.B, c tasks.Codec) { benchDecode(b, def, payload, c) },
+	}
+}
+
+func TestPayloadGolden(t *testing.T) {
+	covered := map[string]bool{}
+	for _, s := range samples() {
+		covered[s.taskType] = true
+		t.Run(s.taskType, s.test)
+	}
+
+	t.Run("every task type has golden files", func(t *testing.T) {
+		for _, def := range tasks.Definitions() {
+			if !covered[def.Type] {
+				t.Errorf("%s has no sample", def.Type)
+				continue
+			}
+			for _, c := range codecs {
+				if _, err := os.Stat(goldenPath(def.Type, def.Version, c)); err != nil {
+					t.Error(err)
+				}
+			}
+		}
+	})
+}
+
+func checkEncoding[P any](t *testing.T, def *tasks.TaskDef[P], payload P, c tasks.Codec) {
+	data, err := def.Encode(c, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c == tasks.JSON {
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, data, "", "  "); err != nil {
+			t.Fatal(err)
+		}
+		data = append(pretty.Bytes(), '\n')
+	}
+
+	path := goldenPath(def.Type, def.Version(), c)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if !bytes.Equal(golden, data) {
+		t.Fatalf("encoding changed, got\n%q\nwant\n%q\nIf %T changed shape, add an upcaster instead of editing %s",
+			data, golden, payload, filepath.Base(path))
+	}
+}
+
+func checkDecoding[P any](t *testing.T, def *tasks.TaskDef[P], payload P) {
+	want, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(goldenRoot, goldenDir(def.Type), "v*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("no golden files in %s", filepath.Join(goldenRoot, goldenDir(def.Type)))
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := def.Decode(asynq.NewTask(def.Type, data))
+		if err != nil {
+			t.Errorf("%s: %v", filepath.Base(file), err)
+			continue
+		}
+		got, err := json.Marshal(decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s decoded to\n%s\nwant\n%s", filepath.Base(file), got, want)
+		}
+	}
+}
+
+func checkUnknownField[P any](t *testing.T, def *tasks.TaskDef[P]) {
+	for _, c := range codecs {
+		data, err := os.ReadFile(goldenPath(def.Type, def.Version(), c))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, err = withUnknownField(c, data); err != nil {
+			t.Fatal(err)
+		}
+		if err := expectPermanent(def, data, nil); err != nil {
+			t.Errorf("%s: %v", c.Name(), err)
+		}
+	}
+}
+
+func checkNewerVersion[P any](t *testing.T, def *tasks.TaskDef[P]) {
+	for _, c := range codecs {
+		data, err := os.ReadFile(goldenPath(def.Type, def.Version(), c))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, err = withVersion(c, data, def.Version()+1); err != nil {
+			t.Fatal(err)
+		}
+		if err := expectPermanent(def, data, tasks.ErrUnsupportedPayloadVersion); err != nil {
+			t.Errorf("%s: %v", c.Name(), err)
+		}
+	}
+}
+
+// expectPermanent decodes data and wants an asynq.SkipRetry error, wrapping target if given
+func expectPermanent[P any](def *tasks.TaskDef[P], data []byte, target error) error {
+	_, err := def.Decode(asynq.NewTask(def.Type, data))
+	switch {
+	case err == nil:
+		return errors.New("decoded without error")
+	case !errors.Is(err, asynq.SkipRetry):
+		return fmt.Errorf("error is not permanent: %v", err)
+	case target != nil && !errors.Is(err, target):
+		return fmt.Errorf("got %v, want %v", err, target)
+	}
+	return nil
+}
+
+// goldenEnvelope mirrors the envelope of JSON payloads
+type goldenEnvelope struct {
+	Version int             `json:"version"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// withUnknownField adds a field the payload type does not have
+func withUnknownField(c tasks.Codec, data []byte) ([]byte, error) {
+	switch c {
+	case tasks.JSON:
+		var env goldenEnvelope
+		if err := json.Unmarshal(data, &env); err != nil {
+			return nil, err
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(env.Payload, &fields); err != nil {
+			return nil, err
+		}
+		fields["unexpected_field"] = json.RawMessage(`true`)
+		payload, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		env.Payload = payload
+		return json.Marshal(env)
+
+	case tasks.Protobuf:
+		data = protowire.AppendTag(data, 99, protowire.VarintType)
+		return protowire.AppendVarint(data, 1), nil
+
+	default:
+		header, body, err := splitFrame(data)
+		if err != nil {
+			return nil, err
+		}
+		h := &codec.MsgpackHandle{}
+		h.WriteExt = true
+		var fields map[string]interface{}
+		if err := codec.NewDecoderBytes(body, h).Decode(&fields); err != nil {
+			return nil, err
+		}
+		fields["unexpected_field"] = true
+		var out []byte
+		if err := codec.NewEncoderBytes(&out, h).Encode(fields); err != nil {
+			return nil, err
+		}
+		return append(header, out...), nil
+	}
+}
+
+// withVersion rewrites the payload version
+func withVersion(c tasks.Codec, data []byte, version int) ([]byte, error) {
+	if c == tasks.JSON {
+		var env goldenEnvelope
+		if err := json.Unmarshal(data, &env); err != nil {
+			return nil, err
+		}
+		env.Version = version
+		return json.Marshal(env)
+	}
+	header, body, err := splitFrame(data)
+	if err != nil {
+		return nil, err
+	}
+	frame := binary.AppendUvarint(header[:2:2], uint64(version))
+	return append(frame, body...), nil
+}
+
+// splitFrame splits a binary payload into its frame header and body
+func splitFrame(data []byte) ([]byte, []byte, error) {
+	if len(data) < 3 {
+		return nil, nil, errors.New("truncated payload frame")
+	}
+	_, n := binary.Uvarint(data[2:])
+	if n <= 0 {
+		return nil, nil, errors.New("invalid version in payload frame")
+	}
+	return data[:2+n], data[2+n:], nil
+}
+
+func goldenDir(taskType string) string {
+	return strings.ReplaceAll(taskType, ":", "_")
+}
+
+func goldenPath(taskType string, version int, c tasks.Codec) string {
+	return filepath.Join(goldenRoot, goldenDir(taskType), fmt.Sprintf("v%d.%s", version, c.Name()))
+}
@@ -0,0 +1,235 @@
+package tasks_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hibiken/asynq"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/tasks"
+)
+
+// The protobuf codec numbers fields by their pb tags; payloads.proto is what
+// other consumers compile. checkProtoSchema ties the two together: the sample
+// is written with the official protobuf runtime from the message in
+// payloads.proto (fields matched by name, numbered by the .proto) and must
+// decode to the sample, and the codec's own bytes must read back as the same
+// message.
+
+var (
+	schemaOnce sync.Once
+	schema     protoreflect.FileDescriptor
+	schemaErr  error
+)
+
+func checkProtoSchema[P any](t *testing.T, def *tasks.TaskDef[P], payload P) {
+	schemaOnce.Do(func() { schema, schemaErr = loadPayloadsProto("payloads.proto") })
+	if schemaErr != nil {
+		t.Fatal(schemaErr)
+	}
+
+	name := reflect.TypeOf(payload).Name()
+	desc := schema.Messages().ByName(protoreflect.Name(name))
+	if desc == nil {
+		t.Fatalf("payloads.proto has no message %s", name)
+	}
+
+	want := dynamicpb.NewMessage(desc)
+	if err := fillMessage(want, reflect.ValueOf(payload)); err != nil {
+		t.Fatal(err)
+	}
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Frame the .proto bytes like the codec does
+	golden, err := os.ReadFile(goldenPath(def.Type, def.Version(), tasks.Protobuf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, _, err := splitFrame(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := def.Decode(asynq.NewTask(def.Type, append(append([]byte(nil), header...), body...)))
+	if err != nil {
+		t.Fatalf("decoding bytes written from payloads.proto: %v", err)
+	}
+	got, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantJSON, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, wantJSON) {
+		t.Errorf("bytes written from payloads.proto decoded to\n%s\nwant\n%s", got, wantJSON)
+	}
+
+	encoded, err := def.Encode(tasks.Protobuf, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ours, err := splitFrame(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := dynamicpb.NewMessage(desc)
+	if err := proto.Unmarshal(ours, read); err != nil {
+		t.Fatalf("codec output is not a %s: %v", name, err)
+	}
+	if !proto.Equal(read, want) {
+		t.Errorf("codec output reads as\n%v\nwith payloads.proto, want\n%v", read, want)
+	}
+}
+
+// fillMessage sets the fields of m from the struct v. Go fields are matched to
+// message fields by their JSON name (lowercased field name when untagged), so
+// field numbers come from the .proto only. Zero values are left unset.
+func fillMessage(m protoreflect.Message, v reflect.Value) error {
+	fields := m.Descriptor().Fields()
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := strings.ToLower(sf.Name)
+		if tag, ok := sf.Tag.Lookup("json"); ok {
+			name = strings.Split(tag, ",")[0]
+		}
+		fd := fields.ByName(protoreflect.Name(name))
+		if fd == nil {
+			return fmt.Errorf("%s has no field %s (%s.%s)", m.Descriptor().Name(), name, v.Type().Name(), sf.Name)
+		}
+
+		field := v.Field(i)
+		if field.IsZero() {
+			continue
+		}
+		switch {
+		case fd.IsList() && fd.Kind() == protoreflect.MessageKind:
+			list := m.Mutable(fd).List()
+			for j := 0; j < field.Len(); j++ {
+				elem := list.NewElement()
+				if err := fillMessage(elem.Message(), field.Index(j)); err != nil {
+					return err
+				}
+				list.Append(elem)
+			}
+		case fd.Kind() == protoreflect.MessageKind:
+			if err := fillMessage(m.Mutable(fd).Message(), field); err != nil {
+				return err
+			}
+		default:
+			value, err := scalarValue(fd, field)
+			if err != nil {
+				return fmt.Errorf("%s.%s: %w", m.Descriptor().Name(), name, err)
+			}
+			m.Set(fd, value)
+		}
+	}
+	return nil
+}
+
+func scalarValue(fd protoreflect.FieldDescriptor, v reflect.Value) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(v.String()), nil
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes(v.Bytes()), nil
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(v.Bool()), nil
+	case protoreflect.Int64Kind:
+		return protoreflect.ValueOfInt64(v.Int()), nil
+	case protoreflect.Int32Kind:
+		return protoreflect.ValueOfInt32(int32(v.Int())), nil
+	}
+	return protoreflect.Value{}, fmt.Errorf("unsupported kind %s", fd.Kind())
+}
+
+// protoScalars maps the scalar types payloads.proto uses to descriptor types
+var protoScalars = map[string]descriptorpb.FieldDescriptorProto_Type{
+	"string": descriptorpb.FieldDescriptorProto_TYPE_STRING,
+	"bytes":  descriptorpb.FieldDescriptorProto_TYPE_BYTES,
+	"bool":   descriptorpb.FieldDescriptorProto_TYPE_BOOL,
+	"int64":  descriptorpb.FieldDescriptorProto_TYPE_INT64,
+	"int32":  descriptorpb.FieldDescriptorProto_TYPE_INT32,
+}
+
+// loadPayloadsProto parses the .proto file at path into a descriptor. It reads
+// the subset payloads.proto is written in: proto3 top-level messages with
+// scalar, message and repeated fields.
+func loadPayloadsProto(path string) (protoreflect.FileDescriptor, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &descriptorpb.FileDescriptorProto{Name: proto.String(path), Syntax: proto.String("proto3")}
+	spacer := strings.NewReplacer("{", " { ", "}", " } ", ";", " ; ", "=", " = ")
+	var msg *descriptorpb.DescriptorProto
+	for i, line := range strings.Split(string(src), "\n") {
+		if j := strings.Index(line, "//"); j >= 0 {
+			line = line[:j]
+		}
+		words := strings.Fields(spacer.Replace(line))
+		syntaxErr := fmt.Errorf("%s:%d: cannot parse %q", path, i+1, strings.TrimSpace(line))
+
+		switch {
+		case len(words) == 0, words[0] == "syntax":
+		case words[0] == "package" && len(words) >= 2:
+			file.Package = proto.String(words[1])
+		case words[0] == "message" && len(words) >= 3 && words[2] == "{":
+			msg = &descriptorpb.DescriptorProto{Name: proto.String(words[1])}
+			file.MessageType = append(file.MessageType, msg)
+			if words[len(words)-1] == "}" {
+				msg = nil
+			}
+		case words[0] == "}":
+			msg = nil
+		case msg != nil:
+			label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+			if words[0] == "repeated" {
+				label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+				words = words[1:]
+			}
+			if len(words) != 5 || words[2] != "=" || words[4] != ";" {
+				return nil, syntaxErr
+			}
+			num, err := strconv.Atoi(words[3])
+			if err != nil {
+				return nil, syntaxErr
+			}
+			field := &descriptorpb.FieldDescriptorProto{
+				Name:     proto.String(words[1]),
+				JsonName: proto.String(words[1]),
+				Number:   proto.Int32(int32(num)),
+				Label:    label.Enum(),
+			}
+			if typ, ok := protoScalars[words[0]]; ok {
+				field.Type = typ.Enum()
+			} else {
+				field.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+				field.TypeName = proto.String("." + file.GetPackage() + "." + words[0])
+			}
+			msg.Field = append(msg.Field, field)
+		default:
+			return nil, syntaxErr
+		}
+	}
+	return protodesc.NewFile(file, nil)
+}
@@ -2,120 +2,212 @@ package tasks
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/hibiken/asynq"
 	"github.com/lppduy/go-asynq-loadtest/internal/domain"
-	"github.com/lppduy/go-asynq-loadtest/internal/repository"
 )
 
 // Task type constants
 const (
 	TypePaymentProcess = "payment:process"
+	TypePaymentRefund  = "payment:refund"
 )
 
 // PaymentPayload represents the payload for payment processing
 type PaymentPayload struct {
-	OrderID       string  `json:"order_id"`
-	Amount        float64 `json:"amount"`
-	PaymentMethod string  `json:"payment_method"`
+	OrderID       string       `json:"order_id" pb:"1"`
+	Amount        domain.Money `json:"amount" pb:"2"`
+	PaymentMethod string       `json:"payment_method" pb:"3"`
 }
 
-// NewPaymentProcessTask creates a new payment processing task
-func NewPaymentProcessTask(orderID string, amount float64, paymentMethod string) (*asynq.Task, error) {
-	payload, err := json.Marshal(PaymentPayload{
-		OrderID:       orderID,
-		Amount:        amount,
-		PaymentMethod: paymentMethod,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payment payload: %w", err)
+// Validate checks the fields needed to charge the order
+func (p PaymentPayload) Validate() error {
+	switch {
+	case p.OrderID == "":
+		return errors.New("order_id is required")
+	case p.Amount.Amount <= 0 || p.Amount.Currency == "":
+		return fmt.Errorf("amount must be positive with a currency, got %s", p.Amount)
+	case p.PaymentMethod == "":
+		return errors.New("payment_method is required")
 	}
-
-	// Create task with options
-	return asynq.NewTask(
-		TypePaymentProcess,
-		payload,
-		asynq.MaxRetry(3),                    // Retry up to 3 times
-		asynq.Timeout(30*time.Second),        // Task timeout
-		asynq.Queue("critical"),              // Use critical queue
-		asynq.ProcessIn(2*time.Second),       // Process after 2 seconds (simulate delay)
-	), nil
+	return nil
 }
 
-// NewPaymentProcessHandler returns a handler that also updates order status in PostgreSQL.
-func NewPaymentProcessHandler(orderRepo repository.OrderRepository) func(context.Context, *asynq.Task) error {
-	return func(ctx context.Context, t *asynq.Task) error {
-		var payload PaymentPayload
-		if err := json.Unmarshal(t.Payload(), &payload); err != nil {
-			return fmt.Errorf("failed to unmarshal payment payload: %w", err)
-		}
-
-		// Mark payment as processing immediately so orders don't remain "pending"
-		_ = updateOrder(ctx, orderRepo, payload.OrderID, func(o *domain.Order) {
+// PaymentProcess charges an order and confirms it
+var PaymentProcess = Define(TaskDef[PaymentPayload]{
+	Type: TypePaymentProcess,
+	Options: []asynq.Option{
+		asynq.MaxRetry(3),                // Retry up to 3 times
+		asynq.Timeout(30 * time.Second),  // Task timeout
+		asynq.Queue("critical"),          // Use critical queue
+		asynq.ProcessIn(2 * time.Second), // Process after 2 seconds (simulate delay)
+	},
+	// Version 1 had float amounts without a currency
+	Upcasters: []Upcaster{upcastLegacyMoney("amount")},
+	// Deterministic ID so order edits can find and replace it
+	TaskID:       func(p PaymentPayload) string { return OrderTaskID(TypePaymentProcess, p.OrderID) },
+	Handler:      newPaymentProcessHandler,
+	RequireOrder: true,
+})
+
+// PaymentRefund pays back a cancelled order that was already charged. Amount
+// is the charged total.
+var PaymentRefund = Define(TaskDef[PaymentPayload]{
+	Type: TypePaymentRefund,
+	Options: []asynq.Option{
+		asynq.MaxRetry(5), // Money is owed, keep trying
+		asynq.Timeout(30 * time.Second),
+		asynq.Queue("critical"),
+	},
+	// One refund per order, even when the cancel request is retried
+	TaskID:       func(p PaymentPayload) string { return OrderTaskID(TypePaymentRefund, p.OrderID) },
+	Handler:      newPaymentRefundHandler,
+	RequireOrder: true,
+})
+
+// newPaymentProcessHandler returns a handler that also updates order status in PostgreSQL.
+// Stock reserved for the order is released once payment has definitely failed.
+func newPaymentProcessHandler(d Deps) func(context.Context, PaymentPayload) error {
+	inventoryRepo := d.InventoryRepo
+	return func(ctx context.Context, payload PaymentPayload) error {
+		// Mark payment as processing immediately so orders don't remain "pending".
+		// Orders cancelled or rejected in the meantime are not charged, and
+		// orders paid by an earlier run are not charged twice.
+		var cancelled, paid bool
+		err := updateOrder(ctx, d, payload.OrderID, func(o *domain.Order) {
+			if o.Status == domain.OrderStatusCancelled {
+				cancelled = true
+				return
+			}
+			if o.PaymentStatus == domain.PaymentStatusCompleted {
+				paid = true
+				return
+			}
 			o.UpdateStatus(domain.OrderStatusPaymentProcessing)
 			o.UpdatePaymentStatus(domain.PaymentStatusProcessing)
 		})
+		switch {
+		case err != nil:
+			// Failed by reconciliation in the meantime, or not readable: don't charge
+			return err
+		case cancelled:
+			log.Printf("⏭️  [Payment] Order %s is cancelled, skipping payment", payload.OrderID)
+			return nil
+		case paid:
+			log.Printf("⏭️  [Payment] Order %s is already paid, skipping payment", payload.OrderID)
+			return nil
+		}
 
 		log.Printf("💳 [Payment] Processing payment for order: %s", payload.OrderID)
-		log.Printf("💳 [Payment] Amount: $%.2f | Method: %s", payload.Amount, payload.PaymentMethod)
+		log.Printf("💳 [Payment] Amount: %s | Method: %s", payload.Amount, payload.PaymentMethod)
 
 		// Simulate payment processing (2 seconds)
 		time.Sleep(2 * time.Second)
 
 		// Simulate payment gateway API call
-		success := simulatePaymentGateway(payload)
+		success := chargeGateway(payload)
 		if !success {
-			_ = updateOrder(ctx, orderRepo, payload.OrderID, func(o *domain.Order) {
-				o.UpdatePaymentStatus(domain.PaymentStatusFailed)
-			})
+			// Keep the order and its stock while retries remain; a later attempt may succeed
+			retried, _ := asynq.GetRetryCount(ctx)
+			maxRetry, _ := asynq.GetMaxRetry(ctx)
+			if retried >= maxRetry {
+				_ = updateOrder(ctx, d, payload.OrderID, func(o *domain.Order) {
+					o.UpdatePaymentStatus(domain.PaymentStatusFailed)
+				})
+				if err := inventoryRepo.Release(ctx, payload.OrderID); err != nil {
+					log.Printf("⚠️  [Payment] Failed to release stock for order %s: %v", payload.OrderID, err)
+				}
+			}
 			return fmt.Errorf("payment failed for order %s", payload.OrderID)
 		}
 
-		// Persist success into PostgreSQL
-		if err := updateOrder(ctx, orderRepo, payload.OrderID, func(o *domain.Order) {
+		// Persist success into PostgreSQL. An order cancelled while it was being
+		// charged stays cancelled, records the charge and gets it refunded.
+		cancelled = false
+		if err := updateOrder(ctx, d, payload.OrderID, func(o *domain.Order) {
+			if o.Status == domain.OrderStatusCancelled {
+				cancelled = true
+				o.PaymentStatus = domain.PaymentStatusCompleted
+				o.UpdatedAt = time.Now()
+				return
+			}
 			o.UpdatePaymentStatus(domain.PaymentStatusCompleted)
 			o.UpdateStatus(domain.OrderStatusConfirmed)
 		}); err != nil {
 			return err
 		}
+		if cancelled {
+			log.Printf("↩️  [Payment] Order %s was cancelled while being charged, refunding", payload.OrderID)
+			if _, err := PaymentRefund.Enqueue(ctx, d.Client, payload); err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+				return fmt.Errorf("failed to enqueue refund of order %s: %w", payload.OrderID, err)
+			}
+			return nil
+		}
 
 		log.Printf("✅ [Payment] Payment processed successfully for order: %s", payload.OrderID)
 		return nil
 	}
 }
 
-// HandlePaymentProcessTask processes payment for an order
-func HandlePaymentProcessTask(ctx context.Context, t *asynq.Task) error {
-	var payload PaymentPayload
-	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
-		return fmt.Errorf("failed to unmarshal payment payload: %w", err)
-	}
-
-	log.Printf("💳 [Payment] Processing payment for order: %s", payload.OrderID)
-	log.Printf("💳 [Payment] Amount: $%.2f | Method: %s", payload.Amount, payload.PaymentMethod)
-
-	// Simulate payment processing (2 seconds)
-	time.Sleep(2 * time.Second)
-
-	// Simulate payment gateway API call
-	// In production: Call Stripe, PayPal, etc.
-	success := simulatePaymentGateway(payload)
+// newPaymentRefundHandler returns a handler that refunds a cancelled, charged
+// order, records it and then announces it with the refund email. A run that
+// finds the order already refunded only enqueues the email again, so a failed
+// enqueue is retried without refunding twice.
+func newPaymentRefundHandler(d Deps) func(context.Context, PaymentPayload) error {
+	return func(ctx context.Context, payload PaymentPayload) error {
+		order, err := d.OrderRepo.FindByID(ctx, payload.OrderID)
+		if err != nil {
+			return err
+		}
+		switch {
+		case order.Status != domain.OrderStatusCancelled:
+			return fmt.Errorf("order %s is %s, only cancelled orders are refunded: %w",
+				payload.OrderID, order.Status, asynq.SkipRetry)
+		case order.PaymentStatus == domain.PaymentStatusRefunded:
+			log.Printf("⏭️  [Refund] Order %s is already refunded", payload.OrderID)
+		case order.PaymentStatus != domain.PaymentStatusCompleted:
+			log.Printf("⏭️  [Refund] Order %s was never charged (payment %s), nothing to refund",
+				payload.OrderID, order.PaymentStatus)
+			return nil
+		default:
+			log.Printf("↩️  [Refund] Refunding %s for order: %s", payload.Amount, payload.OrderID)
+			if !simulateRefundGateway(payload) {
+				return fmt.Errorf("refund failed for order %s", payload.OrderID)
+			}
+			if err := updateOrder(ctx, d, payload.OrderID, func(o *domain.Order) {
+				o.UpdatePaymentStatus(domain.PaymentStatusRefunded)
+			}); err != nil {
+				return err
+			}
+			log.Printf("✅ [Refund] Order %s refunded", payload.OrderID)
+		}
 
-	if !success {
-		return fmt.Errorf("payment failed for order %s", payload.OrderID)
+		if _, err := EmailRefund.Enqueue(ctx, d.Client, EmailPayload{
+			OrderID:       order.ID,
+			CustomerEmail: order.CustomerEmail,
+			CustomerName:  order.CustomerName,
+			TotalAmount:   payload.Amount,
+		}); err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+			return fmt.Errorf("failed to enqueue refund email for order %s: %w", payload.OrderID, err)
+		}
+		return nil
 	}
+}
 
-	log.Printf("✅ [Payment] Payment processed successfully for order: %s", payload.OrderID)
-	// In production: Update order payment status in database
-	// orderRepo.UpdatePaymentStatus(ctx, payload.OrderID, "paid")
-
-	return nil
+// simulateRefundGateway simulates the refund call of the payment gateway
+func simulateRefundGateway(payload PaymentPayload) bool {
+	// In production: Make actual API call to payment gateway
+	time.Sleep(500 * time.Millisecond)
+	return true
 }
 
+// chargeGateway charges the payment; tests replace it
+var chargeGateway = simulatePaymentGateway
+
 // simulatePaymentGateway simulates external payment gateway
 func simulatePaymentGateway(payload PaymentPayload) bool {
 	// Simulate 95% success rate
@@ -0,0 +1,63 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+)
+
+// An order failed by reconciliation while its payment task waited must not be charged
+func TestPaymentProcessSkipsFailedOrder(t *testing.T) {
+	db := newTestDB(t)
+	d := Deps{
+		OrderRepo:     repository.NewGormOrderRepository(db),
+		InventoryRepo: repository.NewGormInventoryRepository(db),
+		Transactor:    repository.NewGormTransactor(db),
+	}
+	ctx := context.Background()
+
+	order := &domain.Order{
+		ID:            "ORD-5e6f7a8b",
+		CustomerID:    "cust-42",
+		CustomerEmail: "cust-42@example.com",
+		TotalAmount:   domain.NewMoney(1000, "USD"),
+		Status:        domain.OrderStatusPaymentFailed,
+		PaymentStatus: domain.PaymentStatusFailed,
+		PaymentMethod: "credit_card",
+		CreatedAt:     time.Now().Add(-time.Hour),
+		UpdatedAt:     time.Now().Add(-time.Hour),
+	}
+	if err := d.OrderRepo.Create(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+
+	charged := false
+	gateway := chargeGateway
+	chargeGateway = func(PaymentPayload) bool { charged = true; return true }
+	t.Cleanup(func() { chargeGateway = gateway })
+
+	err := newPaymentProcessHandler(d)(ctx, PaymentPayload{
+		OrderID:       order.ID,
+		Amount:        order.TotalAmount,
+		PaymentMethod: order.PaymentMethod,
+	})
+	if !errors.Is(err, domain.ErrInvalidStatusTransition) || !errors.Is(err, asynq.SkipRetry) {
+		t.Fatalf("handler = %v, want ErrInvalidStatusTransition and SkipRetry", err)
+	}
+	if charged {
+		t.Fatal("payment_failed order was charged")
+	}
+
+	stored, err := d.OrderRepo.FindByID(ctx, order.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != domain.OrderStatusPaymentFailed || stored.PaymentStatus != domain.PaymentStatusFailed {
+		t.Errorf("order is %s with payment %s, want it left payment_failed", stored.Status, stored.PaymentStatus)
+	}
+}
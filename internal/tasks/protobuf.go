@@ -0,0 +1,247 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+)
+
+// protobufCodec encodes payload structs in the protobuf wire format without
+// generated code. Field numbers come from the `pb:"N"` tags every exported
+// field must carry; reordering fields never changes the wire format. Domain
+// types nested in payloads carry no tags, they are encoded as a mirror struct
+// of this package (domain.Money as moneyPayload). payloads.proto describes the
+// same messages, and the golden test decodes bytes written from it.
+//
+// Supported field types: string, bool, signed integers, []byte, structs and
+// slices of structs. Zero values are not written, as in proto3.
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string { return "protobuf" }
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("protobuf: cannot encode %T", v)
+	}
+	return appendMessage(nil, rv)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("protobuf: cannot decode into %T", v)
+	}
+	return consumeMessage(data, rv.Elem())
+}
+
+// moneyPayload is domain.Money on the wire. The conversions between the two
+// only compile while they have the same fields, so a field added to
+// domain.Money fails the build here until it is given a number.
+type moneyPayload struct {
+	Amount   int64  `pb:"1"` // Minor units
+	Currency string `pb:"2"` // ISO 4217 code
+}
+
+var moneyType = reflect.TypeOf(domain.Money{})
+
+// pbField maps a protobuf field number to a struct field
+type pbField struct {
+	num   protowire.Number
+	index int
+}
+
+// pbFieldCache holds the []pbField of every struct type seen
+var pbFieldCache sync.Map
+
+func pbFields(t reflect.Type) ([]pbField, error) {
+	if cached, ok := pbFieldCache.Load(t); ok {
+		return cached.([]pbField), nil
+	}
+
+	var fields []pbField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		tag, ok := sf.Tag.Lookup("pb")
+		if !ok {
+			return nil, fmt.Errorf("protobuf: %s.%s has no pb tag", t.Name(), sf.Name)
+		}
+		n, err := strconv.Atoi(tag)
+		if err != nil || !protowire.Number(n).IsValid() {
+			return nil, fmt.Errorf("protobuf: invalid tag %q on %s.%s", tag, t.Name(), sf.Name)
+		}
+		for _, f := range fields {
+			if f.num == protowire.Number(n) {
+				return nil, fmt.Errorf("protobuf: %s.%s reuses field number %d", t.Name(), sf.Name, n)
+			}
+		}
+		fields = append(fields, pbField{num: protowire.Number(n), index: i})
+	}
+
+	pbFieldCache.Store(t, fields)
+	return fields, nil
+}
+
+func appendMessage(b []byte, v reflect.Value) ([]byte, error) {
+	fields, err := pbFields(v.Type())
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		field := v.Field(f.index)
+		if field.IsZero() {
+			continue
+		}
+		if b, err = appendField(b, f.num, field); err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", v.Type().Name(), v.Type().Field(f.index).Name, err)
+		}
+	}
+	return b, nil
+}
+
+func appendField(b []byte, num protowire.Number, v reflect.Value) ([]byte, error) {
+	switch v.Kind() {
+	case reflect.String:
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendString(b, v.String()), nil
+
+	case reflect.Bool:
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		return protowire.AppendVarint(b, protowire.EncodeBool(v.Bool())), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		return protowire.AppendVarint(b, uint64(v.Int())), nil
+
+	case reflect.Struct:
+		if v.Type() == moneyType {
+			v = reflect.ValueOf(moneyPayload(v.Interface().(domain.Money)))
+		}
+		msg, err := appendMessage(nil, v)
+		if err != nil {
+			return nil, err
+		}
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendBytes(b, msg), nil
+
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b = protowire.AppendTag(b, num, protowire.BytesType)
+			return protowire.AppendBytes(b, v.Bytes()), nil
+		}
+		if v.Type().Elem().Kind() != reflect.Struct {
+			return nil, fmt.Errorf("unsupported slice of %s", v.Type().Elem())
+		}
+		for i := 0; i < v.Len(); i++ {
+			var err error
+			if b, err = appendField(b, num, v.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", v.Type())
+}
+
+func consumeMessage(b []byte, v reflect.Value) error {
+	fields, err := pbFields(v.Type())
+	if err != nil {
+		return err
+	}
+
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		index := -1
+		for _, f := range fields {
+			if f.num == num {
+				index = f.index
+			}
+		}
+		if index < 0 {
+			return fmt.Errorf("protobuf: unknown field %d in %s", num, v.Type().Name())
+		}
+
+		if n, err = consumeField(b, typ, v.Field(index)); err != nil {
+			return fmt.Errorf("protobuf: %s.%s: %w", v.Type().Name(), v.Type().Field(index).Name, err)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+var errWireType = errors.New("wrong wire type")
+
+func consumeField(b []byte, typ protowire.Type, v reflect.Value) (int, error) {
+	switch v.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if typ != protowire.VarintType {
+			return 0, errWireType
+		}
+		x, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return 0, protowire.ParseError(n)
+		}
+		if v.Kind() == reflect.Bool {
+			v.SetBool(protowire.DecodeBool(x))
+		} else {
+			v.SetInt(int64(x))
+		}
+		return n, nil
+	}
+
+	if typ != protowire.BytesType {
+		return 0, errWireType
+	}
+	data, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(string(data))
+	case reflect.Struct:
+		return n, consumeStruct(data, v)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes(append([]byte(nil), data...))
+			break
+		}
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if err := consumeStruct(data, elem); err != nil {
+			return 0, err
+		}
+		v.Set(reflect.Append(v, elem))
+	default:
+		return 0, fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return n, nil
+}
+
+// consumeStruct decodes a nested message into v, through the mirror struct of
+// a domain type
+func consumeStruct(data []byte, v reflect.Value) error {
+	if v.Type() != moneyType {
+		return consumeMessage(data, v)
+	}
+	var m moneyPayload
+	if err := consumeMessage(data, reflect.ValueOf(&m).Elem()); err != nil {
+		return err
+	}
+	v.Set(reflect.ValueOf(domain.Money(m)))
+	return nil
+}
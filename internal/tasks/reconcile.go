@@ -0,0 +1,156 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+	"github.com/lppduy/go-asynq-loadtest/pkg/metrics"
+)
+
+const (
+	TypeOrdersReconcile = "orders:reconcile"
+)
+
+// Reconcile actions, as counted in orders_reconciled_total
+const (
+	ReconcileWaiting  = "waiting"  // payment:process is still queued or running
+	ReconcileRequeued = "requeued" // payment:process was lost and is enqueued again
+	ReconcileFailed   = "failed"   // the order is too old to retry and failed
+	ReconcileError    = "error"
+)
+
+// ReconcilePayload is empty: every run checks all stuck orders
+type ReconcilePayload struct{}
+
+// ReconcileSettings are the thresholds of orders:reconcile
+type ReconcileSettings struct {
+	PendingAfter           time.Duration // Age of the last update before a pending order is checked
+	PaymentProcessingAfter time.Duration // Same for payment_processing orders
+	FailAfter              time.Duration // Order age after which lost payments are failed, not re-enqueued
+	BatchSize              int           // Orders checked per status and run
+}
+
+// OrdersReconcile finds orders stuck before payment and repairs them. It is
+// enqueued by cmd/scheduler on a cron.
+var OrdersReconcile = Define(TaskDef[ReconcilePayload]{
+	Type: TypeOrdersReconcile,
+	Options: []asynq.Option{
+		asynq.MaxRetry(0), // The next scheduled run tries again
+		asynq.Timeout(2 * time.Minute),
+		asynq.Queue("default"),
+		asynq.Unique(30 * time.Minute), // Runs missed while workers are down do not pile up
+	},
+	Handler: newOrdersReconcileHandler,
+})
+
+// newOrdersReconcileHandler returns a handler that checks pending and payment_processing
+// orders not updated for longer than their threshold. An order whose payment:process
+// task is still live is left alone; otherwise the task is enqueued again under its
+// deterministic ID, or the payment failed and stock released once the order is
+// older than FailAfter.
+func newOrdersReconcileHandler(d Deps) func(context.Context, ReconcilePayload) error {
+	settings := d.Reconcile
+	return func(ctx context.Context, _ ReconcilePayload) error {
+		stages := []struct {
+			status domain.OrderStatus
+			after  time.Duration
+		}{
+			{domain.OrderStatusPending, settings.PendingAfter},
+			{domain.OrderStatusPaymentProcessing, settings.PaymentProcessingAfter},
+		}
+
+		counts := map[string]int{}
+		for _, stage := range stages {
+			orders, err := d.OrderRepo.FindStale(ctx, stage.status, time.Now().Add(-stage.after), settings.BatchSize)
+			if err != nil {
+				return fmt.Errorf("failed to find stale %s orders: %w", stage.status, err)
+			}
+			for _, order := range orders {
+				action := reconcileOrder(ctx, d, order)
+				metrics.OrdersReconciled.WithLabelValues(string(stage.status), action).Inc()
+				counts[action]++
+			}
+		}
+
+		log.Printf("🩺 [Reconcile] %d waiting, %d requeued, %d failed, %d errors",
+			counts[ReconcileWaiting], counts[ReconcileRequeued], counts[ReconcileFailed], counts[ReconcileError])
+		return nil
+	}
+}
+
+// reconcileOrder repairs one stuck order and returns the action taken
+func reconcileOrder(ctx context.Context, d Deps, order *domain.Order) string {
+	queue, taskID := PaymentProcess.Queue(), OrderTaskID(TypePaymentProcess, order.ID)
+
+	info, err := d.Inspector.GetTaskInfo(queue, taskID)
+	switch {
+	case errors.Is(err, asynq.ErrTaskNotFound), errors.Is(err, asynq.ErrQueueNotFound):
+	case err != nil:
+		log.Printf("⚠️  [Reconcile] Failed to look up payment task of order %s: %v", order.ID, err)
+		return ReconcileError
+	case info.State == asynq.TaskStateArchived || info.State == asynq.TaskStateCompleted:
+		// Its ID stays taken until the record is gone
+		if err := d.Inspector.DeleteTask(queue, taskID); err != nil {
+			log.Printf("⚠️  [Reconcile] Failed to delete %s payment task of order %s: %v", info.State, order.ID, err)
+			return ReconcileError
+		}
+	default:
+		return ReconcileWaiting
+	}
+
+	if time.Since(order.CreatedAt) >= d.Reconcile.FailAfter {
+		return failStuckOrder(ctx, d, order)
+	}
+
+	_, err = PaymentProcess.Enqueue(ctx, d.Client, PaymentPayload{
+		OrderID:       order.ID,
+		Amount:        order.TotalAmount,
+		PaymentMethod: order.PaymentMethod,
+	})
+	switch {
+	case errors.Is(err, asynq.ErrTaskIDConflict):
+		// Enqueued since we looked, by the API or an earlier run
+		return ReconcileWaiting
+	case err != nil:
+		log.Printf("⚠️  [Reconcile] Failed to re-enqueue payment of order %s: %v", order.ID, err)
+		return ReconcileError
+	}
+
+	log.Printf("🔁 [Reconcile] Order %s was %s for %s with no live payment task, re-enqueued payment",
+		order.ID, order.Status, time.Since(order.UpdatedAt).Round(time.Second))
+	return ReconcileRequeued
+}
+
+// failStuckOrder fails the payment of an order that is still in the status it was
+// found in, and releases its stock. An order that has moved on is left untouched,
+// so its updated_at keeps counting from its own last change.
+func failStuckOrder(ctx context.Context, d Deps, order *domain.Order) string {
+	var moved bool
+	if err := repository.UpdateOrder(ctx, d.Transactor, d.OrderRepo, order.ID, func(o *domain.Order) bool {
+		if o.Status != order.Status {
+			moved = true
+			return false
+		}
+		o.UpdatePaymentStatus(domain.PaymentStatusFailed)
+		return true
+	}); err != nil {
+		log.Printf("⚠️  [Reconcile] Failed to fail order %s: %v", order.ID, err)
+		return ReconcileError
+	}
+	if moved {
+		return ReconcileWaiting
+	}
+
+	if err := d.InventoryRepo.Release(ctx, order.ID); err != nil {
+		log.Printf("⚠️  [Reconcile] Failed to release stock for order %s: %v", order.ID, err)
+	}
+	log.Printf("🚫 [Reconcile] Order %s was %s since %s with no live payment task, failed payment",
+		order.ID, order.Status, order.UpdatedAt.Format(time.RFC3339))
+	return ReconcileFailed
+}
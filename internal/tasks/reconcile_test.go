@@ -0,0 +1,70 @@
+package tasks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+)
+
+// An order that moved on after reconcile found it stuck is not written, so its
+// staleness clock and its streams are left alone
+func TestFailStuckOrderLeavesMovedOrder(t *testing.T) {
+	db := newTestDB(t)
+	transactor := repository.NewGormTransactor(db)
+	repos := map[string]repository.OrderRepository{
+		"gorm":   repository.NewGormOrderRepository(db),
+		"memory": repository.NewMemoryOrderRepository(),
+	}
+	for name, repo := range repos {
+		t.Run(name, func(t *testing.T) {
+			d := Deps{OrderRepo: repo, InventoryRepo: repository.NewGormInventoryRepository(db), Transactor: transactor}
+			ctx := context.Background()
+
+			order := &domain.Order{
+				ID:            "ORD-0f1e2d3c",
+				CustomerID:    "cust-42",
+				CustomerEmail: "cust-42@example.com",
+				TotalAmount:   domain.NewMoney(1000, "USD"),
+				Status:        domain.OrderStatusPending,
+				PaymentStatus: domain.PaymentStatusPending,
+				PaymentMethod: "credit_card",
+				CreatedAt:     time.Now().Add(-2 * time.Hour),
+				UpdatedAt:     time.Now().Add(-time.Hour),
+			}
+			if err := repo.Create(ctx, order); err != nil {
+				t.Fatal(err)
+			}
+			found, err := repo.FindByID(ctx, order.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Paid since reconcile listed it as pending
+			confirmed := *found
+			confirmed.Status = domain.OrderStatusConfirmed
+			confirmed.PaymentStatus = domain.PaymentStatusCompleted
+			if err := repo.Update(ctx, &confirmed); err != nil {
+				t.Fatal(err)
+			}
+			before, err := repo.FindByID(ctx, order.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if action := failStuckOrder(ctx, d, found); action != ReconcileWaiting {
+				t.Fatalf("failStuckOrder = %s, want %s", action, ReconcileWaiting)
+			}
+			after, err := repo.FindByID(ctx, order.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if after.Status != domain.OrderStatusConfirmed || !after.UpdatedAt.Equal(before.UpdatedAt) {
+				t.Errorf("order is %s updated at %s, want %s left at %s",
+					after.Status, after.UpdatedAt, domain.OrderStatusConfirmed, before.UpdatedAt)
+			}
+		})
+	}
+}
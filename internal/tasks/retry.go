@@ -0,0 +1,17 @@
+package tasks
+
+import (
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+// RetryDelay chooses the retry backoff per task type (asynq.Config.RetryDelayFunc)
+func RetryDelay(n int, err error, t *asynq.Task) time.Duration {
+	switch t.Type() {
+	case TypeWebhookDeliver:
+		return webhookRetryDelay(n)
+	default:
+		return asynq.DefaultRetryDelayFunc(n, err, t)
+	}
+}
@@ -0,0 +1,255 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/lppduy/go-asynq-loadtest/internal/carrier"
+	"github.com/lppduy/go-asynq-loadtest/internal/email"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+	"github.com/lppduy/go-asynq-loadtest/internal/storage"
+)
+
+// TaskDef declares one task type: its name, JSON payload type P, default enqueue
+// options and the handler the worker runs. Declaring it with Define is all it
+// takes; the API enqueues through the definition and the worker picks it up
+// with RegisterAll.
+//
+// Payloads are enqueued in a version envelope. When P changes shape, append an
+// Upcaster that migrates the previous version: Upcasters[0] turns version 1
+// into version 2, and so on, so the current version is len(Upcasters)+1.
+type TaskDef[P any] struct {
+	Type      string
+	Options   []asynq.Option // Defaults; options passed to NewTask/Enqueue come after them
+	TaskID    func(P) string // Deterministic task ID (optional)
+	Upcasters []Upcaster
+
+	// Handler builds the worker's handler from its dependencies. The payload
+	// is already decoded and, if P implements Validator, validated.
+	Handler func(Deps) func(ctx context.Context, payload P) error
+	// RequireOrder archives the task when the order in its order_id is gone
+	RequireOrder bool
+}
+
+// Deps are the dependencies task handlers are built from
+type Deps struct {
+	OrderRepo     repository.OrderRepository
+	InventoryRepo repository.InventoryRepository
+	WebhookRepo   repository.WebhookRepository
+	AnalyticsRepo repository.AnalyticsRepository
+	ShipmentRepo  repository.ShipmentRepository
+	Transactor    repository.Transactor // Order status updates lock the order row
+	HTTPClient    *http.Client          // Webhook deliveries
+	Mailer        email.Mailer          // Order emails
+	Blobs         storage.BlobStore     // Invoice PDFs
+	Carrier       carrier.Carrier       // Shipping labels
+	ParcelUnits   int                   // Units packed per parcel at most
+
+	// Reconciliation looks up and re-enqueues payment tasks; the warehouse
+	// enqueues the shipping email
+	Client    *asynq.Client
+	Inspector *asynq.Inspector
+	Reconcile ReconcileSettings
+}
+
+// Registration describes a declared task type, as listed by RegisterAll
+type Registration struct {
+	Type    string
+	Queue   string
+	Version int // Current payload version
+}
+
+// registrar is what the registry needs from a TaskDef of any payload type
+type registrar interface {
+	register(r *Router, deps Deps)
+	registration() Registration
+	orderID(t *asynq.Task) (string, error)
+}
+
+// registry holds every TaskDef declared with Define, in declaration order
+var registry []registrar
+
+// Define declares a task type and registers it for RegisterAll
+func Define[P any](def TaskDef[P]) *TaskDef[P] {
+	d := &def
+	registry = append(registry, d)
+	return d
+}
+
+// RegisterAll adds the handlers of all declared task types to r
+func RegisterAll(r *Router, deps Deps) []Registration {
+	registered := make([]Registration, 0, len(registry))
+	for _, def := range registry {
+		def.register(r, deps)
+		registered = append(registered, def.registration())
+	}
+	return registered
+}
+
+// Definitions lists the declared task types
+func Definitions() []Registration {
+	defs := make([]Registration, 0, len(registry))
+	for _, def := range registry {
+		defs = append(defs, def.registration())
+	}
+	return defs
+}
+
+// lookup returns the declared task type called taskType
+func lookup(taskType string) (registrar, bool) {
+	for _, def := range registry {
+		if def.registration().Type == taskType {
+			return def, true
+		}
+	}
+	return nil, false
+}
+
+// NewTask encodes payload at the current version with the codec set by
+// SetCodec into a task with the default options, the deterministic ID (if
+// any) and then opts
+func (d *TaskDef[P]) NewTask(payload P, opts ...asynq.Option) (*asynq.Task, error) {
+	data, err := d.Encode(payloadCodec, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	options := append([]asynq.Option{}, d.Options...)
+	if d.TaskID != nil {
+		options = append(options, asynq.TaskID(d.TaskID(payload)))
+	}
+	return asynq.NewTask(d.Type, data, append(options, opts...)...), nil
+}
+
+// Enqueue creates the task for payload and enqueues it
+func (d *TaskDef[P]) Enqueue(ctx context.Context, client *asynq.Client, payload P, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+	task, err := d.NewTask(payload, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return client.EnqueueContext(ctx, task)
+}
+
+// Encode encodes payload at the current version with c
+func (d *TaskDef[P]) Encode(c Codec, payload P) ([]byte, error) {
+	data, err := encodePayload(c, d.Version(), payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s payload as %s: %w", d.Type, c.Name(), err)
+	}
+	return data, nil
+}
+
+// Decode reads the payload of t in any codec, upcasting older versions to the
+// current one. Payloads that do not decode or validate, carry fields P does
+// not have or a version this build does not know fail permanently: retrying
+// the same bytes cannot fix them.
+func (d *TaskDef[P]) Decode(t *asynq.Task) (P, error) {
+	var payload P
+	codec, version, data, err := openPayload(t.Payload())
+	if err != nil {
+		return payload, fmt.Errorf("malformed %s payload: %v: %w", d.Type, err, asynq.SkipRetry)
+	}
+	if version < 1 || version > d.Version() {
+		log.Printf("🚫 [%s] Payload version %d is not supported (this build reads 1-%d), archiving task",
+			d.Type, version, d.Version())
+		return payload, fmt.Errorf("%s payload version %d: %w: %w", d.Type, version, ErrUnsupportedPayloadVersion, asynq.SkipRetry)
+	}
+
+	// Upcasters work on JSON; binary payloads are only read at the current version
+	if codec != JSON && version < d.Version() {
+		log.Printf("🚫 [%s] %s payload version %d cannot be upcast to %d, archiving task",
+			d.Type, codec.Name(), version, d.Version())
+		return payload, fmt.Errorf("%s %s payload version %d: %w: %w", d.Type, codec.Name(), version, ErrUnsupportedPayloadVersion, asynq.SkipRetry)
+	}
+	for v := version; v < d.Version(); v++ {
+		if data, err = d.Upcasters[v-1](data); err != nil {
+			return payload, fmt.Errorf("failed to upcast %s payload from version %d: %v: %w", d.Type, v, err, asynq.SkipRetry)
+		}
+	}
+	if err := codec.Unmarshal(data, &payload); err != nil {
+		return payload, fmt.Errorf("malformed %s %s payload (version %d): %v: %w", d.Type, codec.Name(), version, err, asynq.SkipRetry)
+	}
+	if v, ok := any(payload).(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return payload, fmt.Errorf("invalid %s payload: %v: %w", d.Type, err, asynq.SkipRetry)
+		}
+	}
+	return payload, nil
+}
+
+// Handle adapts a typed handler to asynq.Handler
+func (d *TaskDef[P]) Handle(fn func(ctx context.Context, payload P) error) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		payload, err := d.Decode(t)
+		if err != nil {
+			return err
+		}
+		return fn(ctx, payload)
+	})
+}
+
+// Version is the payload version NewTask writes
+func (d *TaskDef[P]) Version() int {
+	return len(d.Upcasters) + 1
+}
+
+// Queue is the queue the task is enqueued on by default
+func (d *TaskDef[P]) Queue() string {
+	queue := "default"
+	for _, opt := range d.Options {
+		if opt.Type() == asynq.QueueOpt {
+			queue = opt.Value().(string)
+		}
+	}
+	return queue
+}
+
+// timeout is the default asynq.Timeout of the task, 0 if none
+func (d *TaskDef[P]) timeout() time.Duration {
+	var timeout time.Duration
+	for _, opt := range d.Options {
+		if opt.Type() == asynq.TimeoutOpt {
+			timeout = opt.Value().(time.Duration)
+		}
+	}
+	return timeout
+}
+
+// register adds the handler behind its per-type middlewares: the default
+// timeout as a Deadline, and RequireOrder when asked for
+func (d *TaskDef[P]) register(r *Router, deps Deps) {
+	var mws []asynq.MiddlewareFunc
+	if timeout := d.timeout(); timeout > 0 {
+		mws = append(mws, Deadline(timeout))
+	}
+	if d.RequireOrder {
+		mws = append(mws, RequireOrder(deps.OrderRepo))
+	}
+	r.Handle(d.Type, d.Handle(d.Handler(deps)), mws...)
+}
+
+// orderID reads the order_id of a payload, whatever its codec and version
+func (d *TaskDef[P]) orderID(t *asynq.Task) (string, error) {
+	payload, err := d.Decode(t)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	var ref struct {
+		OrderID string `json:"order_id"`
+	}
+	err = json.Unmarshal(data, &ref)
+	return ref.OrderID, err
+}
+
+func (d *TaskDef[P]) registration() Registration {
+	return Registration{Type: d.Type, Queue: d.Queue(), Version: d.Version()}
+}
@@ -2,63 +2,63 @@ package tasks
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"math/rand"
+	"sort"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
+	"github.com/lppduy/go-asynq-loadtest/internal/carrier"
 	"github.com/lppduy/go-asynq-loadtest/internal/domain"
-	"github.com/lppduy/go-asynq-loadtest/internal/repository"
 )
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
-
 const (
 	TypeWarehouseNotify = "warehouse:notify"
 )
 
 // WarehousePayload represents the payload for warehouse notification
 type WarehousePayload struct {
-	OrderID         string `json:"order_id"`
-	CustomerName    string `json:"customer_name"`
-	ShippingAddress string `json:"shipping_address"`
-	ItemCount       int    `json:"item_count"`
-	Priority        string `json:"priority"` // standard, express, overnight
-}
-
-// NewWarehouseNotifyTask creates a new warehouse notification task
-func NewWarehouseNotifyTask(orderID, customerName, shippingAddress string, itemCount int, priority string) (*asynq.Task, error) {
-	payload, err := json.Marshal(WarehousePayload{
-		OrderID:         orderID,
-		CustomerName:    customerName,
-		ShippingAddress: shippingAddress,
-		ItemCount:       itemCount,
-		Priority:        priority,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal warehouse payload: %w", err)
-	}
-
-	return asynq.NewTask(
-		TypeWarehouseNotify,
-		payload,
-		asynq.MaxRetry(3),
-		asynq.Timeout(15*time.Second),
-		asynq.Queue("low"),            // Low priority
-		asynq.ProcessIn(5*time.Second), // Notify after 5 seconds
-	), nil
+	OrderID         string `json:"order_id" pb:"1"`
+	CustomerName    string `json:"customer_name" pb:"2"`
+	ShippingAddress string `json:"shipping_address" pb:"3"`
+	ItemCount       int    `json:"item_count" pb:"4"`
+	Priority        string `json:"priority" pb:"5"` // standard, express, overnight
 }
 
-// NewWarehouseNotifyHandler returns a handler that also updates tracking info in PostgreSQL.
-func NewWarehouseNotifyHandler(orderRepo repository.OrderRepository) func(context.Context, *asynq.Task) error {
-	return func(ctx context.Context, t *asynq.Task) error {
-		var payload WarehousePayload
-		if err := json.Unmarshal(t.Payload(), &payload); err != nil {
-			return fmt.Errorf("failed to unmarshal warehouse payload: %w", err)
+// WarehouseNotify hands an order to the warehouse and ships it with the carrier
+var WarehouseNotify = Define(TaskDef[WarehousePayload]{
+	Type: TypeWarehouseNotify,
+	Options: []asynq.Option{
+		asynq.MaxRetry(10), // Retried until the payment, which retries too, completes
+		asynq.Timeout(15 * time.Second),
+		asynq.Queue("low"),               // Low priority
+		asynq.ProcessIn(5 * time.Second), // Notify after 5 seconds
+	},
+	// Deterministic ID so order edits can find and replace it
+	TaskID:       func(p WarehousePayload) string { return OrderTaskID(TypeWarehouseNotify, p.OrderID) },
+	Handler:      newWarehouseNotifyHandler,
+	RequireOrder: true,
+})
+
+// newWarehouseNotifyHandler returns a handler that packs the order into parcels,
+// buys a carrier label for each, takes the shipped units out of stock, marks the
+// order shipped and enqueues the shipping email. Orders are only shipped once
+// paid; until then the task is retried.
+func newWarehouseNotifyHandler(d Deps) func(context.Context, WarehousePayload) error {
+	orderRepo, inventoryRepo, client := d.OrderRepo, d.InventoryRepo, d.Client
+	return func(ctx context.Context, payload WarehousePayload) error {
+		order, err := orderRepo.FindByID(ctx, payload.OrderID)
+		if err != nil {
+			return err
+		}
+		if order.Status.IsTerminal() {
+			log.Printf("⏭️  [Warehouse] Order %s is %s, not shipping", payload.OrderID, order.Status)
+			return nil
+		}
+		if order.PaymentStatus != domain.PaymentStatusCompleted {
+			return fmt.Errorf("order %s payment is %s, not shipping yet", payload.OrderID, order.PaymentStatus)
 		}
 
 		log.Printf("📦 [Warehouse] Notifying warehouse about order: %s", payload.OrderID)
@@ -71,41 +71,119 @@ func NewWarehouseNotifyHandler(orderRepo repository.OrderRepository) func(contex
 			return fmt.Errorf("failed to notify warehouse: %w", err)
 		}
 
-		// Persist tracking number and mark order as shipped (demo)
-		tracking := fmt.Sprintf("TRK-%s-%04d", payload.OrderID[len(payload.OrderID)-4:], rand.Intn(10000))
-		if err := updateOrder(ctx, orderRepo, payload.OrderID, func(o *domain.Order) {
+		serviceLevel := payload.Priority
+		if serviceLevel == "" {
+			serviceLevel = order.ShippingPriority
+		}
+		shipments, err := createShipments(ctx, d, order, serviceLevel)
+		if err != nil {
+			return err
+		}
+
+		if err := inventoryRepo.Commit(ctx, payload.OrderID); err != nil {
+			return fmt.Errorf("failed to commit stock for order %s: %w", payload.OrderID, err)
+		}
+
+		// The order keeps the tracking number of its first parcel;
+		// GET /orders/:id/shipments lists all of them
+		tracking := shipments[0].TrackingNumber
+		if err := updateOrder(ctx, d, payload.OrderID, func(o *domain.Order) {
 			o.TrackingNumber = tracking
 			o.UpdateStatus(domain.OrderStatusShipped)
 		}); err != nil {
 			return err
 		}
 
-		log.Printf("✅ [Warehouse] Notification sent for order: %s | Tracking: %s", payload.OrderID, tracking)
+		log.Printf("✅ [Warehouse] Order %s shipped in %d parcel(s) via %s | Tracking: %s",
+			payload.OrderID, len(shipments), d.Carrier.Name(), tracking)
+
+		// The order is shipped either way; a lost email is not worth shipping twice
+		_, err = EmailShipping.Enqueue(ctx, client, EmailPayload{
+			OrderID:       order.ID,
+			CustomerEmail: order.CustomerEmail,
+			CustomerName:  order.CustomerName,
+			TotalAmount:   order.TotalAmount,
+		})
+		if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+			log.Printf("⚠️  [Warehouse] Failed to enqueue shipping email for order %s: %v", payload.OrderID, err)
+		}
 		return nil
 	}
 }
 
-// HandleWarehouseNotifyTask notifies warehouse about new order
-func HandleWarehouseNotifyTask(ctx context.Context, t *asynq.Task) error {
-	var payload WarehousePayload
-	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
-		return fmt.Errorf("failed to unmarshal warehouse payload: %w", err)
+// createShipments buys a label for every parcel of the order that has none yet,
+// so a retried task does not ship anything twice. It returns all shipments of
+// the order by parcel number.
+func createShipments(ctx context.Context, d Deps, order *domain.Order, serviceLevel string) ([]*domain.Shipment, error) {
+	shipments, err := d.ShipmentRepo.ListByOrder(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shipments of order %s: %w", order.ID, err)
+	}
+	shipped := make(map[int]bool, len(shipments))
+	for _, s := range shipments {
+		shipped[s.Parcel] = true
 	}
 
-	log.Printf("📦 [Warehouse] Notifying warehouse about order: %s", payload.OrderID)
-	log.Printf("📦 [Warehouse] Customer: %s | Items: %d | Priority: %s", 
-		payload.CustomerName, payload.ItemCount, payload.Priority)
+	recipient := order.CustomerName
+	if recipient == "" {
+		recipient = order.CustomerEmail
+	}
 
-	// Simulate warehouse notification (500ms)
-	time.Sleep(500 * time.Millisecond)
+	parcels := domain.PackParcels(order.Items, d.ParcelUnits)
+	for i, items := range parcels {
+		parcel := i + 1
+		if shipped[parcel] {
+			continue
+		}
 
-	// Send notification to warehouse system
-	if err := notifyWarehouseSystem(payload); err != nil {
-		return fmt.Errorf("failed to notify warehouse: %w", err)
+		label, err := d.Carrier.CreateShipment(ctx, carrier.ShipmentRequest{
+			Reference:    fmt.Sprintf("%s-%d", order.ID, parcel),
+			ServiceLevel: serviceLevel,
+			Recipient:    recipient,
+			Address:      order.ShippingAddress,
+			Items:        items,
+		})
+		if errors.Is(err, carrier.ErrUnknownServiceLevel) {
+			return nil, fmt.Errorf("%v: %w", err, asynq.SkipRetry)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create shipment of parcel %d: %w", parcel, err)
+		}
+
+		now := time.Now()
+		shipment := &domain.Shipment{
+			ID:                fmt.Sprintf("SHP-%s", uuid.New().String()[:8]),
+			OrderID:           order.ID,
+			Parcel:            parcel,
+			Carrier:           d.Carrier.Name(),
+			ServiceLevel:      serviceLevel,
+			TrackingNumber:    label.TrackingNumber,
+			LabelURL:          label.LabelURL,
+			Status:            domain.ShipmentStatusLabelCreated,
+			EstimatedDelivery: label.EstimatedDelivery,
+			Items:             items,
+			Events: []domain.ShipmentEvent{{
+				Status:      domain.ShipmentStatusLabelCreated,
+				Description: "Shipping label created",
+				OccurredAt:  now,
+			}},
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := d.ShipmentRepo.Create(ctx, shipment); err != nil {
+			return nil, fmt.Errorf("failed to save shipment %s of order %s: %w", label.TrackingNumber, order.ID, err)
+		}
+
+		log.Printf("🏷️  [Warehouse] Parcel %d/%d of order %s: %s %s (%s)",
+			parcel, len(parcels), order.ID, d.Carrier.Name(), label.TrackingNumber, serviceLevel)
+		shipments = append(shipments, shipment)
 	}
 
-	log.Printf("✅ [Warehouse] Notification sent for order: %s", payload.OrderID)
-	return nil
+	if len(shipments) == 0 {
+		return nil, fmt.Errorf("order %s has nothing to ship: %w", order.ID, asynq.SkipRetry)
+	}
+	sort.Slice(shipments, func(i, j int) bool { return shipments[i].Parcel < shipments[j].Parcel })
+	return shipments, nil
 }
 
 // notifyWarehouseSystem sends notification to warehouse management system
@@ -115,6 +193,6 @@ func notifyWarehouseSystem(payload WarehousePayload) error {
 	// - REST API call to warehouse system
 	// - Publish to Kafka/RabbitMQ
 	// - Update warehouse database
-	
+
 	return nil
 }
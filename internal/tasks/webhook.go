@@ -0,0 +1,152 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+	"github.com/lppduy/go-asynq-loadtest/pkg/webhooksig"
+)
+
+const (
+	TypeWebhookDeliver = "webhook:deliver"
+)
+
+// WebhookPayload represents the payload for webhook delivery.
+// The secret is not part of the payload; it is read from PostgreSQL when signing.
+type WebhookPayload struct {
+	SubscriptionID string          `json:"subscription_id" pb:"1"`
+	EventID        string          `json:"event_id" pb:"2"`
+	EventType      string          `json:"event_type" pb:"3"`
+	Body           json.RawMessage `json:"body" pb:"4"`
+}
+
+// Validate checks that the delivery can be signed and logged
+func (p WebhookPayload) Validate() error {
+	switch {
+	case p.SubscriptionID == "" || p.EventID == "":
+		return errors.New("subscription_id and event_id are required")
+	case len(p.Body) == 0:
+		return errors.New("body is required")
+	}
+	return nil
+}
+
+// WebhookDeliver POSTs one signed event to one subscription
+var WebhookDeliver = Define(TaskDef[WebhookPayload]{
+	Type: TypeWebhookDeliver,
+	Options: []asynq.Option{
+		asynq.MaxRetry(8), // ~1 hour of retries with exponential backoff
+		asynq.Timeout(15 * time.Second),
+		asynq.Queue("default"),
+	},
+	Handler: newWebhookDeliverHandler,
+})
+
+// newWebhookDeliverHandler returns a handler that POSTs signed events and logs every attempt.
+func newWebhookDeliverHandler(d Deps) func(context.Context, WebhookPayload) error {
+	webhookRepo, httpClient := d.WebhookRepo, d.HTTPClient
+	return func(ctx context.Context, payload WebhookPayload) error {
+		sub, err := webhookRepo.FindSubscriptionByID(ctx, payload.SubscriptionID)
+		if errors.Is(err, repository.ErrWebhookNotFound) {
+			log.Printf("🔕 [Webhook] Subscription %s deleted, dropping event %s", payload.SubscriptionID, payload.EventID)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !sub.Active {
+			log.Printf("🔕 [Webhook] Subscription %s inactive, dropping event %s", sub.ID, payload.EventID)
+			return nil
+		}
+
+		retried, _ := asynq.GetRetryCount(ctx)
+		delivery := &domain.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        payload.EventID,
+			EventType:      payload.EventType,
+			Attempt:        retried + 1,
+		}
+
+		log.Printf("🔔 [Webhook] Delivering %s (%s) to %s | Attempt: %d",
+			payload.EventType, payload.EventID, sub.URL, delivery.Attempt)
+
+		start := time.Now()
+		statusCode, deliverErr := postWebhook(ctx, httpClient, sub, payload)
+		delivery.DurationMs = time.Since(start).Milliseconds()
+		delivery.StatusCode = statusCode
+		delivery.Success = deliverErr == nil
+		delivery.CreatedAt = time.Now()
+		if deliverErr != nil {
+			delivery.Error = deliverErr.Error()
+		}
+
+		if err := webhookRepo.RecordDelivery(ctx, delivery); err != nil {
+			log.Printf("⚠️  [Webhook] Failed to record delivery: %v", err)
+		}
+
+		if deliverErr != nil {
+			return deliverErr
+		}
+
+		log.Printf("✅ [Webhook] Delivered %s to %s | Status: %d", payload.EventID, sub.URL, statusCode)
+		return nil
+	}
+}
+
+// postWebhook sends one signed request. Client errors other than 408/429
+// are permanent (asynq.SkipRetry), everything else is retried.
+func postWebhook(ctx context.Context, httpClient *http.Client, sub *domain.WebhookSubscription, payload WebhookPayload) (int, error) {
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(payload.Body))
+	if err != nil {
+		return 0, fmt.Errorf("invalid webhook request: %v: %w", err, asynq.SkipRetry)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-asynq-loadtest-webhooks/1.0")
+	req.Header.Set(webhooksig.HeaderID, payload.EventID)
+	req.Header.Set(webhooksig.HeaderEvent, payload.EventType)
+	req.Header.Set(webhooksig.HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(webhooksig.HeaderSignature, webhooksig.Sign(sub.Secret, timestamp, payload.Body))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return resp.StatusCode, nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests:
+		return resp.StatusCode, fmt.Errorf("webhook endpoint returned %d", resp.StatusCode)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return resp.StatusCode, fmt.Errorf("webhook endpoint returned %d: %w", resp.StatusCode, asynq.SkipRetry)
+	default:
+		return resp.StatusCode, fmt.Errorf("webhook endpoint returned %d", resp.StatusCode)
+	}
+}
+
+// webhookRetryDelay backs off exponentially: 10s, 20s, 40s ... capped at 1h, with ±20% jitter
+func webhookRetryDelay(n int) time.Duration {
+	delay := 10 * time.Second * time.Duration(math.Pow(2, float64(n)))
+	if delay > time.Hour || delay <= 0 {
+		delay = time.Hour
+	}
+	jitter := time.Duration(rand.Int63n(int64(delay)/5*2)) - delay/5
+	return delay + jitter
+}
@@ -0,0 +1,143 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/pkg/webhooksig"
+)
+
+func TestPostWebhookSignsRequest(t *testing.T) {
+	payload := WebhookPayload{
+		SubscriptionID: "WH-1",
+		EventID:        "EVT-1",
+		EventType:      "order.created",
+		Body:           json.RawMessage(`{"order_id":"ORD-1"}`),
+	}
+	sub := &domain.WebhookSubscription{ID: "WH-1", Secret: "whsec_test"}
+
+	var got *http.Request
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	sub.URL = srv.URL
+
+	status, err := postWebhook(context.Background(), srv.Client(), sub, payload)
+	if err != nil {
+		t.Fatalf("postWebhook: %v", err)
+	}
+	if status != http.StatusNoContent {
+		t.Errorf("status = %d, want %d", status, http.StatusNoContent)
+	}
+
+	if got.Method != http.MethodPost {
+		t.Errorf("method = %s, want POST", got.Method)
+	}
+	if string(gotBody) != string(payload.Body) {
+		t.Errorf("body = %s, want %s", gotBody, payload.Body)
+	}
+	for header, want := range map[string]string{
+		"Content-Type":         "application/json",
+		webhooksig.HeaderID:    payload.EventID,
+		webhooksig.HeaderEvent: payload.EventType,
+	} {
+		if v := got.Header.Get(header); v != want {
+			t.Errorf("%s = %q, want %q", header, v, want)
+		}
+	}
+
+	err = webhooksig.Verify(sub.Secret, got.Header.Get(webhooksig.HeaderTimestamp),
+		got.Header.Get(webhooksig.HeaderSignature), gotBody, time.Minute)
+	if err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+	err = webhooksig.Verify("other-secret", got.Header.Get(webhooksig.HeaderTimestamp),
+		got.Header.Get(webhooksig.HeaderSignature), gotBody, time.Minute)
+	if !errors.Is(err, webhooksig.ErrInvalidSignature) {
+		t.Errorf("Verify with another secret = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestPostWebhookRetryClassification(t *testing.T) {
+	tests := []struct {
+		status    int
+		wantErr   bool
+		wantRetry bool
+	}{
+		{http.StatusOK, false, false},
+		{http.StatusAccepted, false, false},
+		{http.StatusNoContent, false, false},
+		{http.StatusBadRequest, true, false},
+		{http.StatusUnauthorized, true, false},
+		{http.StatusNotFound, true, false},
+		{http.StatusGone, true, false},
+		{http.StatusRequestTimeout, true, true},
+		{http.StatusTooManyRequests, true, true},
+		{http.StatusInternalServerError, true, true},
+		{http.StatusBadGateway, true, true},
+		{http.StatusServiceUnavailable, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			sub := &domain.WebhookSubscription{ID: "WH-1", URL: srv.URL, Secret: "whsec_test"}
+			payload := WebhookPayload{SubscriptionID: "WH-1", EventID: "EVT-1", EventType: "order.created", Body: json.RawMessage(`{}`)}
+
+			status, err := postWebhook(context.Background(), srv.Client(), sub, payload)
+			if status != tt.status {
+				t.Errorf("status = %d, want %d", status, tt.status)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error: %v", err, tt.wantErr)
+			}
+			if err != nil && errors.Is(err, asynq.SkipRetry) == tt.wantRetry {
+				t.Errorf("err = %v, want retry: %v", err, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestPostWebhookConnectionErrorIsRetried(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := srv.URL
+	srv.Close()
+
+	sub := &domain.WebhookSubscription{ID: "WH-1", URL: url, Secret: "whsec_test"}
+	payload := WebhookPayload{SubscriptionID: "WH-1", EventID: "EVT-1", EventType: "order.created", Body: json.RawMessage(`{}`)}
+
+	status, err := postWebhook(context.Background(), http.DefaultClient, sub, payload)
+	if err == nil || status != 0 {
+		t.Fatalf("postWebhook = %d, %v; want 0 and an error", status, err)
+	}
+	if errors.Is(err, asynq.SkipRetry) {
+		t.Errorf("connection error should be retried: %v", err)
+	}
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	for n, base := range map[int]time.Duration{0: 10 * time.Second, 3: 80 * time.Second, 20: time.Hour, 100: time.Hour} {
+		for i := 0; i < 50; i++ {
+			d := webhookRetryDelay(n)
+			if d < base*4/5 || d > base*6/5 {
+				t.Fatalf("webhookRetryDelay(%d) = %s, want within 20%% of %s", n, d, base)
+			}
+		}
+	}
+}
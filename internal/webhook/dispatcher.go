@@ -0,0 +1,129 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+	"github.com/lppduy/go-asynq-loadtest/internal/tasks"
+)
+
+// subscriptionCacheTTL bounds how long a new/deleted subscription takes to apply
+const subscriptionCacheTTL = 10 * time.Second
+
+// Dispatcher turns events into webhook:deliver tasks, one per matching subscription
+type Dispatcher struct {
+	repo        repository.WebhookRepository
+	asynqClient *asynq.Client
+
+	mu        sync.Mutex
+	cached    []*domain.WebhookSubscription
+	fetchedAt time.Time
+}
+
+// NewDispatcher creates a new webhook dispatcher
+func NewDispatcher(repo repository.WebhookRepository, asynqClient *asynq.Client) *Dispatcher {
+	return &Dispatcher{repo: repo, asynqClient: asynqClient}
+}
+
+// Dispatch enqueues delivery of an event to every active subscription that wants it
+func (d *Dispatcher) Dispatch(ctx context.Context, eventType string, data interface{}) error {
+	subs, err := d.activeSubscriptions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load webhook subscriptions: %w", err)
+	}
+
+	var event *domain.WebhookEvent
+	var body []byte
+	for _, sub := range subs {
+		if !sub.Matches(eventType) {
+			continue
+		}
+		if event == nil {
+			// Same event (and ID) for every subscriber
+			event = newEvent(eventType, data)
+			if body, err = json.Marshal(event); err != nil {
+				return fmt.Errorf("failed to marshal webhook event: %w", err)
+			}
+		}
+		if err := d.enqueue(ctx, sub, event, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DispatchTo enqueues an event for a single subscription, regardless of its event types
+func (d *Dispatcher) DispatchTo(ctx context.Context, sub *domain.WebhookSubscription, eventType string, data interface{}) (*domain.WebhookEvent, error) {
+	event := newEvent(eventType, data)
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhook event: %w", err)
+	}
+	if err := d.enqueue(ctx, sub, event, body); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func (d *Dispatcher) enqueue(ctx context.Context, sub *domain.WebhookSubscription, event *domain.WebhookEvent, body []byte) error {
+	if _, err := tasks.WebhookDeliver.Enqueue(ctx, d.asynqClient, tasks.WebhookPayload{
+		SubscriptionID: sub.ID,
+		EventID:        event.ID,
+		EventType:      event.Type,
+		Body:           body,
+	}); err != nil {
+		return fmt.Errorf("failed to enqueue webhook for %s: %w", sub.ID, err)
+	}
+	log.Printf("📤 [Enqueued] Webhook %s (%s) for subscription: %s", event.Type, event.ID, sub.ID)
+	return nil
+}
+
+// activeSubscriptions returns subscriptions from a short-lived cache
+func (d *Dispatcher) activeSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.cached != nil && time.Since(d.fetchedAt) < subscriptionCacheTTL {
+		return d.cached, nil
+	}
+
+	subs, err := d.repo.FindActiveSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d.cached = subs
+	d.fetchedAt = time.Now()
+	return subs, nil
+}
+
+func newEvent(eventType string, data interface{}) *domain.WebhookEvent {
+	return &domain.WebhookEvent{
+		ID:        "evt_" + uuid.New().String(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+}
+
+// NewOrderEventData captures the order fields sent in order.* events
+func NewOrderEventData(order *domain.Order, previous domain.OrderStatus) domain.OrderEventData {
+	return domain.OrderEventData{
+		OrderID:        order.ID,
+		CustomerID:     order.CustomerID,
+		Status:         string(order.Status),
+		PreviousStatus: string(previous),
+		PaymentStatus:  string(order.PaymentStatus),
+		TotalAmount:    order.TotalAmount,
+		InvoiceURL:     order.InvoiceURL,
+		TrackingNumber: order.TrackingNumber,
+	}
+}
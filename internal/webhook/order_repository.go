@@ -0,0 +1,62 @@
+package webhook
+
+import (
+	"context"
+	"log"
+
+	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+	"github.com/lppduy/go-asynq-loadtest/internal/repository"
+)
+
+// dispatchingOrderRepository announces order lifecycle events to webhooks
+type dispatchingOrderRepository struct {
+	repository.OrderRepository
+	dispatcher *Dispatcher
+}
+
+// WithDispatcher wraps repo so that creating an order emits order.created and
+// every persisted status transition emits order.<new status>.
+// Dispatch failures are logged; they never fail the write.
+// Inside a repository transaction, events wait for the commit.
+func WithDispatcher(repo repository.OrderRepository, dispatcher *Dispatcher) repository.OrderRepository {
+	return &dispatchingOrderRepository{OrderRepository: repo, dispatcher: dispatcher}
+}
+
+// Create adds the order and emits order.created
+func (r *dispatchingOrderRepository) Create(ctx context.Context, order *domain.Order) error {
+	order.TakeStatusChanges()
+	if err := r.OrderRepository.Create(ctx, order); err != nil {
+		return err
+	}
+
+	// Orders created in a transaction are only announced once it commits
+	data := NewOrderEventData(order, "")
+	repository.AfterCommit(ctx, func(ctx context.Context) {
+		r.dispatch(ctx, domain.WebhookEventOrderCreated, data)
+	})
+	return nil
+}
+
+// Update updates the order and emits one event per status transition
+func (r *dispatchingOrderRepository) Update(ctx context.Context, order *domain.Order) error {
+	changes := order.TakeStatusChanges()
+	if err := r.OrderRepository.Update(ctx, order); err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		data := NewOrderEventData(order, change.From)
+		data.Status = string(change.To)
+		eventType := domain.OrderStatusEventType(change.To)
+		repository.AfterCommit(ctx, func(ctx context.Context) {
+			r.dispatch(ctx, eventType, data)
+		})
+	}
+	return nil
+}
+
+func (r *dispatchingOrderRepository) dispatch(ctx context.Context, eventType string, data domain.OrderEventData) {
+	if err := r.dispatcher.Dispatch(ctx, eventType, data); err != nil {
+		log.Printf("⚠️  [Webhook] Failed to dispatch %s for order %s: %v", eventType, data.OrderID, err)
+	}
+}
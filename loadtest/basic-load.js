@@ -22,6 +22,13 @@ export const options = {
 
 const BASE_URL = 'http://localhost:8080';
 
+// Required unless the API runs with AUTH_ENABLED=false (key needs the admin scope
+// because orders are created for many different customer IDs)
+const API_KEY = __ENV.API_KEY || '';
+const HEADERS = API_KEY
+  ? { 'Content-Type': 'application/json', 'X-API-Key': API_KEY }
+  : { 'Content-Type': 'application/json' };
+
 // Generate random order data
 function generateOrder(userId) {
   return {
@@ -30,9 +37,7 @@ function generateOrder(userId) {
     items: [
       {
         product_id: `prod-${Math.floor(Math.random() * 100)}`,
-        product_name: `Product ${Math.floor(Math.random() * 100)}`,
-        quantity: Math.floor(Math.random() * 5) + 1,
-        unit_price: Math.floor(Math.random() * 1000) + 10,
+        quantity: Math.floor(Math.random() * 5) + 1, // name & price come from the catalog (cmd/seed)
       }
     ],
     shipping_address: {
@@ -65,7 +70,7 @@ export default function () {
     `${BASE_URL}/api/v1/orders`,
     orderPayload,
     {
-      headers: { 'Content-Type': 'application/json' },
+      headers: HEADERS,
     }
   );
 
@@ -89,7 +94,7 @@ export default function () {
     // Test 3: Get order by ID
     try {
       const order = JSON.parse(orderRes.body);
-      const getRes = http.get(`${BASE_URL}/api/v1/orders/${order.id}`);
+      const getRes = http.get(`${BASE_URL}/api/v1/orders/${order.id}`, { headers: HEADERS });
       
       check(getRes, {
         'get order status is 200': (r) => r.status === 200,
@@ -111,7 +116,7 @@ export default function () {
   sleep(1);
 
   // Test 4: List orders
-  const listRes = http.get(`${BASE_URL}/api/v1/orders?page=1&limit=10`);
+  const listRes = http.get(`${BASE_URL}/api/v1/orders?page=1&limit=10`, { headers: HEADERS });
   check(listRes, {
     'list orders status is 200': (r) => r.status === 200,
     'list has orders': (r) => {
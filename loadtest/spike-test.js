@@ -18,15 +18,20 @@ export const options = {
 
 const BASE_URL = 'http://localhost:8080';
 
+// Required unless the API runs with AUTH_ENABLED=false (key needs the admin scope
+// because orders are created for many different customer IDs)
+const API_KEY = __ENV.API_KEY || '';
+const HEADERS = API_KEY
+  ? { 'Content-Type': 'application/json', 'X-API-Key': API_KEY }
+  : { 'Content-Type': 'application/json' };
+
 function generateOrder(userId) {
   return {
     customer_id: `spike-${userId}`,
     customer_email: `spike${userId}@test.com`,
     items: [{
       product_id: `prod-${Math.floor(Math.random() * 50)}`,
-      product_name: `Spike Product`,
-      quantity: 1,
-      unit_price: 99.99,
+      quantity: 1, // name & price come from the catalog (cmd/seed)
     }],
     shipping_address: {
       street: '999 Spike St',
@@ -48,7 +53,7 @@ export default function () {
     `${BASE_URL}/api/v1/orders`,
     orderPayload,
     {
-      headers: { 'Content-Type': 'application/json' },
+      headers: HEADERS,
       timeout: '15s',
     }
   );
@@ -22,6 +22,13 @@ export const options = {
 
 const BASE_URL = 'http://localhost:8080';
 
+// Required unless the API runs with AUTH_ENABLED=false (key needs the admin scope
+// because orders are created for many different customer IDs)
+const API_KEY = __ENV.API_KEY || '';
+const HEADERS = API_KEY
+  ? { 'Content-Type': 'application/json', 'X-API-Key': API_KEY }
+  : { 'Content-Type': 'application/json' };
+
 function generateOrder(userId) {
   return {
     customer_id: `stress-${userId}`,
@@ -29,9 +36,7 @@ function generateOrder(userId) {
     items: [
       {
         product_id: `prod-${Math.floor(Math.random() * 100)}`,
-        product_name: `Product ${Math.floor(Math.random() * 100)}`,
-        quantity: Math.floor(Math.random() * 5) + 1,
-        unit_price: Math.floor(Math.random() * 1000) + 10,
+        quantity: Math.floor(Math.random() * 5) + 1, // name & price come from the catalog (cmd/seed)
       }
     ],
     shipping_address: {
@@ -55,7 +60,7 @@ export default function () {
     `${BASE_URL}/api/v1/orders`,
     orderPayload,
     {
-      headers: { 'Content-Type': 'application/json' },
+      headers: HEADERS,
       timeout: '10s',
     }
   );
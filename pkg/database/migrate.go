@@ -0,0 +1,336 @@
+package database
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Each dialect has its own directory; a version means the same schema in both
+//
+//go:embed migrations/postgres/*.sql migrations/sqlite/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the pg_advisory_lock key held while migrations run,
+// so concurrent `migrate` runs wait for each other instead of racing
+const migrationLockID int64 = 7_364_201_036
+
+// ErrSchemaOutdated is returned by CheckSchema when migrations are pending
+var ErrSchemaOutdated = errors.New("database schema is not up to date")
+
+// Migration is one versioned schema change with its rollback
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// schemaMigration represents the schema_migrations table
+type schemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName overrides the table name
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migrator applies the SQL files embedded from pkg/database/migrations/<dialect>
+type Migrator struct {
+	db         *gorm.DB
+	dialect    string      // postgres or sqlite
+	migrations []Migration // Sorted by version
+}
+
+// NewMigrator loads the embedded migrations for the dialect of db. Files are
+// named <version>_<name>.up.sql / .down.sql and every version needs both.
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	dialect := db.Dialector.Name()
+	if dialect != DriverPostgres && dialect != DriverSQLite {
+		return nil, fmt.Errorf("no migrations for database dialect %q", dialect)
+	}
+	migrations, err := loadMigrations(migrationFiles, "migrations/"+dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file name %q", entry.Name())
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		body, err := fs.ReadFile(fsys, dir+"/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Latest returns the newest migration version known to this binary
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies pending migrations in order, at most steps of them (0 = all).
+// Each migration and its schema_migrations row commit in one transaction.
+func (m *Migrator) Up(steps int) ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(func(conn *gorm.DB) error {
+		done, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			if steps > 0 && len(applied) == steps {
+				break
+			}
+
+			log.Printf("⬆️  Applying migration %04d_%s", migration.Version, migration.Name)
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Up).Error; err != nil {
+					return err
+				}
+				return tx.Create(&schemaMigration{
+					Version:   migration.Version,
+					Name:      migration.Name,
+					AppliedAt: time.Now(),
+				}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the most recently applied migrations, at most steps of them
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.locked(func(conn *gorm.DB) error {
+		done, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+
+			log.Printf("⬇️  Reverting migration %04d_%s", migration.Version, migration.Name)
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Down).Error; err != nil {
+					return err
+				}
+				return tx.Delete(&schemaMigration{}, "version = ?", migration.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists every known migration with the time it was applied (nil = pending)
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	done := map[int64]schemaMigration{}
+	if m.db.Migrator().HasTable(&schemaMigration{}) {
+		var err error
+		if done, err = appliedVersions(m.db); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = MigrationStatus{Migration: migration}
+		if row, ok := done[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			statuses[i].AppliedAt = &appliedAt
+		}
+	}
+	return statuses, nil
+}
+
+// CheckSchema verifies that every migration known to this binary has been applied.
+// Binaries call it at boot instead of changing the schema themselves.
+func (m *Migrator) CheckSchema() error {
+	if !m.db.Migrator().HasTable(&schemaMigration{}) {
+		return fmt.Errorf("%w: no schema_migrations table, run `go run cmd/migrate/main.go up`", ErrSchemaOutdated)
+	}
+	done, err := appliedVersions(m.db)
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, migration := range m.migrations {
+		if _, ok := done[migration.Version]; !ok {
+			pending = append(pending, fmt.Sprintf("%04d_%s", migration.Version, migration.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: pending %s, run `go run cmd/migrate/main.go up`", ErrSchemaOutdated, strings.Join(pending, ", "))
+	}
+
+	// A newer deploy may already have migrated; this binary keeps working as
+	// long as migrations stay backward compatible
+	for version := range done {
+		if version > m.Latest() {
+			log.Printf("⚠️  Database schema (version %d) is newer than this binary (version %d)", version, m.Latest())
+			break
+		}
+	}
+
+	log.Printf("✅ Database schema is at version %d", m.Latest())
+	return nil
+}
+
+// CheckSchema verifies that db has every migration of this binary applied
+func CheckSchema(db *gorm.DB) error {
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	return migrator.CheckSchema()
+}
+
+// MigrateUp applies every pending migration of this binary to db
+func MigrateUp(db *gorm.DB) error {
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(0)
+	if err != nil {
+		return err
+	}
+	log.Printf("✅ Database schema is at version %d (%d migration(s) applied)", migrator.Latest(), len(applied))
+	return nil
+}
+
+// locked runs fn on one pooled connection holding the migration advisory lock.
+// SQLite has no advisory locks; fn runs in one transaction holding the database
+// write lock instead, so processes migrating at the same time take turns.
+func (m *Migrator) locked(fn func(conn *gorm.DB) error) error {
+	return m.db.Connection(func(conn *gorm.DB) error {
+		if m.dialect == DriverSQLite {
+			return conn.Transaction(func(tx *gorm.DB) error {
+				if err := ensureMigrationsTable(tx, m.dialect); err != nil {
+					return err
+				}
+				return fn(tx)
+			})
+		}
+
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockID).Error; err != nil {
+			return fmt.Errorf("failed to take migration lock: %w", err)
+		}
+		defer func() {
+			if err := conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockID).Error; err != nil {
+				log.Printf("⚠️  Failed to release migration lock: %v", err)
+			}
+		}()
+
+		if err := ensureMigrationsTable(conn, m.dialect); err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+func ensureMigrationsTable(db *gorm.DB, dialect string) error {
+	if dialect == DriverSQLite {
+		return db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    bigint       PRIMARY KEY,
+			name       varchar(255) NOT NULL,
+			applied_at datetime     NOT NULL
+		)`).Error
+	}
+	return db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    bigint       PRIMARY KEY,
+		name       varchar(255) NOT NULL,
+		applied_at timestamptz  NOT NULL
+	)`).Error
+}
+
+// appliedVersions reads schema_migrations keyed by version
+func appliedVersions(db *gorm.DB) (map[int64]schemaMigration, error) {
+	var rows []schemaMigration
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	done := make(map[int64]schemaMigration, len(rows))
+	for _, row := range rows {
+		done[row.Version] = row
+	}
+	return done, nil
+}
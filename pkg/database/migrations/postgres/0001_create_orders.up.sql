@@ -0,0 +1,24 @@
+-- Orders as first released: items and address as JSON text, total as decimal.
+-- IF NOT EXISTS lets databases created by the old AutoMigrate adopt this history.
+CREATE TABLE IF NOT EXISTS orders (
+    id              varchar(50)   PRIMARY KEY,
+    customer_id     varchar(100)  NOT NULL,
+    customer_email  varchar(255)  NOT NULL,
+    items_json      text          NOT NULL,
+    total_amount    decimal(10,2) NOT NULL,
+    address_json    text          NOT NULL,
+    status          varchar(50)   NOT NULL DEFAULT 'pending',
+    payment_status  varchar(50)   NOT NULL DEFAULT 'pending',
+    payment_method  varchar(50)   NOT NULL,
+    invoice_url     varchar(500),
+    tracking_number varchar(100),
+    notes           text,
+    created_at      timestamptz   NOT NULL,
+    updated_at      timestamptz   NOT NULL,
+    deleted_at      timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_customer_id ON orders (customer_id);
+CREATE INDEX IF NOT EXISTS idx_orders_status ON orders (status);
+CREATE INDEX IF NOT EXISTS idx_orders_created_at ON orders (created_at);
+CREATE INDEX IF NOT EXISTS idx_orders_deleted_at ON orders (deleted_at);
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id          varchar(50)   PRIMARY KEY,
+    url         varchar(2000) NOT NULL,
+    secret      varchar(255)  NOT NULL,
+    event_types text          NOT NULL,
+    active      boolean       NOT NULL DEFAULT true,
+    created_at  timestamptz   NOT NULL,
+    updated_at  timestamptz   NOT NULL,
+    deleted_at  timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_deleted_at ON webhook_subscriptions (deleted_at);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              bigserial    PRIMARY KEY,
+    subscription_id varchar(50)  NOT NULL,
+    event_id        varchar(50)  NOT NULL,
+    event_type      varchar(100) NOT NULL,
+    attempt         bigint       NOT NULL,
+    status_code     bigint       NOT NULL DEFAULT 0,
+    success         boolean      NOT NULL,
+    error           text,
+    duration_ms     bigint       NOT NULL,
+    created_at      timestamptz  NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_sub_created ON webhook_deliveries (subscription_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event_id ON webhook_deliveries (event_id);
@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS products (
+    id         varchar(50)   PRIMARY KEY,
+    name       varchar(255)  NOT NULL,
+    price      decimal(10,2) NOT NULL,
+    active     boolean       NOT NULL DEFAULT true,
+    created_at timestamptz   NOT NULL,
+    updated_at timestamptz   NOT NULL,
+    deleted_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products (deleted_at);
+
+-- One row per product; the checks make overselling impossible at the database level
+CREATE TABLE IF NOT EXISTS inventory (
+    product_id varchar(50) PRIMARY KEY,
+    on_hand    bigint      NOT NULL DEFAULT 0,
+    reserved   bigint      NOT NULL DEFAULT 0,
+    updated_at timestamptz NOT NULL,
+    CONSTRAINT chk_inventory_on_hand CHECK (on_hand >= 0),
+    CONSTRAINT chk_inventory_reserved CHECK (reserved >= 0 AND reserved <= on_hand),
+    CONSTRAINT fk_products_inventory FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS inventory_reservations (
+    id         bigserial   PRIMARY KEY,
+    order_id   varchar(50) NOT NULL,
+    product_id varchar(50) NOT NULL,
+    quantity   bigint      NOT NULL,
+    status     varchar(20) NOT NULL,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reservations_order_product ON inventory_reservations (order_id, product_id);
+CREATE INDEX IF NOT EXISTS idx_inventory_reservations_status ON inventory_reservations (status);
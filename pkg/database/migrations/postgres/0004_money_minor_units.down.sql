@@ -0,0 +1,10 @@
+-- Amounts in zero-decimal currencies (JPY, KRW, VND) do not survive this.
+ALTER TABLE orders ADD COLUMN total_amount decimal(10,2) NOT NULL DEFAULT 0;
+UPDATE orders SET total_amount = total_amount_minor / 100.0;
+ALTER TABLE orders ALTER COLUMN total_amount DROP DEFAULT;
+ALTER TABLE orders DROP COLUMN total_amount_minor, DROP COLUMN currency;
+
+ALTER TABLE products ADD COLUMN price decimal(10,2) NOT NULL DEFAULT 0;
+UPDATE products SET price = price_minor / 100.0;
+ALTER TABLE products ALTER COLUMN price DROP DEFAULT;
+ALTER TABLE products DROP COLUMN price_minor, DROP COLUMN currency;
@@ -0,0 +1,20 @@
+-- Amounts become integer minor units plus currency. Rows of the decimal era are USD.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS total_amount_minor bigint NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS currency char(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS price_minor bigint NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS currency char(3) NOT NULL DEFAULT 'USD';
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'orders' AND column_name = 'total_amount') THEN
+        UPDATE orders SET total_amount_minor = ROUND(total_amount * 100);
+        ALTER TABLE orders DROP COLUMN total_amount;
+    END IF;
+
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'products' AND column_name = 'price') THEN
+        UPDATE products SET price_minor = ROUND(price * 100);
+        ALTER TABLE products DROP COLUMN price;
+    END IF;
+END $$;
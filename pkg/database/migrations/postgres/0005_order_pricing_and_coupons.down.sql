@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS coupon_redemptions;
+DROP TABLE IF EXISTS coupons;
+
+ALTER TABLE orders
+    DROP COLUMN subtotal_minor,
+    DROP COLUMN discount_minor,
+    DROP COLUMN shipping_minor,
+    DROP COLUMN tax_minor,
+    DROP COLUMN tax_rate_bp,
+    DROP COLUMN coupon_code,
+    DROP COLUMN shipping_priority;
@@ -0,0 +1,42 @@
+-- Price breakdown of orders
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS subtotal_minor    bigint      NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS discount_minor    bigint      NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS shipping_minor    bigint      NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_minor         bigint      NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_rate_bp       bigint      NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS coupon_code       varchar(50),
+    ADD COLUMN IF NOT EXISTS shipping_priority varchar(20) NOT NULL DEFAULT 'standard';
+
+CREATE INDEX IF NOT EXISTS idx_orders_coupon_code ON orders (coupon_code);
+
+-- Orders from before the breakdown only had a total (priced orders always have a subtotal)
+UPDATE orders SET subtotal_minor = total_amount_minor
+WHERE subtotal_minor = 0 AND discount_minor = 0 AND shipping_minor = 0 AND tax_minor = 0;
+
+CREATE TABLE IF NOT EXISTS coupons (
+    code            varchar(50) PRIMARY KEY,
+    type            varchar(20) NOT NULL,
+    percent_bp      bigint      NOT NULL DEFAULT 0,
+    amount_minor    bigint      NOT NULL DEFAULT 0,
+    min_spend_minor bigint      NOT NULL DEFAULT 0,
+    currency        char(3)     NOT NULL DEFAULT 'USD',
+    max_uses        bigint      NOT NULL DEFAULT 0,
+    used_count      bigint      NOT NULL DEFAULT 0,
+    active          boolean     NOT NULL DEFAULT true,
+    expires_at      timestamptz,
+    created_at      timestamptz NOT NULL,
+    updated_at      timestamptz NOT NULL,
+    CONSTRAINT chk_coupons_used_count CHECK (used_count >= 0)
+);
+
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+    id             bigserial   PRIMARY KEY,
+    coupon_code    varchar(50) NOT NULL,
+    order_id       varchar(50) NOT NULL,
+    discount_minor bigint      NOT NULL,
+    created_at     timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_coupon_code ON coupon_redemptions (coupon_code);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_coupon_redemptions_order_id ON coupon_redemptions (order_id);
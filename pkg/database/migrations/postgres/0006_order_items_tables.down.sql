@@ -0,0 +1,35 @@
+-- Rebuild the JSON columns from the tables, then drop the tables
+CREATE FUNCTION pg_temp.money_json(minor bigint, currency text) RETURNS json AS $$
+    SELECT json_build_object(
+        'amount', CASE WHEN currency IN ('JPY', 'KRW', 'VND') THEN minor::text
+                       ELSE to_char(minor / 100.0, 'FM999999999999990.00') END,
+        'currency', currency)
+$$ LANGUAGE sql IMMUTABLE;
+
+ALTER TABLE orders ADD COLUMN items_json text, ADD COLUMN address_json text;
+
+UPDATE orders o SET
+    items_json = COALESCE((
+        SELECT json_agg(json_build_object(
+                   'product_id', i.product_id,
+                   'product_name', i.product_name,
+                   'quantity', i.quantity,
+                   'unit_price', pg_temp.money_json(i.unit_price_minor, i.currency),
+                   'subtotal', pg_temp.money_json(i.subtotal_minor, i.currency)
+               ) ORDER BY i.line_no)::text
+        FROM order_items i WHERE i.order_id = o.id), '[]'),
+    address_json = COALESCE((
+        SELECT json_build_object(
+                   'street', a.street,
+                   'city', a.city,
+                   'state', a.state,
+                   'postal_code', a.postal_code,
+                   'country', a.country
+               )::text
+        FROM order_addresses a WHERE a.order_id = o.id), '{}');
+
+ALTER TABLE orders ALTER COLUMN items_json SET NOT NULL, ALTER COLUMN address_json SET NOT NULL;
+
+DROP FUNCTION pg_temp.money_json(bigint, text);
+DROP TABLE IF EXISTS order_addresses;
+DROP TABLE IF EXISTS order_items;
@@ -0,0 +1,82 @@
+-- Order lines and shipping address move out of JSON text into their own tables.
+-- product_id is not a foreign key: orders outlive catalog entries.
+CREATE TABLE IF NOT EXISTS order_items (
+    order_id         varchar(50)  NOT NULL,
+    line_no          bigint       NOT NULL,
+    product_id       varchar(50)  NOT NULL,
+    product_name     varchar(255) NOT NULL,
+    quantity         bigint       NOT NULL,
+    unit_price_minor bigint       NOT NULL,
+    subtotal_minor   bigint       NOT NULL,
+    currency         char(3)      NOT NULL,
+    PRIMARY KEY (order_id, line_no),
+    CONSTRAINT chk_order_items_quantity CHECK (quantity > 0),
+    CONSTRAINT fk_orders_items FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_items_product_id ON order_items (product_id);
+
+CREATE TABLE IF NOT EXISTS order_addresses (
+    order_id    varchar(50)  PRIMARY KEY,
+    street      varchar(255) NOT NULL,
+    city        varchar(100) NOT NULL,
+    state       varchar(100) NOT NULL,
+    postal_code varchar(20)  NOT NULL,
+    country     varchar(100) NOT NULL,
+    CONSTRAINT fk_orders_address FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_addresses_country ON order_addresses (country);
+
+-- Item amounts are {"amount": "19.99", "currency": "USD"} or, before currencies,
+-- a bare number in dollars
+CREATE FUNCTION pg_temp.json_money_minor(v jsonb, currency text) RETURNS bigint AS $$
+    SELECT CASE
+        WHEN jsonb_typeof(v) = 'number' THEN ROUND((v #>> '{}')::numeric * 100)
+        WHEN currency IN ('JPY', 'KRW', 'VND') THEN ROUND((v ->> 'amount')::numeric)
+        ELSE ROUND((v ->> 'amount')::numeric * 100)
+    END::bigint
+$$ LANGUAGE sql IMMUTABLE;
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'orders' AND column_name = 'items_json') THEN
+        -- Soft-deleted orders are included
+        EXECUTE $sql$
+            INSERT INTO order_items (order_id, line_no, product_id, product_name, quantity,
+                                     unit_price_minor, subtotal_minor, currency)
+            SELECT o.id,
+                   e.ord - 1,
+                   e.item ->> 'product_id',
+                   COALESCE(e.item ->> 'product_name', ''),
+                   (e.item ->> 'quantity')::bigint,
+                   pg_temp.json_money_minor(e.item -> 'unit_price', c.currency),
+                   pg_temp.json_money_minor(e.item -> 'subtotal', c.currency),
+                   c.currency
+            FROM orders o
+            CROSS JOIN LATERAL jsonb_array_elements(o.items_json::jsonb) WITH ORDINALITY AS e(item, ord)
+            CROSS JOIN LATERAL (
+                SELECT COALESCE(NULLIF(e.item -> 'unit_price' ->> 'currency', ''), o.currency) AS currency
+            ) c
+            ON CONFLICT DO NOTHING
+        $sql$;
+
+        EXECUTE $sql$
+            INSERT INTO order_addresses (order_id, street, city, state, postal_code, country)
+            SELECT o.id,
+                   COALESCE(a.address ->> 'street', ''),
+                   COALESCE(a.address ->> 'city', ''),
+                   COALESCE(a.address ->> 'state', ''),
+                   COALESCE(a.address ->> 'postal_code', ''),
+                   COALESCE(a.address ->> 'country', '')
+            FROM orders o
+            CROSS JOIN LATERAL (SELECT o.address_json::jsonb AS address) a
+            ON CONFLICT DO NOTHING
+        $sql$;
+
+        ALTER TABLE orders DROP COLUMN items_json, DROP COLUMN address_json;
+    END IF;
+END $$;
+
+DROP FUNCTION pg_temp.json_money_minor(jsonb, text);
@@ -0,0 +1,14 @@
+-- Local analytics store, written in batches by analytics:flush. One row per
+-- order: replayed batches do not count an order twice.
+CREATE TABLE IF NOT EXISTS analytics_events (
+    order_id           varchar(50)  PRIMARY KEY,
+    customer_id        varchar(100) NOT NULL,
+    total_amount_minor bigint       NOT NULL,
+    currency           char(3)      NOT NULL,
+    item_count         bigint       NOT NULL,
+    payment_method     varchar(50)  NOT NULL,
+    created_at         timestamptz  NOT NULL,
+    recorded_at        timestamptz  NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_analytics_events_created_at ON analytics_events (created_at);
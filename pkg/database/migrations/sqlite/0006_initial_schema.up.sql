@@ -0,0 +1,152 @@
+-- SQLite starts at the schema PostgreSQL reaches with 0006, there is no older
+-- SQLite data to convert. Later versions get a file in both directories.
+-- Time columns are datetime so the driver scans them into time.Time.
+CREATE TABLE IF NOT EXISTS orders (
+    id                 varchar(50)  PRIMARY KEY,
+    customer_id        varchar(100) NOT NULL,
+    customer_email     varchar(255) NOT NULL,
+    total_amount_minor bigint       NOT NULL DEFAULT 0,
+    currency           char(3)      NOT NULL DEFAULT 'USD',
+    subtotal_minor     bigint       NOT NULL DEFAULT 0,
+    discount_minor     bigint       NOT NULL DEFAULT 0,
+    shipping_minor     bigint       NOT NULL DEFAULT 0,
+    tax_minor          bigint       NOT NULL DEFAULT 0,
+    tax_rate_bp        bigint       NOT NULL DEFAULT 0,
+    coupon_code        varchar(50),
+    shipping_priority  varchar(20)  NOT NULL DEFAULT 'standard',
+    status             varchar(50)  NOT NULL DEFAULT 'pending',
+    payment_status     varchar(50)  NOT NULL DEFAULT 'pending',
+    payment_method     varchar(50)  NOT NULL,
+    invoice_url        varchar(500),
+    tracking_number    varchar(100),
+    notes              text,
+    created_at         datetime     NOT NULL,
+    updated_at         datetime     NOT NULL,
+    deleted_at         datetime
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_customer_id ON orders (customer_id);
+CREATE INDEX IF NOT EXISTS idx_orders_status ON orders (status);
+CREATE INDEX IF NOT EXISTS idx_orders_created_at ON orders (created_at);
+CREATE INDEX IF NOT EXISTS idx_orders_deleted_at ON orders (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_orders_coupon_code ON orders (coupon_code);
+
+CREATE TABLE IF NOT EXISTS order_items (
+    order_id         varchar(50)  NOT NULL,
+    line_no          bigint       NOT NULL,
+    product_id       varchar(50)  NOT NULL,
+    product_name     varchar(255) NOT NULL,
+    quantity         bigint       NOT NULL,
+    unit_price_minor bigint       NOT NULL,
+    subtotal_minor   bigint       NOT NULL,
+    currency         char(3)      NOT NULL,
+    PRIMARY KEY (order_id, line_no),
+    CONSTRAINT chk_order_items_quantity CHECK (quantity > 0),
+    CONSTRAINT fk_orders_items FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_items_product_id ON order_items (product_id);
+
+CREATE TABLE IF NOT EXISTS order_addresses (
+    order_id    varchar(50)  PRIMARY KEY,
+    street      varchar(255) NOT NULL,
+    city        varchar(100) NOT NULL,
+    state       varchar(100) NOT NULL,
+    postal_code varchar(20)  NOT NULL,
+    country     varchar(100) NOT NULL,
+    CONSTRAINT fk_orders_address FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_addresses_country ON order_addresses (country);
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id          varchar(50)   PRIMARY KEY,
+    url         varchar(2000) NOT NULL,
+    secret      varchar(255)  NOT NULL,
+    event_types text          NOT NULL,
+    active      boolean       NOT NULL DEFAULT true,
+    created_at  datetime      NOT NULL,
+    updated_at  datetime      NOT NULL,
+    deleted_at  datetime
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_deleted_at ON webhook_subscriptions (deleted_at);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              integer      PRIMARY KEY AUTOINCREMENT,
+    subscription_id varchar(50)  NOT NULL,
+    event_id        varchar(50)  NOT NULL,
+    event_type      varchar(100) NOT NULL,
+    attempt         bigint       NOT NULL,
+    status_code     bigint       NOT NULL DEFAULT 0,
+    success         boolean      NOT NULL,
+    error           text,
+    duration_ms     bigint       NOT NULL,
+    created_at      datetime     NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_sub_created ON webhook_deliveries (subscription_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event_id ON webhook_deliveries (event_id);
+
+CREATE TABLE IF NOT EXISTS products (
+    id          varchar(50)  PRIMARY KEY,
+    name        varchar(255) NOT NULL,
+    price_minor bigint       NOT NULL DEFAULT 0,
+    currency    char(3)      NOT NULL DEFAULT 'USD',
+    active      boolean      NOT NULL DEFAULT true,
+    created_at  datetime     NOT NULL,
+    updated_at  datetime     NOT NULL,
+    deleted_at  datetime
+);
+
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products (deleted_at);
+
+CREATE TABLE IF NOT EXISTS inventory (
+    product_id varchar(50) PRIMARY KEY,
+    on_hand    bigint      NOT NULL DEFAULT 0,
+    reserved   bigint      NOT NULL DEFAULT 0,
+    updated_at datetime    NOT NULL,
+    CONSTRAINT chk_inventory_on_hand CHECK (on_hand >= 0),
+    CONSTRAINT chk_inventory_reserved CHECK (reserved >= 0 AND reserved <= on_hand),
+    CONSTRAINT fk_products_inventory FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS inventory_reservations (
+    id         integer     PRIMARY KEY AUTOINCREMENT,
+    order_id   varchar(50) NOT NULL,
+    product_id varchar(50) NOT NULL,
+    quantity   bigint      NOT NULL,
+    status     varchar(20) NOT NULL,
+    created_at datetime    NOT NULL,
+    updated_at datetime    NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reservations_order_product ON inventory_reservations (order_id, product_id);
+CREATE INDEX IF NOT EXISTS idx_inventory_reservations_status ON inventory_reservations (status);
+
+CREATE TABLE IF NOT EXISTS coupons (
+    code            varchar(50) PRIMARY KEY,
+    type            varchar(20) NOT NULL,
+    percent_bp      bigint      NOT NULL DEFAULT 0,
+    amount_minor    bigint      NOT NULL DEFAULT 0,
+    min_spend_minor bigint      NOT NULL DEFAULT 0,
+    currency        char(3)     NOT NULL DEFAULT 'USD',
+    max_uses        bigint      NOT NULL DEFAULT 0,
+    used_count      bigint      NOT NULL DEFAULT 0,
+    active          boolean     NOT NULL DEFAULT true,
+    expires_at      datetime,
+    created_at      datetime    NOT NULL,
+    updated_at      datetime    NOT NULL,
+    CONSTRAINT chk_coupons_used_count CHECK (used_count >= 0)
+);
+
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+    id             integer     PRIMARY KEY AUTOINCREMENT,
+    coupon_code    varchar(50) NOT NULL,
+    order_id       varchar(50) NOT NULL,
+    discount_minor bigint      NOT NULL,
+    created_at     datetime    NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_coupon_code ON coupon_redemptions (coupon_code);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_coupon_redemptions_order_id ON coupon_redemptions (order_id);
@@ -0,0 +1,3 @@
+-- Name of the customer as entered at checkout, used in emails and invoices.
+-- Older orders have none.
+ALTER TABLE orders ADD COLUMN customer_name varchar(255) NOT NULL DEFAULT '';
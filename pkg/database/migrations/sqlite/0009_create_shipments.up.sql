@@ -0,0 +1,42 @@
+-- Parcels handed to carriers by warehouse:notify, with their status history.
+-- Carrier callbacks look shipments up by (carrier, tracking_number).
+CREATE TABLE IF NOT EXISTS shipments (
+    id                 varchar(50)  PRIMARY KEY,
+    order_id           varchar(50)  NOT NULL,
+    parcel             bigint       NOT NULL,
+    carrier            varchar(50)  NOT NULL,
+    service_level      varchar(20)  NOT NULL,
+    tracking_number    varchar(100) NOT NULL,
+    label_url          varchar(500) NOT NULL,
+    status             varchar(50)  NOT NULL,
+    estimated_delivery datetime     NOT NULL,
+    created_at         datetime     NOT NULL,
+    updated_at         datetime     NOT NULL,
+    CONSTRAINT fk_orders_shipments FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shipments_order_parcel ON shipments (order_id, parcel);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shipments_carrier_tracking ON shipments (carrier, tracking_number);
+CREATE INDEX IF NOT EXISTS idx_shipments_status ON shipments (status);
+
+CREATE TABLE IF NOT EXISTS shipment_items (
+    shipment_id  varchar(50)  NOT NULL,
+    line_no      bigint       NOT NULL,
+    product_id   varchar(50)  NOT NULL,
+    product_name varchar(255) NOT NULL,
+    quantity     bigint       NOT NULL,
+    PRIMARY KEY (shipment_id, line_no),
+    CONSTRAINT chk_shipment_items_quantity CHECK (quantity > 0),
+    CONSTRAINT fk_shipments_items FOREIGN KEY (shipment_id) REFERENCES shipments (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS shipment_events (
+    shipment_id varchar(50)  NOT NULL,
+    seq         bigint       NOT NULL,
+    status      varchar(50)  NOT NULL,
+    location    varchar(255) NOT NULL,
+    description varchar(500) NOT NULL,
+    occurred_at datetime     NOT NULL,
+    PRIMARY KEY (shipment_id, seq),
+    CONSTRAINT fk_shipments_events FOREIGN KEY (shipment_id) REFERENCES shipments (id) ON DELETE CASCADE
+);
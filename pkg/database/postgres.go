@@ -8,33 +8,43 @@ import (
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+)
 
-	"github.com/lppduy/go-asynq-loadtest/internal/domain"
+// Supported drivers
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory" // Private SQLite database in memory, see connectMemory
 )
 
 // Config holds database configuration
 type Config struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Driver     string // postgres (default), sqlite or memory
+	SQLitePath string // Database file when Driver is sqlite
+	Host       string
+	Port       string
+	User       string
+	Password   string
+	DBName     string
+	SSLMode    string
+	DSN        string // PostgreSQL connection string; replaces Host to SSLMode when set
 }
 
 // Connect creates a new database connection
 func Connect(cfg Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
-		cfg.Host,
-		cfg.User,
-		cfg.Password,
-		cfg.DBName,
-		cfg.Port,
-		cfg.SSLMode,
-	)
+	var dialector gorm.Dialector
+	switch cfg.Driver {
+	case DriverPostgres, "":
+		dialector = postgresDialector(cfg)
+	case DriverSQLite:
+		dialector = sqliteDialector(cfg.SQLitePath)
+	case DriverMemory:
+		dialector = sqliteDialector(":memory:")
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
@@ -56,22 +66,30 @@ func Connect(cfg Config) (*gorm.DB, error) {
 	sqlDB.SetMaxOpenConns(100)
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
-	log.Println("✅ Database connected successfully")
+	if cfg.Driver == DriverMemory {
+		if err := connectMemory(db); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Printf("✅ Database connected successfully (%s)", db.Dialector.Name())
 	return db, nil
 }
 
-// AutoMigrate runs database migrations
-func AutoMigrate(db *gorm.DB) error {
-	log.Println("🔄 Running database migrations...")
-	
-	err := db.AutoMigrate(
-		&domain.OrderModel{},
-	)
-	
-	if err != nil {
-		return fmt.Errorf("failed to run migrations: %w", err)
+// postgresDialector opens PostgreSQL through pgx
+func postgresDialector(cfg Config) gorm.Dialector {
+	if cfg.DSN != "" {
+		return postgres.Open(cfg.DSN)
 	}
+	dsn := fmt.Sprintf(
+		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
+		cfg.Host,
+		cfg.User,
+		cfg.Password,
+		cfg.DBName,
+		cfg.Port,
+		cfg.SSLMode,
+	)
 
-	log.Println("✅ Database migrations completed")
-	return nil
+	return postgres.Open(dsn)
 }
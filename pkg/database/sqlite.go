@@ -0,0 +1,46 @@
+package database
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// sqliteDialector opens a SQLite file through a pure-Go driver (no cgo), for
+// local development and CI without Docker.
+//   - foreign_keys: SQLite leaves ON DELETE CASCADE off by default
+//   - journal_mode(WAL): the API and the worker can read while the other writes
+//   - busy_timeout: writers wait for the lock instead of failing with SQLITE_BUSY
+//   - _txlock=immediate: transactions take the write lock at BEGIN, so
+//     read-then-write transactions (stock reservations) serialize like
+//     SELECT ... FOR UPDATE does on PostgreSQL
+func sqliteDialector(path string) gorm.Dialector {
+	query := url.Values{}
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Add("_pragma", "busy_timeout(5000)")
+	query.Set("_txlock", "immediate")
+	return sqlite.Open("file:" + path + "?" + query.Encode())
+}
+
+// connectMemory prepares a database opened on ":memory:". Every connection to
+// it is a database of its own, so the pool keeps exactly one, forever, and the
+// schema is created right away: there is nothing to migrate beforehand and
+// nothing is left when the process exits.
+func connectMemory(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database instance: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+	sqlDB.SetConnMaxLifetime(0)
+	sqlDB.SetConnMaxIdleTime(0)
+
+	if err := MigrateUp(db); err != nil {
+		return fmt.Errorf("failed to create in-memory schema: %w", err)
+	}
+	return nil
+}
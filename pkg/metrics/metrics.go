@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Admission control (API backpressure)
+var (
+	// AdmissionDecisions counts every accept/shed/reject decision taken for new orders
+	AdmissionDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "order_admission_decisions_total",
+		Help: "Number of order admission decisions by outcome and reason.",
+	}, []string{"decision", "reason"})
+
+	// TasksShed counts low-priority tasks that were skipped while shedding load
+	TasksShed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "order_tasks_shed_total",
+		Help: "Number of background tasks not enqueued because of load shedding.",
+	}, []string{"task_type"})
+
+	// QueueBacklog is the cached number of unfinished tasks per Asynq queue
+	QueueBacklog = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "asynq_queue_backlog",
+		Help: "Pending, scheduled, active, retry and aggregating tasks per queue.",
+	}, []string{"queue"})
+
+	// QueueLatency is the cached age of the oldest pending task per Asynq queue
+	QueueLatency = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "asynq_queue_latency_seconds",
+		Help: "Age of the oldest pending task per queue.",
+	}, []string{"queue"})
+)
+
+// HTTP admission (rate limiting)
+var (
+	// RateLimitDecisions counts rate-limited and allowed requests per route group
+	RateLimitDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_rate_limit_decisions_total",
+		Help: "Number of rate limit decisions by route group, limiting scope and result.",
+	}, []string{"group", "scope", "result"})
+)
+
+// Order validation
+var (
+	// OrdersRejected counts orders refused with 422 (unknown product, wrong price, out of stock)
+	OrdersRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "orders_rejected_total",
+		Help: "Number of orders refused at creation, by the first item error code.",
+	}, []string{"reason"})
+)
+
+// Worker
+var (
+	// TaskDuration observes how long task handlers run, including failed runs
+	TaskDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "asynq_task_duration_seconds",
+		Help:    "Duration of task handler runs by task type and result (success, error, permanent).",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"task_type", "result"})
+)
+
+// Analytics
+var (
+	// AnalyticsBatchSize observes how many events each analytics write carries
+	AnalyticsBatchSize = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "analytics_batch_size",
+		Help:    "Number of analytics events written per batch.",
+		Buckets: []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000},
+	})
+
+	// AnalyticsEventLatency observes the time from enqueueing an analytics event to writing it
+	AnalyticsEventLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "analytics_event_latency_seconds",
+		Help:    "Time from enqueueing an analytics event to writing it to the analytics store.",
+		Buckets: []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	})
+)
+
+// Reconciliation
+var (
+	// OrdersReconciled counts stuck orders found by orders:reconcile and what was done with them
+	OrdersReconciled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "orders_reconciled_total",
+		Help: "Number of stuck orders checked by status and action (waiting, requeued, failed, error).",
+	}, []string{"status", "action"})
+)
+
+// Handler returns the HTTP handler serving metrics in Prometheus format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
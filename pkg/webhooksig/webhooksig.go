@@ -0,0 +1,62 @@
+// Package webhooksig signs and verifies webhook payloads with HMAC-SHA256.
+//
+// The signature covers "<timestamp>.<body>" so a captured request cannot be
+// replayed with a different timestamp.
+package webhooksig
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTP headers sent with every delivery
+const (
+	HeaderID        = "X-Webhook-ID"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Verification errors
+var (
+	ErrInvalidTimestamp = errors.New("invalid webhook timestamp")
+	ErrExpired          = errors.New("webhook timestamp outside tolerance")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// Sign returns the signature header value ("sha256=<hex>")
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the timestamp and signature headers against the raw body.
+// A zero tolerance skips the timestamp age check.
+func Verify(secret, timestampHeader, signatureHeader string, body []byte, tolerance time.Duration) error {
+	timestamp, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+
+	if tolerance > 0 {
+		age := time.Since(time.Unix(timestamp, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrExpired
+		}
+	}
+
+	expected := Sign(secret, timestamp, body)
+	if !strings.HasPrefix(signatureHeader, "sha256=") ||
+		!hmac.Equal([]byte(expected), []byte(signatureHeader)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}